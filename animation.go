@@ -0,0 +1,668 @@
+package shirei
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+// -----------------------------------------------------------------------------
+//      Animated images
+// -----------------------------------------------------------------------------
+// GIF, APNG and animated WebP files are composed into full frames, each its
+// own image handle, when they fit in maxComposedAnimation bytes; bigger ones
+// keep the decoded frames and compose each as it's shown. Big files are
+// decoded in the background, like LoadImage does.
+//
+// Playing advances at the start of the frame, and only animations drawn (and
+// not clipped away) in the last frame advance or ask for frames.
+
+type ImageAnimation struct {
+	Frames []ImageId // nil while loading, and for ones composed as shown
+	Delays []time.Duration
+	Width  int
+	Height int
+
+	// how many times it plays; zero is forever
+	Loops int
+
+	paused  bool
+	frame   int
+	played  int
+	elapsed time.Duration // into the current frame
+	clock   time.Time
+
+	advancedFrame int64
+	visibleFrame  int64
+
+	composed []*image.RGBA // made off the frame thread, waiting for handles
+	composer *animComposer // when the frames are composed as shown
+}
+
+// more than this for all the composed frames, and they're composed as they're
+// shown instead
+const maxComposedAnimation = 64 << 20
+
+// bigger canvases are taken for malformed files rather than allocated
+const maxAnimationPixels = 1 << 26
+
+func canvasFits(width, height int) bool {
+	return width > 0 && height > 0 && width*height <= maxAnimationPixels
+}
+
+// a frame must be on the canvas, which keeps it from being too big to decode
+func frameFits(rect image.Rectangle, width, height int) bool {
+	return !rect.Empty() && rect.In(image.Rect(0, 0, width, height))
+}
+
+var visibleAnimations []*ImageAnimation
+
+// the current frame
+func (a *ImageAnimation) Frame() ImageId {
+	if a.composer != nil {
+		return a.composer.show(a.frame)
+	}
+	if len(a.Frames) == 0 {
+		return 0
+	}
+	return a.Frames[a.frame]
+}
+
+func (a *ImageAnimation) Playing() bool {
+	return !a.paused && !a.finished()
+}
+
+func (a *ImageAnimation) finished() bool {
+	return a.Loops > 0 && a.played >= a.Loops
+}
+
+func (a *ImageAnimation) Play() {
+	if a.finished() {
+		a.Restart()
+	}
+	a.paused = false
+	RequestNextFrame()
+}
+
+func (a *ImageAnimation) Pause() {
+	a.paused = true
+}
+
+func (a *ImageAnimation) Restart() {
+	a.frame, a.played, a.elapsed = 0, 0, 0
+	RequestNextFrame()
+}
+
+// Release frees the frames; the animation can't be drawn after
+func (a *ImageAnimation) Release() {
+	for _, id := range a.Frames {
+		ReleaseImage(id)
+	}
+	a.Frames = nil
+	a.Delays = nil
+	if a.composer != nil {
+		ReleaseImage(a.composer.id)
+		a.composer = nil
+	}
+}
+
+func (a *ImageAnimation) markVisible() {
+	if a.visibleFrame != FrameNumber {
+		a.visibleFrame = FrameNumber
+		visibleAnimations = append(visibleAnimations, a)
+	}
+}
+
+// runs at the start of the frame for the animations seen in the last one
+func advanceAnimations() {
+	for _, a := range visibleAnimations {
+		a.advance(frameStart)
+	}
+	clear(visibleAnimations)
+	visibleAnimations = visibleAnimations[:0]
+}
+
+func (a *ImageAnimation) advance(now time.Time) {
+	if len(a.Delays) < 2 || !a.Playing() {
+		return
+	}
+	if a.advancedFrame != FrameNumber-1 {
+		// it was paused or out of sight; go on from here
+		a.clock = now
+	}
+	a.advancedFrame = FrameNumber
+	a.elapsed += now.Sub(a.clock)
+	a.clock = now
+
+	var total time.Duration
+	for _, d := range a.Delays {
+		total += d
+	}
+	if a.Loops == 0 && a.elapsed > total {
+		a.elapsed %= total
+	}
+	for a.elapsed >= a.Delays[a.frame] {
+		a.elapsed -= a.Delays[a.frame]
+		a.frame++
+		if a.frame == len(a.Delays) {
+			a.played++
+			if a.finished() {
+				a.frame, a.elapsed = len(a.Delays)-1, 0
+				return
+			}
+			a.frame = 0
+		}
+	}
+	RequestFrameAfter(a.Delays[a.frame] - a.elapsed)
+}
+
+// gives the frames composed off the frame thread their handles
+func (a *ImageAnimation) register() {
+	for _, img := range a.composed {
+		a.Frames = append(a.Frames, RegisterImage(img))
+	}
+	a.composed = nil
+}
+
+var animationByPath = make(map[string]*ImageAnimation)
+
+// LoadImageAnimation loads any image file; ones that aren't animated have a
+// single frame. Returns nil when the file can't be decoded. Big files have no
+// frames until they're decoded in the background, but their size is known.
+func LoadImageAnimation(fpath string) *ImageAnimation {
+	const key = "animation"
+	anim, found := _getFileCacheContent[*ImageAnimation](fpath, key)
+	if found {
+		return anim
+	}
+
+	var content = ReadFileContent(fpath)
+	if len(content) < backgroundDecodeSize {
+		anim, _ = DecodeImageAnimation(content)
+	} else if cfg, _, err := image.DecodeConfig(bytes.NewReader(content)); err == nil {
+		anim = &ImageAnimation{Width: cfg.Width, Height: cfg.Height}
+		go func() {
+			decoded, err := decodeAnimation(content)
+			if err != nil {
+				return
+			}
+			WithFrameLock(func() {
+				if animationByPath[fpath] != anim {
+					return // the file changed since
+				}
+				decoded.register()
+				anim.Frames, anim.Delays, anim.Loops = decoded.Frames, decoded.Delays, decoded.Loops
+				anim.Width, anim.Height = decoded.Width, decoded.Height
+				anim.composer = decoded.composer
+				RequestNextFrame()
+			})
+		}()
+	}
+	_setFileCacheContent(fpath, key, anim)
+
+	// a changed file replaces the old frames
+	if old := animationByPath[fpath]; old != nil {
+		old.Release()
+	}
+	animationByPath[fpath] = anim
+	return anim
+}
+
+func DecodeImageAnimation(content []byte) (*ImageAnimation, error) {
+	anim, err := decodeAnimation(content)
+	if err != nil {
+		return nil, err
+	}
+	anim.register()
+	return anim, nil
+}
+
+// decodes and composes without touching image handles, so it can run off the
+// frame thread
+func decodeAnimation(content []byte) (*ImageAnimation, error) {
+	var width, height, loops int
+	var frames []animFrame
+	var err error
+	switch {
+	case bytes.HasPrefix(content, []byte("GIF8")):
+		frames, width, height, loops, err = decodeGIFFrames(content)
+	case bytes.HasPrefix(content, pngSignature):
+		frames, width, height, loops, err = decodeAPNGFrames(content)
+	case len(content) >= 12 && string(content[:4]) == "RIFF" && string(content[8:12]) == "WEBP":
+		frames, width, height, loops, err = decodeWebPFrames(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if frames != nil && !canvasFits(width, height) {
+		return nil, errBadAnimation
+	}
+	if frames == nil {
+		// not animated
+		decoded, _, err := image.Decode(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		var rgba = imageToRGBA(decoded)
+		return &ImageAnimation{composed: []*image.RGBA{rgba}, Delays: []time.Duration{0}, Width: rgba.Rect.Dx(), Height: rgba.Rect.Dy()}, nil
+	}
+	return composeAnimation(frames, width, height, loops), nil
+}
+
+// AnimatedImage draws an image file, animated if it is; like Image
+func AnimatedImage(fpath string, maxSize Vec2) {
+	var attrs = DefaultImageAttrs()
+	attrs.MaxSize = maxSize
+	AnimatedImageExt(LoadImageAnimation(fpath), attrs)
+}
+
+func AnimatedImageExt(anim *ImageAnimation, attrs ImageAttrs) {
+	if anim == nil {
+		return
+	}
+	// the size is known before the frames are
+	imageBox(Vec2{f32(anim.Width), f32(anim.Height)}, anim.Frame(), attrs, anim)
+}
+
+// ---- composing ----
+
+type animDispose uint8
+
+const (
+	disposeNone animDispose = iota
+	disposeBackground
+	disposePrevious
+)
+
+type animFrame struct {
+	img     image.Image
+	rect    image.Rectangle // where it goes on the canvas
+	delay   time.Duration
+	dispose animDispose // what happens to its area after it's shown
+	blend   bool        // drawn over the canvas instead of replacing its area
+}
+
+// draws the frames in order on a canvas
+type animComposer struct {
+	frames   []animFrame
+	canvas   *image.RGBA
+	previous *image.RGBA // the canvas before a frame that's disposed to it
+	next     int         // the frame drawn next
+
+	// for composing as shown: the frame on the canvas, and its handle
+	shown int
+	id    ImageId
+}
+
+func newAnimComposer(frames []animFrame, width, height int) *animComposer {
+	var canvas = image.NewRGBA(image.Rect(0, 0, width, height))
+	return &animComposer{frames: frames, canvas: canvas, previous: image.NewRGBA(canvas.Rect), shown: -1}
+}
+
+// draws the next frame, after disposing of the one before it
+func (c *animComposer) step() {
+	if c.next == 0 {
+		clear(c.canvas.Pix)
+	} else {
+		var last = c.frames[c.next-1]
+		switch last.dispose {
+		case disposeBackground:
+			draw.Draw(c.canvas, last.rect, image.Transparent, image.Point{}, draw.Src)
+		case disposePrevious:
+			copy(c.canvas.Pix, c.previous.Pix)
+		}
+	}
+	var frame = c.frames[c.next]
+	if frame.dispose == disposePrevious {
+		copy(c.previous.Pix, c.canvas.Pix)
+	}
+	var op = draw.Src
+	if frame.blend {
+		op = draw.Over
+	}
+	draw.Draw(c.canvas, frame.rect, frame.img, frame.img.Bounds().Min, op)
+	c.next++
+}
+
+func (c *animComposer) snapshot() *image.RGBA {
+	var shown = image.NewRGBA(c.canvas.Rect)
+	copy(shown.Pix, c.canvas.Pix)
+	return shown
+}
+
+// the handle of frame index, composing it when it's not the one shown; going
+// back starts over from the first frame
+func (c *animComposer) show(index int) ImageId {
+	if index == c.shown {
+		return c.id
+	}
+	if index < c.next-1 {
+		c.next = 0
+	}
+	for c.next <= index {
+		c.step()
+	}
+	c.shown = index
+	c.id = ReplaceImage(c.id, c.snapshot())
+	return c.id
+}
+
+func composeAnimation(frames []animFrame, width, height, loops int) *ImageAnimation {
+	var anim = &ImageAnimation{Width: width, Height: height, Loops: loops}
+	for _, frame := range frames {
+		// like browsers, very short delays are slowed down
+		var delay = frame.delay
+		if delay <= 10*time.Millisecond {
+			delay = 100 * time.Millisecond
+		}
+		anim.Delays = append(anim.Delays, delay)
+	}
+
+	var composer = newAnimComposer(frames, width, height)
+	if width*height*4*len(frames) > maxComposedAnimation {
+		anim.composer = composer
+		return anim
+	}
+	for range frames {
+		composer.step()
+		anim.composed = append(anim.composed, composer.snapshot())
+	}
+	return anim
+}
+
+// ---- gif ----
+
+func decodeGIFFrames(content []byte) (frames []animFrame, width, height, loops int, err error) {
+	g, err := gif.DecodeAll(bytes.NewReader(content))
+	if err != nil || len(g.Image) < 2 {
+		return nil, 0, 0, 0, err
+	}
+	for i, img := range g.Image {
+		var frame = animFrame{img: img, rect: img.Bounds(), delay: time.Duration(g.Delay[i]) * 10 * time.Millisecond, blend: true}
+		switch g.Disposal[i] {
+		case gif.DisposalBackground:
+			frame.dispose = disposeBackground
+		case gif.DisposalPrevious:
+			frame.dispose = disposePrevious
+		}
+		frames = append(frames, frame)
+	}
+	// gif counts the repeats, with -1 for none
+	switch {
+	case g.LoopCount < 0:
+		loops = 1
+	case g.LoopCount > 0:
+		loops = g.LoopCount + 1
+	}
+	return frames, g.Config.Width, g.Config.Height, loops, nil
+}
+
+// ---- apng ----
+// every frame is made into a png of its own for image/png to decode
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var errBadAnimation = errors.New("malformed animated image")
+
+func writePNGChunk(buf *bytes.Buffer, kind string, data []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	var crc = crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	buf.WriteString(kind)
+	buf.Write(data)
+	binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
+
+func decodeAPNGFrames(content []byte) (frames []animFrame, width, height, loops int, err error) {
+	type pngChunk struct {
+		kind string
+		data []byte
+	}
+	type apngFrame struct {
+		control []byte // the fcTL
+		data    [][]byte
+	}
+	var ihdr []byte
+	var shared []pngChunk // chunks every frame needs, like the palette
+	var animated bool
+	var seenData bool
+	var list []*apngFrame
+	var current *apngFrame
+
+	var rest = content[len(pngSignature):]
+	for len(rest) >= 12 {
+		var size = binary.BigEndian.Uint32(rest)
+		if uint64(size)+12 > uint64(len(rest)) {
+			return nil, 0, 0, 0, errBadAnimation
+		}
+		var kind = string(rest[4:8])
+		var data = rest[8 : 8+size]
+		rest = rest[12+size:]
+
+		switch kind {
+		case "IHDR":
+			ihdr = data
+		case "acTL":
+			if len(data) < 8 {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			animated = true
+			loops = int(binary.BigEndian.Uint32(data[4:]))
+		case "fcTL":
+			if len(data) < 26 {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			current = &apngFrame{control: data}
+			list = append(list, current)
+		case "IDAT":
+			seenData = true
+			// the default image is only a frame when a fcTL comes before it
+			if current != nil {
+				current.data = append(current.data, data)
+			}
+		case "fdAT":
+			if current == nil || len(data) < 4 {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			current.data = append(current.data, data[4:])
+		case "IEND":
+		default:
+			if !seenData {
+				shared = append(shared, pngChunk{kind, data})
+			}
+		}
+	}
+	if !animated || len(ihdr) < 13 || len(list) < 2 {
+		return nil, 0, 0, 0, nil
+	}
+	width = int(binary.BigEndian.Uint32(ihdr[0:]))
+	height = int(binary.BigEndian.Uint32(ihdr[4:]))
+	if !canvasFits(width, height) {
+		return nil, 0, 0, 0, errBadAnimation
+	}
+
+	for i, f := range list {
+		var c = f.control
+		var w, h = binary.BigEndian.Uint32(c[4:]), binary.BigEndian.Uint32(c[8:])
+		var x, y = int(binary.BigEndian.Uint32(c[12:])), int(binary.BigEndian.Uint32(c[16:]))
+		var num, den = binary.BigEndian.Uint16(c[20:]), binary.BigEndian.Uint16(c[22:])
+		var rect = image.Rect(x, y, x+int(w), y+int(h))
+		if !frameFits(rect, width, height) {
+			return nil, 0, 0, 0, errBadAnimation
+		}
+		if den == 0 {
+			den = 100
+		}
+
+		var buf bytes.Buffer
+		buf.Write(pngSignature)
+		var header = bytes.Clone(ihdr)
+		binary.BigEndian.PutUint32(header[0:], w)
+		binary.BigEndian.PutUint32(header[4:], h)
+		writePNGChunk(&buf, "IHDR", header)
+		for _, chunk := range shared {
+			writePNGChunk(&buf, chunk.kind, chunk.data)
+		}
+		for _, data := range f.data {
+			writePNGChunk(&buf, "IDAT", data)
+		}
+		writePNGChunk(&buf, "IEND", nil)
+		img, err := png.Decode(&buf)
+		if err != nil {
+			return nil, 0, 0, 0, err
+		}
+
+		var frame = animFrame{
+			img:   img,
+			rect:  rect,
+			delay: time.Duration(num) * time.Second / time.Duration(den),
+			blend: c[25] == 1,
+		}
+		switch c[24] {
+		case 1:
+			frame.dispose = disposeBackground
+		case 2:
+			frame.dispose = disposePrevious
+			if i == 0 {
+				frame.dispose = disposeBackground
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames, width, height, loops, nil
+}
+
+// ---- webp ----
+// every frame is made into a webp of its own for x/image/webp to decode
+
+func u24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+func putU24(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+type riffChunk struct {
+	kind string
+	data []byte
+}
+
+func readRIFFChunks(data []byte) ([]riffChunk, error) {
+	var chunks []riffChunk
+	for len(data) >= 8 {
+		var size = binary.LittleEndian.Uint32(data[4:])
+		if uint64(size)+8 > uint64(len(data)) {
+			return nil, errBadAnimation
+		}
+		chunks = append(chunks, riffChunk{string(data[:4]), data[8 : 8+size]})
+		data = data[8+size:]
+		if size%2 == 1 && len(data) > 0 {
+			data = data[1:]
+		}
+	}
+	return chunks, nil
+}
+
+func writeRIFFChunk(buf *bytes.Buffer, kind string, data []byte) {
+	buf.WriteString(kind)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func decodeWebPFrames(content []byte) (frames []animFrame, width, height, loops int, err error) {
+	chunks, err := readRIFFChunks(content[12:])
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	for _, chunk := range chunks {
+		var d = chunk.data
+		switch chunk.kind {
+		case "VP8X":
+			if len(d) < 10 {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			width, height = u24(d[4:])+1, u24(d[7:])+1
+		case "ANIM":
+			if len(d) < 6 {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			loops = int(binary.LittleEndian.Uint16(d[4:]))
+		case "ANMF":
+			if len(d) < 16 {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			var x, y = u24(d[0:]) * 2, u24(d[3:]) * 2
+			var w, h = u24(d[6:]) + 1, u24(d[9:]) + 1
+			if !canvasFits(width, height) || !frameFits(image.Rect(x, y, x+w, y+h), width, height) {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			var frame = animFrame{
+				rect:  image.Rect(x, y, x+w, y+h),
+				delay: time.Duration(u24(d[12:])) * time.Millisecond,
+				blend: d[15]&2 == 0,
+			}
+			if d[15]&1 != 0 {
+				frame.dispose = disposeBackground
+			}
+			sub, err := readRIFFChunks(d[16:])
+			if err != nil {
+				return nil, 0, 0, 0, err
+			}
+
+			var body bytes.Buffer
+			body.WriteString("WEBP")
+			var alpha []byte
+			for _, c := range sub {
+				if c.kind == "ALPH" {
+					alpha = c.data
+				}
+			}
+			if alpha != nil {
+				var header = make([]byte, 10)
+				header[0] = 1 << 4 // has alpha
+				putU24(header[4:], w-1)
+				putU24(header[7:], h-1)
+				writeRIFFChunk(&body, "VP8X", header)
+				writeRIFFChunk(&body, "ALPH", alpha)
+			}
+			for _, c := range sub {
+				if c.kind == "VP8 " || c.kind == "VP8L" {
+					writeRIFFChunk(&body, c.kind, c.data)
+				}
+			}
+			var file bytes.Buffer
+			file.WriteString("RIFF")
+			binary.Write(&file, binary.LittleEndian, uint32(body.Len()))
+			file.Write(body.Bytes())
+			config, err := webp.DecodeConfig(bytes.NewReader(file.Bytes()))
+			if err != nil {
+				return nil, 0, 0, 0, err
+			}
+			if config.Width != w || config.Height != h {
+				return nil, 0, 0, 0, errBadAnimation
+			}
+			frame.img, err = webp.Decode(&file)
+			if err != nil {
+				return nil, 0, 0, 0, err
+			}
+			frames = append(frames, frame)
+		}
+	}
+	if len(frames) < 2 {
+		return nil, 0, 0, 0, nil
+	}
+	return frames, width, height, loops, nil
+}
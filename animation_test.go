@@ -0,0 +1,252 @@
+package shirei
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// ---- fixtures ----
+
+func solidImage(w, h int, c color.NRGBA) *image.NRGBA {
+	var img = image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+// the chunks of a png, minus the signature
+func pngChunks(t *testing.T, img image.Image) map[string][]byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	var chunks = make(map[string][]byte)
+	var rest = buf.Bytes()[len(pngSignature):]
+	for len(rest) >= 12 {
+		var size = binary.BigEndian.Uint32(rest)
+		var kind = string(rest[4:8])
+		chunks[kind] = append(chunks[kind], rest[8:8+size]...)
+		rest = rest[12+size:]
+	}
+	return chunks
+}
+
+func fcTL(seq, w, h, x, y uint32, delayNum, delayDen uint16, dispose, blend byte) []byte {
+	var c = make([]byte, 26)
+	for i, v := range []uint32{seq, w, h, x, y} {
+		binary.BigEndian.PutUint32(c[4*i:], v)
+	}
+	binary.BigEndian.PutUint16(c[20:], delayNum)
+	binary.BigEndian.PutUint16(c[22:], delayDen)
+	c[24], c[25] = dispose, blend
+	return c
+}
+
+// 4x4, red then a green 2x2 in the bottom right corner over it, playing twice
+func apngFixture(t *testing.T) []byte {
+	var first = pngChunks(t, solidImage(4, 4, color.NRGBA{255, 0, 0, 255}))
+	var second = pngChunks(t, solidImage(2, 2, color.NRGBA{0, 255, 0, 255}))
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	writePNGChunk(&buf, "IHDR", first["IHDR"])
+	writePNGChunk(&buf, "acTL", []byte{0, 0, 0, 2, 0, 0, 0, 2})
+	writePNGChunk(&buf, "fcTL", fcTL(0, 4, 4, 0, 0, 1, 10, 0, 0))
+	writePNGChunk(&buf, "IDAT", first["IDAT"])
+	writePNGChunk(&buf, "fcTL", fcTL(1, 2, 2, 2, 2, 50, 1000, 0, 0))
+	writePNGChunk(&buf, "fdAT", append([]byte{0, 0, 0, 2}, second["IDAT"]...))
+	writePNGChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+// a lossless webp bitstream of one color: every prefix code has one symbol,
+// so the pixels take no bits at all
+func vp8lSolid(w, h int, c color.NRGBA) []byte {
+	var out []byte
+	var acc uint64
+	var n uint
+	put := func(v uint64, bits uint) {
+		acc |= v << n
+		n += bits
+		for n >= 8 {
+			out = append(out, byte(acc))
+			acc >>= 8
+			n -= 8
+		}
+	}
+	put(0x2f, 8)
+	put(uint64(w-1), 14)
+	put(uint64(h-1), 14)
+	put(1, 1) // has alpha
+	put(0, 3) // version
+	put(0, 1) // no transforms
+	put(0, 1) // no color cache
+	put(0, 1) // no meta prefix codes
+	for _, symbol := range []uint8{c.G, c.R, c.B, c.A} {
+		put(1, 1) // simple code
+		put(0, 1) // one symbol
+		put(1, 1) // of 8 bits
+		put(uint64(symbol), 8)
+	}
+	put(1, 1) // distances: the one symbol 0, of 1 bit
+	put(0, 1)
+	put(0, 1)
+	put(0, 7) // flush
+	return out
+}
+
+func anmf(x, y, w, h, delay int, flags byte, frame []byte) []byte {
+	var d = make([]byte, 16)
+	putU24(d[0:], x/2)
+	putU24(d[3:], y/2)
+	putU24(d[6:], w-1)
+	putU24(d[9:], h-1)
+	putU24(d[12:], delay)
+	d[15] = flags
+	var buf = bytes.NewBuffer(d)
+	writeRIFFChunk(buf, "VP8L", frame)
+	return buf.Bytes()
+}
+
+func riffWebP(chunks ...riffChunk) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, c := range chunks {
+		writeRIFFChunk(&body, c.kind, c.data)
+	}
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	binary.Write(&file, binary.LittleEndian, uint32(body.Len()))
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+// same as the apng, except the second frame is blue and it plays forever
+func webpFixture() []byte {
+	var vp8x = make([]byte, 10)
+	vp8x[0] = 1<<4 | 1<<1 // alpha, animation
+	putU24(vp8x[4:], 3)
+	putU24(vp8x[7:], 3)
+	return riffWebP(
+		riffChunk{"VP8X", vp8x},
+		riffChunk{"ANIM", make([]byte, 6)},
+		riffChunk{"ANMF", anmf(0, 0, 4, 4, 100, 2, vp8lSolid(4, 4, color.NRGBA{255, 0, 0, 255}))},
+		riffChunk{"ANMF", anmf(2, 2, 2, 2, 50, 2, vp8lSolid(2, 2, color.NRGBA{0, 0, 255, 255}))},
+	)
+}
+
+// ---- tests ----
+
+func checkAnimation(t *testing.T, name string, content []byte, loops int, second color.RGBA) {
+	anim, err := decodeAnimation(content)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if anim.Width != 4 || anim.Height != 4 || anim.Loops != loops {
+		t.Errorf("%s: got %dx%d playing %d times, want 4x4 playing %d times", name, anim.Width, anim.Height, anim.Loops, loops)
+	}
+	var delays = []time.Duration{100 * time.Millisecond, 50 * time.Millisecond}
+	if len(anim.composed) != 2 || len(anim.Delays) != 2 || anim.Delays[0] != delays[0] || anim.Delays[1] != delays[1] {
+		t.Fatalf("%s: got %d frames with delays %v, want 2 with %v", name, len(anim.composed), anim.Delays, delays)
+	}
+	var red = color.RGBA{255, 0, 0, 255}
+	var checks = []struct {
+		frame int
+		x, y  int
+		want  color.RGBA
+	}{
+		{0, 0, 0, red}, {0, 3, 3, red},
+		{1, 0, 0, red}, {1, 1, 1, red}, {1, 2, 2, second}, {1, 3, 3, second},
+	}
+	for _, c := range checks {
+		if got := anim.composed[c.frame].RGBAAt(c.x, c.y); got != c.want {
+			t.Errorf("%s: frame %d at %d,%d is %v, want %v", name, c.frame, c.x, c.y, got, c.want)
+		}
+	}
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	checkAnimation(t, "apng", apngFixture(t), 2, color.RGBA{0, 255, 0, 255})
+}
+
+func TestDecodeAnimatedWebP(t *testing.T) {
+	checkAnimation(t, "webp", webpFixture(), 0, color.RGBA{0, 0, 255, 255})
+}
+
+func TestDecodeMalformedAnimations(t *testing.T) {
+	var apng = apngFixture(t)
+	var webp = webpFixture()
+
+	// chunks that are cut short, too short for what they hold, or out of place
+	var cases = []struct {
+		name    string
+		content []byte
+	}{
+		{"apng cut in a chunk", apng[:len(apng)-20]},
+		{"webp cut in a chunk", webp[:len(webp)-5]},
+		{"apng short acTL", func() []byte {
+			var buf bytes.Buffer
+			buf.Write(apng[:33]) // signature and IHDR
+			writePNGChunk(&buf, "acTL", []byte{0, 0, 0, 2})
+			return buf.Bytes()
+		}()},
+		{"apng short fcTL", func() []byte {
+			var buf bytes.Buffer
+			buf.Write(apng[:33])
+			writePNGChunk(&buf, "fcTL", make([]byte, 10))
+			return buf.Bytes()
+		}()},
+		{"apng fdAT before any fcTL", func() []byte {
+			var buf bytes.Buffer
+			buf.Write(apng[:33])
+			writePNGChunk(&buf, "fdAT", make([]byte, 8))
+			return buf.Bytes()
+		}()},
+		{"apng frame outside the canvas", func() []byte {
+			var bad = bytes.Clone(apng)
+			var at = bytes.LastIndex(bad, []byte("fcTL")) + 4
+			binary.BigEndian.PutUint32(bad[at+12:], 3) // x
+			return bad
+		}()},
+		{"apng huge canvas", func() []byte {
+			var bad = bytes.Clone(apng)
+			binary.BigEndian.PutUint32(bad[16:], 1<<30) // IHDR width
+			return bad
+		}()},
+		{"webp short VP8X", riffWebP(riffChunk{"VP8X", make([]byte, 4)})},
+		{"webp short ANIM", riffWebP(riffChunk{"ANIM", make([]byte, 2)})},
+		{"webp short ANMF", riffWebP(riffChunk{"ANMF", make([]byte, 8)})},
+		{"webp huge canvas", func() []byte {
+			var vp8x = make([]byte, 10)
+			putU24(vp8x[4:], 1<<24-1)
+			putU24(vp8x[7:], 1<<24-1)
+			var frame = vp8lSolid(1, 1, color.NRGBA{})
+			return riffWebP(
+				riffChunk{"VP8X", vp8x},
+				riffChunk{"ANMF", anmf(0, 0, 1, 1, 100, 0, frame)},
+				riffChunk{"ANMF", anmf(0, 0, 1, 1, 100, 0, frame)},
+			)
+		}()},
+	}
+	for _, c := range cases {
+		if _, err := decodeAnimation(c.content); err != errBadAnimation {
+			t.Errorf("%s: got error %v, want %v", c.name, err, errBadAnimation)
+		}
+	}
+
+	// cut anywhere else, decoding fails without panicking; the apng only
+	// needs what comes before its IEND
+	for _, content := range [][]byte{apng[:len(apng)-12], webp} {
+		for n := 12; n < len(content); n++ {
+			if _, err := decodeAnimation(content[:n]); err == nil {
+				t.Errorf("decoding %q cut at %d didn't fail", content[:4], n)
+			}
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package shirei
+
+import "math"
+
+type BorderStyle uint8
+
+const (
+	BorderSolid BorderStyle = iota
+	BorderDashed
+	BorderDotted
+)
+
+// where a border goes relative to the edge of its container
+type StrokeAlign uint8
+
+const (
+	StrokeCenter StrokeAlign = iota
+	StrokeInside
+	StrokeOutside
+)
+
+type Border struct {
+	BorderColor Vec4
+	BorderWidth f32
+
+	// widths per side, css order: top right bottom left; when any is set they
+	// are used instead of BorderWidth
+	BorderSides Vec4
+	// colors per side (see SetSideColor); sides without one use BorderColor
+	BorderSideColors BorderColorsId
+
+	BorderStyle BorderStyle
+	BorderAlign StrokeAlign
+	BorderDash  Vec2 // dash and gap lengths of dashed borders; zero picks them from the width
+}
+
+func (b *Border) sideWidths() Vec4 {
+	if b.BorderSides != (Vec4{}) {
+		return b.BorderSides
+	}
+	return N4(b.BorderWidth)
+}
+
+type BorderColorsId uint32
+
+// colors per side, and which sides have one
+type borderColors struct {
+	colors [4]Vec4
+	set    uint8 // a bit per side
+}
+
+// kept with the frame like gradients, so attrs without them stay small.
+// reset every frame; the zero id is no colors
+var frameBorderColors = make([]borderColors, 1, 16)
+
+func resetBorderColors() {
+	frameBorderColors = frameBorderColors[:1]
+}
+
+func (b *Border) sideColors() borderColors {
+	if int(b.BorderSideColors) >= len(frameBorderColors) {
+		return borderColors{} // from another frame
+	}
+	return frameBorderColors[b.BorderSideColors]
+}
+
+// SetSideColor colors one side (css order: top right bottom left), where a
+// transparent color hides it; the other sides keep theirs. Like gradients,
+// it's only good in the frame it's set in.
+func (b *Border) SetSideColor(side int, c Vec4) {
+	var entry = b.sideColors()
+	entry.colors[side] = c
+	entry.set |= 1 << side
+	frameBorderColors = append(frameBorderColors, entry)
+	b.BorderSideColors = BorderColorsId(len(frameBorderColors) - 1)
+}
+
+func (b *Border) sideColor(side int) Vec4 {
+	if entry := b.sideColors(); entry.set&(1<<side) != 0 {
+		return entry.colors[side]
+	}
+	return b.BorderColor
+}
+
+func (b *Border) hasBorder() bool {
+	var w = b.sideWidths()
+	return w[0] > 0 || w[1] > 0 || w[2] > 0 || w[3] > 0
+}
+
+// a plain border is drawn by the backend as one centered stroke
+func (b *Border) isPlain() bool {
+	return b.BorderSides == (Vec4{}) && b.BorderSideColors == 0 && b.BorderStyle == BorderSolid
+}
+
+// the rect and corners for a plain stroke, moved by the alignment
+func plainBorderRect(rect Rect, corners Vec4, b *Border) (Rect, Vec4) {
+	var shift f32
+	switch b.BorderAlign {
+	case StrokeInside:
+		shift = -b.BorderWidth / 2
+	case StrokeOutside:
+		shift = b.BorderWidth / 2
+	default:
+		return rect, corners
+	}
+	rect.Origin = Vec2Sub(rect.Origin, Vec2{shift, shift})
+	rect.Size = Vec2Add(rect.Size, Vec2{shift * 2, shift * 2})
+	for i, r := range corners {
+		if r > 0 {
+			corners[i] = max(0, r+shift)
+		}
+	}
+	return rect, corners
+}
+
+// the outline of a box with elliptic corners, as points per corner (tl, tr,
+// br, bl) going clockwise. Every corner has the same odd number of points,
+// so the middle one is at 45 degrees.
+type cornerPoints [4][]Vec2
+
+const cornerSteps = 8
+
+func boxCorners(box Rect, radii [4]Vec2) cornerPoints {
+	var out cornerPoints
+	var end = Vec2Add(box.Origin, box.Size)
+	var points = [4]Vec2{box.Origin, {end[0], box.Origin[1]}, end, {box.Origin[0], end[1]}}
+	// towards the inside from each corner
+	var inward = [4]Vec2{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+	for i := range 4 {
+		var r = radii[i]
+		r[0] = max(0, min(r[0], box.Size[0]/2))
+		r[1] = max(0, min(r[1], box.Size[1]/2))
+		out[i] = make([]Vec2, cornerSteps+1)
+		if r[0] == 0 || r[1] == 0 {
+			for j := range out[i] {
+				out[i][j] = points[i]
+			}
+			continue
+		}
+		var center = Vec2{points[i][0] + inward[i][0]*r[0], points[i][1] + inward[i][1]*r[1]}
+		for j := range cornerSteps + 1 {
+			var a = math.Pi + float64(i)*math.Pi/2 + float64(j)*math.Pi/2/cornerSteps
+			out[i][j] = Vec2{center[0] + r[0]*f32(math.Cos(a)), center[1] + r[1]*f32(math.Sin(a))}
+		}
+	}
+	return out
+}
+
+func insetRect(r Rect, sides Vec4, k f32) Rect {
+	r.Origin[0] += sides[3] * k
+	r.Origin[1] += sides[0] * k
+	r.Size[0] = max(0, r.Size[0]-(sides[1]+sides[3])*k)
+	r.Size[1] = max(0, r.Size[1]-(sides[0]+sides[2])*k)
+	return r
+}
+
+// the widths of the sides meeting at each corner, as x (the vertical side)
+// and y (the horizontal one)
+func cornerWidths(sides Vec4) [4]Vec2 {
+	return [4]Vec2{
+		{sides[3], sides[0]},
+		{sides[1], sides[0]},
+		{sides[1], sides[2]},
+		{sides[3], sides[2]},
+	}
+}
+
+// pushBorderSurfaces draws what the backend's single centered stroke can't:
+// per-side widths and colors, dashes and dots, as path surfaces
+func pushBorderSurfaces(rect Rect, corners Vec4, b *Border) {
+	var sides = b.sideWidths()
+	var widths = cornerWidths(sides)
+
+	// the outer edge, by the alignment
+	var k f32
+	switch b.BorderAlign {
+	case StrokeCenter:
+		k = 0.5
+	case StrokeOutside:
+		k = 1
+	}
+	var local = Rect{Size: rect.Size}
+	var outer = insetRect(local, sides, -k)
+	var outerRadii, innerRadii, midRadii [4]Vec2
+	for i, r := range corners {
+		if r <= 0 {
+			continue
+		}
+		outerRadii[i] = Vec2{r + widths[i][0]*k, r + widths[i][1]*k}
+		innerRadii[i] = Vec2Sub(outerRadii[i], widths[i])
+		midRadii[i] = Vec2Sub(outerRadii[i], Vec2Mul(widths[i], 0.5))
+	}
+
+	var p = Path{rect: rect}
+	const mid = cornerSteps / 2
+
+	if b.BorderStyle != BorderSolid {
+		var center = boxCorners(insetRect(outer, sides, 0.5), midRadii)
+		style := func(width f32) StrokeStyle {
+			var s = StrokeStyle{Width: width}
+			if b.BorderStyle == BorderDotted {
+				s.Cap = CapRound
+				s.Dashes = []f32{0, width * 2}
+			} else {
+				s.Dashes = []f32{max(1, width*3), max(1, width*2)}
+			}
+			if b.BorderDash != (Vec2{}) {
+				s.Dashes = []f32{b.BorderDash[0], b.BorderDash[1]}
+			}
+			return s
+		}
+		var uniform = sides == N4(sides[0])
+		for i := 1; i < 4; i++ {
+			uniform = uniform && b.sideColor(i) == b.sideColor(0)
+		}
+		if uniform {
+			// one path all around keeps the dashes going
+			for i, corner := range center {
+				for j, v := range corner {
+					if i == 0 && j == 0 {
+						p.MoveTo(v)
+					} else {
+						p.LineTo(v)
+					}
+				}
+			}
+			p.Close()
+			p.Stroke(b.sideColor(0), style(sides[0]))
+			return
+		}
+		for side := range 4 {
+			if sides[side] <= 0 {
+				continue
+			}
+			p.Begin()
+			p.MoveTo(center[side][mid])
+			for _, v := range center[side][mid+1:] {
+				p.LineTo(v)
+			}
+			for _, v := range center[(side+1)%4][:mid+1] {
+				p.LineTo(v)
+			}
+			p.Stroke(b.sideColor(side), style(sides[side]))
+		}
+		return
+	}
+
+	var outerPoints = boxCorners(outer, outerRadii)
+	var innerPoints = boxCorners(insetRect(outer, sides, 1), innerRadii)
+
+	var sameColor = true
+	for i := 1; i < 4; i++ {
+		sameColor = sameColor && (b.sideColor(i) == b.sideColor(0) || sides[i] <= 0)
+	}
+	if sameColor {
+		// a ring: the outside clockwise and the inside the other way
+		for i, corner := range outerPoints {
+			for j, v := range corner {
+				if i == 0 && j == 0 {
+					p.MoveTo(v)
+				} else {
+					p.LineTo(v)
+				}
+			}
+		}
+		p.Close()
+		for i := 3; i >= 0; i-- {
+			for j := cornerSteps; j >= 0; j-- {
+				if i == 3 && j == cornerSteps {
+					p.MoveTo(innerPoints[i][j])
+				} else {
+					p.LineTo(innerPoints[i][j])
+				}
+			}
+		}
+		p.Close()
+		p.Fill(b.sideColor(0))
+		return
+	}
+
+	// each side between the middles of its corners, like css
+	for side := range 4 {
+		if sides[side] <= 0 {
+			continue
+		}
+		var next = (side + 1) % 4
+		p.Begin()
+		p.MoveTo(outerPoints[side][mid])
+		for _, v := range outerPoints[side][mid+1:] {
+			p.LineTo(v)
+		}
+		for _, v := range outerPoints[next][:mid+1] {
+			p.LineTo(v)
+		}
+		for j := mid; j >= 0; j-- {
+			p.LineTo(innerPoints[next][j])
+		}
+		for j := cornerSteps; j >= mid; j-- {
+			p.LineTo(innerPoints[side][j])
+		}
+		p.Close()
+		p.Fill(b.sideColor(side))
+	}
+}
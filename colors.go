@@ -69,3 +69,36 @@ func FloatHSLToRGB(h f32, s f32, l f32) (f32, f32, f32) {
 
 	return temp[0], temp[1], temp[2]
 }
+
+// the inverse of FloatHSLToRGB; all values are in [0, 1]. The hue is 0 for
+// grays, where it's undefined.
+func FloatRGBToHSL(r f32, g f32, b f32) (f32, f32, f32) {
+	hi := max(r, g, b)
+	lo := min(r, g, b)
+	l := (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l
+	}
+
+	d := hi - lo
+	var s f32
+	if l > 0.5 {
+		s = d / (2 - hi - lo)
+	} else {
+		s = d / (hi + lo)
+	}
+
+	var h f32
+	switch hi {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, s, l
+}
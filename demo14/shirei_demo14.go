@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	app "go.hasen.dev/shirei/giobackend"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+
+	. "go.hasen.dev/shirei/widgets"
+)
+
+var doc *CodeDocument
+var title string
+
+func main() {
+	flag.Parse()
+
+	fpath := flag.Arg(0)
+	if fpath == "" {
+		// no file given: generate a large one to show off the virtualization
+		var sb strings.Builder
+		sb.WriteString("package main\n\n")
+		for i := range 20000 {
+			fmt.Fprintf(&sb, "/* function number\n   %d */\nfunc f%d(x int) string {\n\treturn fmt.Sprint(x*%d, \"text\", nil) // done\n}\n", i, i, i)
+		}
+		title = "generated.go"
+		doc = NewCodeDocument(sb.String(), GoTokenizer{})
+	} else {
+		content, err := os.ReadFile(fpath)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		title = filepath.Base(fpath)
+		doc = NewCodeDocument(string(content), tokenizerFor(fpath))
+	}
+
+	app.SetupWindow("Code Editor Demo", 700, 600)
+	app.Run(RootView)
+}
+
+func tokenizerFor(fpath string) Tokenizer {
+	switch filepath.Ext(fpath) {
+	case ".go":
+		return GoTokenizer{}
+	case ".json":
+		return JSONTokenizer{}
+	case ".yaml", ".yml":
+		return YAMLTokenizer{}
+	}
+	return PlainTokenizer{}
+}
+
+func RootView() {
+	defer DebugPanel(false)
+
+	Layout(TW(Row, CrossMid, Gap(10), Pad(4)), func() {
+		Label(title, Sz(12), FontWeight(WeightBold))
+		Label(fmt.Sprintf("%d lines", len(doc.Lines)), Sz(12))
+		Label(fmt.Sprintf("Ln %d, Col %d", doc.Cursor.Line+1, doc.Cursor.Col+1), Sz(12), Clr(0, 0, 40, 1))
+	})
+
+	CodeEditor(doc)
+}
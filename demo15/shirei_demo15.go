@@ -0,0 +1,287 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+
+	app "go.hasen.dev/shirei/giobackend"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+	. "go.hasen.dev/shirei/widgets"
+)
+
+func main() {
+	app.SetupWindow("Data Views Demo", 800, 600)
+	app.Run(frameFn)
+}
+
+type Section int
+
+const (
+	SectionTable Section = iota
+	SectionTree
+	SectionTabs
+	SectionDock
+)
+
+var section Section
+
+var sectionNames = []string{
+	SectionTable: "Table",
+	SectionTree:  "Tree",
+	SectionTabs:  "Tabs",
+	SectionDock:  "Docking",
+}
+
+func frameFn() {
+	Layout(TW(Row, Expand, Pad(6), Gap(6), BG(0, 0, 90, 1)), func() {
+		for s, name := range sectionNames {
+			Layout(TW(Pad2(4, 10), BR(4)), func() {
+				if PressAction() {
+					section = Section(s)
+				}
+				if section == Section(s) {
+					ModAttrs(BG(0, 0, 100, 1), Shd(1))
+				}
+				Label(name)
+			})
+		}
+	})
+
+	Layout(TW(Viewport, Pad(10), Gap(10)), func() {
+		switch section {
+		case SectionTable:
+			TableDemo()
+		case SectionTree:
+			TreeDemo()
+		case SectionTabs:
+			TabsDemo()
+		case SectionDock:
+			DockDemo()
+		}
+	})
+
+	PopupsHost()
+	DebugPanel(false)
+}
+
+// ---- table ----
+
+type FileRow struct {
+	Name string
+	Kind string
+	Size int
+}
+
+var files []FileRow
+var filesTable = NewTableState()
+
+func init() {
+	var kinds = []string{"Document", "Image", "Video", "Archive", "Source"}
+	var r = rand.New(rand.NewSource(1))
+	for i := range 100_000 {
+		files = append(files, FileRow{
+			Name: fmt.Sprintf("file-%06d", i),
+			Kind: kinds[r.Intn(len(kinds))],
+			Size: r.Intn(1 << 30),
+		})
+	}
+}
+
+func sortFiles() {
+	slices.SortStableFunc(files, func(a, b FileRow) int {
+		var c int
+		switch filesTable.SortColumn {
+		case 0:
+			c = cmp.Compare(a.Name, b.Name)
+		case 1:
+			c = cmp.Compare(a.Kind, b.Kind)
+		case 2:
+			c = cmp.Compare(a.Size, b.Size)
+		}
+		if filesTable.SortDescending {
+			c = -c
+		}
+		return c
+	})
+}
+
+func TableDemo() {
+	var selected = "none"
+	if filesTable.Selected >= 0 {
+		selected = files[filesTable.Selected].Name
+	}
+	Label(fmt.Sprintf("%d rows; selected: %s", len(files), selected))
+
+	columns := []TableColumn{
+		{Header: "Name", Width: 240, MinWidth: 100, Sortable: true, Cell: func(row int) {
+			Label(files[row].Name, Fonts(Monospace...))
+		}},
+		{Header: "Kind", Width: 120, Sortable: true, Cell: func(row int) {
+			Label(files[row].Kind, Clr(0, 0, 40, 1))
+		}},
+		{Header: "Size", Width: 120, MaxWidth: 200, Align: AlignEnd, Sortable: true, Cell: func(row int) {
+			Label(fmt.Sprintf("%.1f MB", float64(files[row].Size)/(1<<20)))
+		}},
+	}
+	if Table(filesTable, len(files), columns) {
+		sortFiles()
+	}
+}
+
+// ---- tree ----
+
+var homeDir, _ = os.UserHomeDir()
+var dirTree TreeState[string]
+var isDir = make(map[string]bool)
+
+func TreeDemo() {
+	var selected = "none"
+	if dirTree.HasSelected {
+		selected = dirTree.Selected
+	}
+	Label("Selected: " + selected)
+
+	attrs := DefaultTreeAttrs[string]()
+	attrs.Async = true
+	attrs.Children = func(dir string) []string {
+		entries, _ := os.ReadDir(dir)
+		var children []string
+		var dirs = make(map[string]bool)
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			children = append(children, path)
+			dirs[path] = entry.IsDir()
+		}
+		WithFrameLock(func() {
+			maps.Copy(isDir, dirs)
+		})
+		return children
+	}
+	attrs.HasChildren = func(path string) bool {
+		return path == homeDir || isDir[path]
+	}
+	attrs.NodeView = func(path string, depth int) {
+		var icon = TypDocument
+		if attrs.HasChildren(path) {
+			icon = SymFolder
+		}
+		Icon(icon, Sz(12), Clr(0, 0, 40, 1))
+		Label(filepath.Base(path), Sz(12))
+	}
+	TreeView(&dirTree, []string{homeDir}, attrs)
+}
+
+// ---- tabs ----
+
+var activeDoc int
+var docTabs []TabItem
+var nextDoc int
+
+func newDocTab() {
+	nextDoc++
+	docTabs = append(docTabs, TabItem{Id: nextDoc, Title: fmt.Sprintf("Document %d", nextDoc), Icon: TypDocument, Closable: true})
+	activeDoc = len(docTabs) - 1
+}
+
+func init() {
+	for range 4 {
+		newDocTab()
+	}
+	activeDoc = 0
+}
+
+func TabsDemo() {
+	if Button(SymPlus, "New Tab") {
+		newDocTab()
+	}
+	closed := TabsView(&activeDoc, &docTabs, DefaultTabsAttrs(), func(tab TabItem) {
+		ModAttrs(Pad(10), Gap(10))
+		// hook state is kept per tab while switching
+		var notes = Use[string]("notes")
+		var clicks = Use[int]("clicks")
+		Label(tab.Title, Sz(20))
+		TextInput(notes)
+		if Button(0, fmt.Sprintf("Clicked %d times", *clicks)) {
+			*clicks++
+		}
+	})
+	if closed >= 0 {
+		CloseTab(&activeDoc, &docTabs, closed)
+	}
+}
+
+// ---- docking ----
+
+func newDockLayout() *DockLayout {
+	var layout = NewDockLayout("editor", "preview")
+	layout.Root = &DockNode{
+		Row:   true,
+		Split: SplitState{Ratio: 0.25},
+		First: &DockNode{Panels: []string{"outline"}},
+		Second: &DockNode{
+			Split:  SplitState{Ratio: 0.7},
+			First:  &DockNode{Panels: []string{"editor", "preview"}},
+			Second: &DockNode{Panels: []string{"console"}},
+		},
+	}
+	return layout
+}
+
+var dockLayout = newDockLayout()
+var savedLayout []byte
+
+var dockPanels = []DockPanel{
+	{Id: "outline", Title: "Outline", Icon: SymList, View: func() {
+		ModAttrs(Pad(8), Gap(4))
+		for i := range 20 {
+			Label(fmt.Sprintf("Section %d", i+1))
+		}
+	}},
+	{Id: "editor", Title: "Editor", Icon: TypDocument, View: func() {
+		ModAttrs(Pad(8))
+		var text = Use[string]("text")
+		TextInput(text)
+	}},
+	{Id: "preview", Title: "Preview", Closable: true, View: func() {
+		ModAttrs(Pad(8))
+		Label("Drag tabs to the edges of a panel to split it, or out to float them")
+	}},
+	{Id: "console", Title: "Console", Closable: true, View: func() {
+		ModAttrs(Pad(8), Gap(4))
+		var clicks = Use[int]("clicks")
+		if Button(0, fmt.Sprintf("Log (%d)", *clicks)) {
+			*clicks++
+		}
+	}},
+}
+
+func DockDemo() {
+	Layout(TW(Row, CrossMid, Gap(6)), func() {
+		if Button(0, "Save Layout") {
+			savedLayout, _ = dockLayout.Save()
+		}
+		if Button(0, "Restore Layout") && savedLayout != nil {
+			if layout, err := LoadDockLayout(savedLayout); err == nil {
+				dockLayout = layout
+			}
+		}
+		if Button(0, "Reset") {
+			dockLayout = newDockLayout()
+		}
+		for _, p := range dockPanels {
+			if !dockLayout.Contains(p.Id) && Button(SymPlus, p.Title) {
+				dockLayout.Show(p.Id)
+			}
+		}
+	})
+	Layout(TW(Viewport, BW(1), Bo(0, 0, 70, 1)), func() {
+		DockSpace(dockLayout, dockPanels, DefaultDockAttrs())
+	})
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	app "go.hasen.dev/shirei/giobackend"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+	. "go.hasen.dev/shirei/widgets"
+)
+
+func main() {
+	app.SetupWindow("Overlays Demo", 800, 600)
+	app.Run(frameFn)
+}
+
+type Section int
+
+const (
+	SectionDialogs Section = iota
+	SectionTooltips
+	SectionMenuBar
+	SectionSelect
+	SectionPickers
+)
+
+var section Section
+
+var sectionNames = []string{
+	SectionDialogs:  "Dialogs",
+	SectionTooltips: "Tooltips",
+	SectionMenuBar:  "Menu Bar",
+	SectionSelect:   "Select",
+	SectionPickers:  "Pickers",
+}
+
+func frameFn() {
+	Layout(TW(Row, Expand, Pad(6), Gap(6), BG(0, 0, 90, 1)), func() {
+		for s, name := range sectionNames {
+			Layout(TW(Pad2(4, 10), BR(4)), func() {
+				if PressAction() {
+					section = Section(s)
+				}
+				if section == Section(s) {
+					ModAttrs(BG(0, 0, 100, 1), Shd(1))
+				}
+				Label(name)
+			})
+		}
+	})
+
+	Layout(TW(Viewport, Pad(10), Gap(10)), func() {
+		switch section {
+		case SectionDialogs:
+			DialogsDemo()
+		case SectionTooltips:
+			TooltipsDemo()
+		case SectionMenuBar:
+			MenuBarDemo()
+		case SectionSelect:
+			SelectDemo()
+		case SectionPickers:
+			PickersDemo()
+		}
+	})
+
+	PopupsHost()
+	DebugPanel(false)
+}
+
+// ---- dialogs ----
+
+var log []string
+
+func logf(format string, args ...any) {
+	log = append(log, fmt.Sprintf(format, args...))
+}
+
+var confirmOpen bool
+var promptOpen bool
+var settingsOpen bool
+var discardOpen bool
+
+var name = "World"
+var newName string
+var settings struct {
+	Title string
+	Notes string
+}
+
+func DialogsDemo() {
+	Layout(TW(Row, Gap(8)), func() {
+		if Button(0, "Confirm") {
+			confirmOpen = true
+		}
+		if Button(0, "Prompt") {
+			newName = name
+			promptOpen = true
+		}
+		if Button(0, "Nested") {
+			settingsOpen = true
+		}
+	})
+	Label(fmt.Sprintf("Hello, %s!", name), Sz(20))
+
+	switch Confirm(&confirmOpen, "Delete everything?", "This can't be undone.") {
+	case DialogAccepted:
+		logf("confirmed")
+	case DialogCancelled:
+		logf("cancelled")
+	}
+
+	if Prompt(&promptOpen, "Rename", "What's your name?", &newName) == DialogAccepted {
+		name = newName
+		logf("renamed to %s", name)
+	}
+
+	var attrs = DefaultDialogAttrs()
+	attrs.Title = "Settings"
+	attrs.AcceptOnEnter = false
+	attrs.CloseOnBackdrop = true
+	var result = Dialog(&settingsOpen, attrs, func() {
+		Label("Title")
+		TextInput(&settings.Title)
+		Label("Notes")
+		TextInput(&settings.Notes)
+		Layout(TW(Row, Expand, MA(AlignEnd), Gap(8)), func() {
+			if Button(0, "Close") {
+				discardOpen = true
+			}
+			if ButtonExt("Save", ButtonAttrs{Primary: true}) {
+				CloseDialog(&settingsOpen, DialogAccepted)
+			}
+		})
+
+		// stacked on top of the settings dialog
+		if Confirm(&discardOpen, "Discard changes?", "The settings were not saved.") == DialogAccepted {
+			CloseDialog(&settingsOpen, DialogCancelled)
+		}
+	})
+	switch result {
+	case DialogAccepted:
+		logf("settings saved: %q", settings.Title)
+	case DialogCancelled:
+		logf("settings discarded")
+	}
+
+	Layout(TW(Gap(4)), func() {
+		for _, line := range log {
+			Label(line, Sz(12), Clr(0, 0, 40, 1))
+		}
+	})
+}
+
+// ---- tooltips ----
+
+func TooltipsDemo() {
+	Label("Hover the buttons; tooltips flip to stay inside the window")
+	var placements = []struct {
+		name      string
+		placement Placement
+	}{
+		{"Top", PlaceTop},
+		{"Bottom", PlaceBottom},
+		{"Left", PlaceLeft},
+		{"Right", PlaceRight},
+	}
+	Layout(TW(Row, Gap(10)), func() {
+		for _, p := range placements {
+			Layout(TW(), func() {
+				Button(0, p.name)
+				var attrs = DefaultTooltipAttrs()
+				attrs.Placement = p.placement
+				TooltipExt(attrs, func() {
+					Label(fmt.Sprintf("Placed at the %s", p.name), Sz(12), Clr(0, 0, 100, 1))
+				})
+			})
+		}
+	})
+
+	Layout(TW(Row, Gap(10), Pad(10), BR(4), BG(0, 0, 94, 1)), func() {
+		TooltipText("The outer box has a tooltip too")
+		Label("Nested:")
+		Layout(TW(Pad2(2, 6), BR(4), BG(50, 80, 80, 1)), func() {
+			TooltipExt(TooltipAttrs{Delay: time.Millisecond * 100, Placement: PlaceRight, Gap: 6, MaxWidth: 300}, func() {
+				Layout(TW(Gap(4)), func() {
+					Label("Rich content", Sz(14), FontWeight(WeightBold), Clr(0, 0, 100, 1))
+					Label("with a shorter delay", Sz(12), Clr(0, 0, 80, 1))
+				})
+			})
+			Label("inner")
+		})
+	})
+}
+
+// ---- menu bar ----
+
+var wordWrap = true
+var fontSize = 14
+
+func MenuBarDemo() {
+	MenuBar(func() {
+		Menu("&File", func() {
+			if MenuAction("&New", MenuItemAttrs{Combo: Combo(KeyN, ModCtrl)}) {
+				logf("new")
+			}
+			if MenuAction("&Open...", MenuItemAttrs{Icon: SymFolder, Combo: Combo(KeyO, ModCtrl)}) {
+				logf("open")
+			}
+			if MenuAction("&Save", MenuItemAttrs{Combo: Combo(KeyS, ModCtrl)}) {
+				logf("save")
+			}
+			if MenuAction("Save &As...", MenuItemAttrs{Combo: Combo(KeyS, ModCtrl|ModShift)}) {
+				logf("save as")
+			}
+			MenuSeparator()
+			if MenuAction("&Quit", MenuItemAttrs{Combo: Combo(KeyQ, ModCtrl)}) {
+				logf("quit")
+			}
+		})
+		Menu("&Edit", func() {
+			if MenuAction("&Undo", MenuItemAttrs{Combo: Combo(KeyZ, ModCtrl)}) {
+				logf("undo")
+			}
+			if MenuAction("&Redo", MenuItemAttrs{Combo: Combo(KeyY, ModCtrl)}) {
+				logf("redo")
+			}
+			MenuSeparator()
+			MenuAction("Cu&t", MenuItemAttrs{Icon: TypScissors, Combo: Combo(KeyX, ModCtrl), Disabled: true})
+			MenuAction("&Copy", MenuItemAttrs{Icon: SymCopy, Combo: Combo(KeyC, ModCtrl), Disabled: true})
+		})
+		Menu("&View", func() {
+			MenuCheck("&Word Wrap", &wordWrap)
+			SubMenu(0, "&Font Size", func() {
+				for _, size := range []int{12, 14, 18} {
+					MenuRadio(fmt.Sprintf("%dpx", size), &fontSize, size)
+				}
+			})
+		})
+	})
+
+	Label("Alt+letter opens a menu, Alt alone opens the first one; the shortcuts work with the menus closed", Sz(12))
+	Layout(TW(Gap(4)), func() {
+		for _, line := range log {
+			Label(line, Sz(12), Clr(0, 0, 40, 1))
+		}
+	})
+}
+
+// ---- select ----
+
+var fruits = []string{"Apple", "Apricot", "Banana", "Blueberry", "Cherry", "Grape", "Green Apple", "Lemon", "Mango", "Orange", "Peach", "Pear", "Pineapple", "Plum", "Strawberry", "Watermelon"}
+var fruit = "Banana"
+var favorite = "Mango"
+var custom = "Kiwi"
+
+var numbers []int
+var number = 42
+
+func SelectDemo() {
+	if numbers == nil {
+		for i := range 100_000 {
+			numbers = append(numbers, i)
+		}
+	}
+
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Select:")
+		if Select(&fruit, fruits) {
+			logf("selected %s", fruit)
+		}
+	})
+
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("100,000 options:")
+		var attrs = DefaultSelectAttrs[int]()
+		attrs.Label = func(n int) string {
+			return fmt.Sprintf("Item #%d", n)
+		}
+		ComboBoxExt(&number, numbers, attrs)
+		Label(fmt.Sprint(number), Sz(12))
+	})
+
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("ComboBox:")
+		if ComboBox(&favorite, fruits) {
+			logf("favorite is %s", favorite)
+		}
+	})
+
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Free text:")
+		var attrs = DefaultSelectAttrs[string]()
+		attrs.Placeholder = "Type a fruit"
+		attrs.Parse = func(text string) (string, bool) {
+			return text, text != ""
+		}
+		if ComboBoxExt(&custom, fruits, attrs) {
+			logf("typed %q", custom)
+		}
+	})
+
+	Layout(TW(Gap(4)), func() {
+		for _, line := range log {
+			Label(line, Sz(12), Clr(0, 0, 40, 1))
+		}
+	})
+}
+
+// ---- date and time pickers ----
+
+var meeting = time.Now()
+var deadline time.Time
+var rangeFrom, rangeTo time.Time
+
+func PickersDemo() {
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Meeting:")
+		DatePicker(&meeting)
+		TimePicker(&meeting)
+	})
+	Label(meeting.Format(time.RFC1123), Sz(12), Clr(0, 0, 40, 1))
+
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Deadline (within 60 days):")
+		var attrs = DefaultDatePickerAttrs()
+		attrs.Min = time.Now()
+		attrs.Max = time.Now().AddDate(0, 0, 60)
+		attrs.Placeholder = "No deadline"
+		DatePickerExt(&deadline, attrs)
+	})
+
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Logs between:")
+		if DateRangePicker(&rangeFrom, &rangeTo) {
+			logf("range: %d days", int(rangeTo.Sub(rangeFrom).Hours()/24)+1)
+		}
+	})
+
+	Layout(TW(Gap(4)), func() {
+		for _, line := range log {
+			Label(line, Sz(12), Clr(0, 0, 40, 1))
+		}
+	})
+}
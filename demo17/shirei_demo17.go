@@ -0,0 +1,706 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+
+	app "go.hasen.dev/shirei/giobackend"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+	. "go.hasen.dev/shirei/widgets"
+)
+
+func main() {
+	app.SetupWindow("Graphics Demo", 800, 600)
+	app.Run(frameFn)
+}
+
+type Section int
+
+const (
+	SectionCanvas Section = iota
+	SectionCharts
+	SectionTransforms
+	SectionGradients
+	SectionBorders
+	SectionShadows
+	SectionImages
+	SectionSVG
+)
+
+var section Section
+
+var sectionNames = []string{
+	SectionCanvas:     "Canvas",
+	SectionCharts:     "Charts",
+	SectionTransforms: "Transforms",
+	SectionGradients:  "Gradients",
+	SectionBorders:    "Borders",
+	SectionShadows:    "Shadows",
+	SectionImages:     "Images",
+	SectionSVG:        "SVG",
+}
+
+func frameFn() {
+	Layout(TW(Row, Expand, Pad(6), Gap(6), BG(0, 0, 90, 1)), func() {
+		for s, name := range sectionNames {
+			Layout(TW(Pad2(4, 10), BR(4)), func() {
+				if PressAction() {
+					section = Section(s)
+				}
+				if section == Section(s) {
+					ModAttrs(BG(0, 0, 100, 1), Shd(1))
+				}
+				Label(name)
+			})
+		}
+	})
+
+	Layout(TW(Viewport, Pad(10), Gap(10)), func() {
+		switch section {
+		case SectionCanvas:
+			CanvasDemo()
+		case SectionCharts:
+			ChartsDemo()
+		case SectionTransforms:
+			TransformsDemo()
+		case SectionGradients:
+			GradientsDemo()
+		case SectionBorders:
+			BordersDemo()
+		case SectionShadows:
+			ShadowsDemo()
+		case SectionImages:
+			ImagesDemo()
+		case SectionSVG:
+			SVGDemo()
+		}
+	})
+
+	PopupsHost()
+	DebugPanel(false)
+}
+
+// ---- canvas ----
+
+var strokeWidth float32 = 8
+var lineCap = CapButt
+var lineJoin = JoinMiter
+var dashed bool
+
+func CanvasDemo() {
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Width:")
+		Slider(&strokeWidth, SliderAttrs{Min: 1, Max: 24, Step: 1, Width: 120})
+		Label("Cap:")
+		OptionButton(&lineCap, "Butt", CapButt)
+		OptionButton(&lineCap, "Round", CapRound)
+		OptionButton(&lineCap, "Square", CapSquare)
+		Label("Join:")
+		OptionButton(&lineJoin, "Miter", JoinMiter)
+		OptionButton(&lineJoin, "Round", JoinRound)
+		OptionButton(&lineJoin, "Bevel", JoinBevel)
+		CheckBox(&dashed, "Dashed")
+	})
+
+	var style = StrokeStyle{Width: strokeWidth, Cap: lineCap, Join: lineJoin}
+	if dashed {
+		style.Dashes = []float32{strokeWidth * 2, strokeWidth * 1.5}
+	}
+
+	Layout(TW(Row, Gap(10), Wrap), func() {
+		Canvas(Vec2{220, 180}, func(p *Path) {
+			p.MoveTo(Vec2{20, 150})
+			p.LineTo(Vec2{70, 30})
+			p.LineTo(Vec2{120, 150})
+			p.LineTo(Vec2{200, 40})
+			p.Stroke(Vec4{210, 70, 45, 1}, style)
+		})
+
+		Canvas(Vec2{220, 180}, func(p *Path) {
+			p.MoveTo(Vec2{20, 160})
+			p.CubeTo(Vec2{40, 0}, Vec2{180, 0}, Vec2{200, 160})
+			p.QuadTo(Vec2{110, 100}, Vec2{20, 160})
+			p.Fill(Vec4{40, 90, 70, 1})
+			p.Stroke(Vec4{20, 80, 30, 1}, style)
+		})
+
+		Canvas(Vec2{220, 180}, func(p *Path) {
+			// a pie
+			var center = Vec2{110, 90}
+			var slices = []float32{0.45, 0.3, 0.25}
+			var angle float32 = -math.Pi / 2
+			for i, share := range slices {
+				p.Begin()
+				p.MoveTo(center)
+				p.Arc(center, 70, angle, share*2*math.Pi)
+				p.Close()
+				p.Fill(Vec4{float32(i) * 120, 60, 60, 1})
+				p.Stroke(Vec4{0, 0, 100, 1}, StrokeStyle{Width: 2, Join: JoinRound})
+				angle += share * 2 * math.Pi
+			}
+		})
+
+		Canvas(Vec2{220, 180}, func(p *Path) {
+			// dotted ring
+			p.Circle(Vec2{110, 90}, 60)
+			p.Stroke(Vec4{280, 60, 50, 1}, StrokeStyle{Width: 6, Cap: CapRound, Dashes: []float32{0, 14}})
+			p.Begin()
+			p.Rect(Rect{Origin: Vec2{70, 50}, Size: Vec2{80, 80}})
+			p.Stroke(Vec4{0, 0, 30, 1}, style)
+		})
+	})
+
+	Label("Draw paints on any container, under its children:", Sz(12))
+	Layout(TW(Pad(20), Gap(6), BR(6), BG(0, 0, 97, 1)), func() {
+		Draw(func(p *Path) {
+			var size = GetResolvedSize()
+			for x := float32(0); x < size[0]; x += 20 {
+				p.MoveTo(Vec2{x, 0})
+				p.LineTo(Vec2{x, size[1]})
+			}
+			p.Stroke(Vec4{0, 0, 0, 0.1}, StrokeStyle{Width: 1})
+		})
+		Label("Grid lines drawn with a path")
+		Label("behind these labels")
+	})
+}
+
+// ---- charts ----
+
+var signal, noise, million []float32
+var sales = []ChartSeries{
+	{Name: "2024", Values: []float32{12, 19, 7, 15}},
+	{Name: "2025", Values: []float32{14, 22, 11, 18}},
+}
+var cpu = []float32{12, 18, 15, 40, 35, 22, 28, 60, 45, 30, 25, 33, 20, 18}
+
+func ChartsDemo() {
+	if million == nil {
+		for i := range 500 {
+			var x = float64(i) / 25
+			signal = append(signal, float32(math.Sin(x)*10+20))
+			noise = append(noise, float32(math.Cos(x*1.7)*6+12))
+		}
+		million = make([]float32, 1_000_000)
+		for i := range million {
+			var x = float64(i) / 20000
+			million[i] = float32(math.Sin(x) + math.Sin(float64(i)*0.37)*0.2)
+		}
+	}
+
+	Layout(TW(Row, Wrap, Gap(16)), func() {
+		LineChart([]ChartSeries{{Name: "signal", Values: signal}, {Name: "noise", Values: noise}})
+
+		var attrs = DefaultChartAttrs()
+		attrs.Categories = []string{"Q1", "Q2", "Q3", "Q4"}
+		BarChartExt(sales, attrs)
+
+		attrs = DefaultChartAttrs()
+		attrs.YFormat = func(y float32) string { return fmt.Sprintf("%.0f%%", y) }
+		AreaChartExt([]ChartSeries{{Name: "cpu", Values: cpu}}, attrs)
+
+		LineChart([]ChartSeries{{Name: "1,000,000 points", Values: million}})
+	})
+
+	Layout(TW(Row, CrossMid, Gap(8)), func() {
+		Label("CPU")
+		Sparkline(cpu)
+		Label("Signal")
+		SparklineExt(signal, SparklineAttrs{Size: Vec2{120, 24}, Color: Vec4{140, 55, 40, 1}, Fill: true})
+	})
+}
+
+// ---- transforms ----
+
+var expanded = map[int]bool{}
+var angle float32 = 15
+var zoom float32 = 1
+
+func TransformsDemo() {
+	Label("Chevrons turn when a section opens:")
+	for i, title := range []string{"General", "Appearance", "Advanced"} {
+		Layout(TW(Gap(4)), func() {
+			Layout(TW(Row, CrossMid, Gap(6), Pad(4), BR(4)), func() {
+				if PressAction() {
+					expanded[i] = !expanded[i]
+				}
+				if IsHovered() {
+					ModAttrs(BG(0, 0, 0, 0.05))
+				}
+				Layout(TW(), func() {
+					if expanded[i] {
+						ModAttrs(Rotate(90))
+					}
+					Icon(SymRight)
+				})
+				Label(title)
+			})
+			if expanded[i] {
+				Layout(TW(Pad4(0, 0, 4, 30)), func() {
+					Label("Settings go here", Sz(12), Clr(0, 0, 40, 1))
+				})
+			}
+		})
+	}
+
+	Label("Cards grow when hovered; hit-testing follows the transform:")
+	Layout(TW(Row, Gap(20), Pad(20)), func() {
+		for i := range 4 {
+			Layout(TW(FixSize(110, 80), Pad(10), BR(8), BG(float32(i)*70+200, 60, 92, 1), Shd(2), Center), func() {
+				if IsHovered() {
+					ModAttrs(Scale(1.1), BG(float32(i)*70+200, 70, 85, 1))
+				}
+				Label("Card")
+			})
+		}
+	})
+
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Rotation:")
+		Slider(&angle, SliderAttrs{Min: -180, Max: 180, Step: 1})
+		Label("Scale:")
+		Slider(&zoom, SliderAttrs{Min: 0.25, Max: 2, Step: 0.05})
+	})
+	Layout(TW(FixSize(400, 240), Center, BG(0, 0, 97, 1), BR(6), Clip), func() {
+		Layout(TW(Pad(14), Gap(8), BR(8), BG(0, 0, 100, 1), BW(1), Bo(0, 0, 0, 0.2), Shd(3), Rotate(angle), Scale(zoom), NoAnimate), func() {
+			Label("Everything in here turns", FontWeight(WeightBold))
+			Button(SymRight, "Including buttons")
+			Canvas(Vec2{120, 30}, func(p *Path) {
+				p.MoveTo(Vec2{0, 15})
+				for x := float32(0); x <= 120; x += 4 {
+					p.LineTo(Vec2{x, 15 + 10*float32(math.Sin(float64(x)/10))})
+				}
+				p.Stroke(Vec4{210, 70, 45, 1}, StrokeStyle{Width: 2})
+			})
+		})
+	})
+}
+
+// ---- gradients ----
+
+var gradientAngle float32 = 135
+
+func GradientsDemo() {
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Angle:")
+		Slider(&gradientAngle, SliderAttrs{Min: 0, Max: 360, Step: 1})
+	})
+
+	swatch := func(title string, fill AttrsFn) {
+		Layout(TW(Gap(4)), func() {
+			Element(TW(FixSize(160, 100), BR(8), BW(1), Bo(0, 0, 0, 0.2), fill))
+			Label(title, Sz(12), Clr(0, 0, 30, 1))
+		})
+	}
+
+	Layout(TW(Row, Wrap, Gap(16)), func() {
+		swatch("Linear, two stops", LinearGrad(gradientAngle, Stop(0, 200, 80, 60, 1), Stop(1, 280, 70, 45, 1)))
+		swatch("Linear, rainbow", LinearGrad(gradientAngle,
+			Stop(0, 0, 90, 55, 1), Stop(0.2, 40, 95, 55, 1), Stop(0.4, 60, 95, 50, 1),
+			Stop(0.6, 130, 60, 45, 1), Stop(0.8, 210, 80, 50, 1), Stop(1, 280, 70, 55, 1)))
+		swatch("Linear, hard stops", LinearGrad(gradientAngle,
+			Stop(0, 0, 0, 95, 1), Stop(0.5, 0, 0, 95, 1), Stop(0.5, 210, 70, 50, 1), Stop(1, 210, 70, 50, 1)))
+		swatch("Radial", RadialGrad(Stop(0, 50, 100, 70, 1), Stop(0.6, 20, 90, 50, 1), Stop(1, 330, 60, 25, 1)))
+		swatch("Conic", ConicGrad(gradientAngle, Stop(0, 0, 90, 55, 1), Stop(0.33, 120, 70, 45, 1), Stop(0.66, 240, 80, 55, 1), Stop(1, 360, 90, 55, 1)))
+		swatch("Radial, off center", func(a *Attrs) {
+			var g = RadialGradient(Stop(0, 0, 0, 100, 0.9), Stop(1, 0, 0, 100, 0))
+			g.Center = Vec2{-0.25, -0.3}
+			g.Radius = Vec2{0.5, 0.6}
+			a.Fill = FrameGradient(g)
+			a.Background = Vec4{210, 60, 45, 1}
+		})
+	})
+
+	Label("Transparent stops blend with what's behind:", Sz(12))
+	Layout(TW(FixSize(340, 80), BR(8), Clip, BG(0, 0, 100, 1)), func() {
+		checkerboard()
+		Element(TW(Float(0, 0), FixSize(340, 80), LinearGrad(90, Stop(0, 210, 80, 50, 0), Stop(1, 210, 80, 50, 1))))
+	})
+}
+
+func checkerboard() {
+	for y := float32(0); y < 80; y += 10 {
+		for x := float32(0); x < 340; x += 10 {
+			if int(x/10+y/10)%2 == 0 {
+				Element(TW(Float(x, y), FixSize(10, 10), BG(0, 0, 85, 1)))
+			}
+		}
+	}
+}
+
+// ---- borders ----
+
+var borderWidth float32 = 4
+var borderAlign = StrokeCenter
+
+func BordersDemo() {
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Width:")
+		Slider(&borderWidth, SliderAttrs{Min: 1, Max: 16, Step: 1})
+		Label("Align:")
+		OptionButton(&borderAlign, "Center", StrokeCenter)
+		OptionButton(&borderAlign, "Inside", StrokeInside)
+		OptionButton(&borderAlign, "Outside", StrokeOutside)
+	})
+
+	var align = func(a *Attrs) {
+		a.BorderAlign = borderAlign
+	}
+	box := func(title string, attrs AttrsFn) {
+		Layout(TW(Gap(6), CrossMid), func() {
+			Element(TW(FixSize(140, 90), BR(12), BG(0, 0, 100, 1), BW(borderWidth), Bo(210, 70, 45, 1), align, attrs))
+			Label(title, Sz(12), Clr(0, 0, 30, 1))
+		})
+	}
+
+	var red, green, blue, gold = Vec4{0, 75, 55, 1}, Vec4{130, 55, 40, 1}, Vec4{210, 70, 50, 1}, Vec4{45, 90, 50, 1}
+	Layout(TW(Row, Wrap, Gap(24), Pad(8)), func() {
+		box("Solid", Compose())
+		box("Dashed", Dashed)
+		box("Dotted", Dotted)
+		box("Long dashes", Compose(Dashed, Dash(16, 6)))
+		box("Bottom only", BW4(0, 0, borderWidth, 0))
+		box("Per side widths", BW4(borderWidth/2, borderWidth, borderWidth*2, borderWidth))
+		box("Per side colors", Bo4(red, green, blue, gold))
+		box("Dotted sides", Compose(Dotted, BW4(borderWidth, 0, borderWidth, 0), BoSide(0, red), BoSide(2, blue)))
+		box("Clear top", BoSide(0, Vec4{}))
+	})
+
+	Label("Clipping containers keep their outer borders:", Sz(12))
+	Layout(TW(FixSize(200, 80), BR(16), Clip, BW(borderWidth), Bo(280, 60, 50, 1), align, BG(0, 0, 100, 1)), func() {
+		Element(TW(Float(-20, -20), FixSize(120, 120), BR(60), BG(50, 90, 70, 1)))
+	})
+}
+
+// ---- shadows ----
+
+var shadowBlur float32 = 8
+var shadowSpread float32 = 0
+
+func ShadowsDemo() {
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Blur:")
+		Slider(&shadowBlur, SliderAttrs{Min: 0, Max: 24, Step: 1})
+		Label("Spread:")
+		Slider(&shadowSpread, SliderAttrs{Min: -8, Max: 16, Step: 1})
+	})
+
+	card := func(title string, attrs AttrsFn) {
+		Layout(TW(FixSize(150, 100), BR(12), BG(0, 0, 100, 1), Center, attrs), func() {
+			Label(title, Sz(12), Clr(0, 0, 30, 1))
+		})
+	}
+
+	Layout(TW(Row, Wrap, Gap(40), Pad(30), BG(220, 20, 94, 1), BR(8)), func() {
+		card("Drop", ShdV(Shadow{Offset: Vec2{0, 4}, Blur: shadowBlur, Alpha: 0.4, Spread: shadowSpread}))
+		card("Colored", ShdV(Shadow{Offset: Vec2{0, 6}, Blur: shadowBlur, Spread: shadowSpread, Color: Vec4{260, 80, 55, 0.6}}))
+		card("Glow", ShdV(Shadow{Blur: shadowBlur, Spread: shadowSpread, Color: Vec4{45, 100, 55, 0.9}}))
+		card("Layered", Compose(
+			ShdV(Shadow{Offset: Vec2{0, 1}, Blur: 1, Alpha: 0.3}),
+			ShdV(Shadow{Offset: Vec2{0, 4}, Blur: shadowBlur / 2, Alpha: 0.15, Spread: shadowSpread}),
+			ShdV(Shadow{Offset: Vec2{0, 12}, Blur: shadowBlur, Alpha: 0.1, Spread: shadowSpread}),
+		))
+		card("Inset", ShdV(Shadow{Offset: Vec2{0, 2}, Blur: shadowBlur, Alpha: 0.4, Spread: shadowSpread, Inset: true}))
+		card("Well", Compose(BG(220, 15, 90, 1),
+			ShdV(Shadow{Offset: Vec2{0, 2}, Blur: shadowBlur / 2, Alpha: 0.35, Inset: true}),
+			ShdV(Shadow{Offset: Vec2{0, -1}, Blur: 1, Color: Vec4{0, 0, 100, 0.8}, Inset: true}),
+		))
+	})
+
+	Label("Press me:", Sz(12))
+	Layout(TW(Pad2(10, 20), BR(8), BG(210, 70, 50, 1)), func() {
+		if IsActive() {
+			ModAttrs(InShd(4), BG(210, 70, 44, 1))
+		} else {
+			ModAttrs(Shd(4))
+		}
+		PressAction()
+		Label("Button", Clr(0, 0, 100, 1))
+	})
+}
+
+// ---- images ----
+
+var plasmaPhase float32 = 1
+var plasmaDrawnPhase float32 = -1
+var plasmaId ImageId
+
+func plasma(phase float32) *image.NRGBA {
+	const w, h = 240, 140
+	var img = image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			var v = math.Sin(float64(x)/16+float64(phase)) + math.Sin(float64(y)/11-float64(phase)) + math.Sin(float64(x+y)/24)
+			var c = HSLAColor(Vec4{float32(v*60) + phase*40, 70, 55, 1})
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+var stripes = func() *image.NRGBA {
+	var img = image.NewNRGBA(image.Rect(0, 0, 120, 120))
+	for y := range 120 {
+		for x := range 120 {
+			if (x+y)/12%2 == 0 {
+				img.SetNRGBA(x, y, color.NRGBA{40, 90, 160, 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{230, 230, 240, 255})
+			}
+		}
+	}
+	return img
+}()
+
+func ImagesDemo() {
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Phase:")
+		Slider(&plasmaPhase, SliderAttrs{Min: 0, Max: 6, Step: 0.1})
+	})
+
+	// new pixels, new handle; the old one is freed once it's off screen
+	if plasmaPhase != plasmaDrawnPhase {
+		plasmaId = ReplaceImage(plasmaId, plasma(plasmaPhase))
+		plasmaDrawnPhase = plasmaPhase
+	}
+
+	Layout(TW(Row, Gap(20)), func() {
+		Layout(TW(Gap(4)), func() {
+			ImageById(plasmaId, Vec2{})
+			Label(fmt.Sprintf("Registered, id %d", plasmaId), Sz(12))
+		})
+		Layout(TW(Gap(4)), func() {
+			var id = FrameImage(stripes)
+			ImageById(id, Vec2{})
+			Label(fmt.Sprintf("Per frame, id %d", id), Sz(12))
+		})
+	})
+
+	Label("Fit modes, in a 120x120 box:", Sz(12))
+	Layout(TW(Row, Wrap, Gap(16)), func() {
+		fitted := func(title string, fit ImageFit, focus Vec2) {
+			Layout(TW(Gap(4)), func() {
+				var attrs = DefaultImageAttrs()
+				attrs.Size = Vec2{120, 120}
+				attrs.Fit = fit
+				attrs.Focus = focus
+				attrs.Corners = N4(12)
+				attrs.BorderColor = Vec4{0, 0, 0, 0.3}
+				attrs.BorderWidth = 1
+				ImageByIdExt(plasmaId, attrs)
+				Label(title, Sz(12))
+			})
+		}
+		fitted("Scale down", FitScaleDown, Vec2{})
+		fitted("Contain", FitContain, Vec2{})
+		fitted("Cover", FitCover, Vec2{})
+		fitted("Cover, focus left", FitCover, Vec2{-0.5, 0})
+		fitted("Fill", FitFill, Vec2{})
+		fitted("None", FitNone, Vec2{})
+	})
+
+	Layout(TW(Row, Wrap, Gap(16)), func() {
+		Layout(TW(Gap(4)), func() {
+			var attrs = DefaultImageAttrs()
+			attrs.Crop = Rect{Origin: Vec2{60, 30}, Size: Vec2{60, 60}}
+			attrs.Size = Vec2{120, 120}
+			attrs.Corners = N4(60)
+			ImageByIdExt(plasmaId, attrs)
+			Label("Cropped, round", Sz(12))
+		})
+		Layout(TW(Gap(4)), func() {
+			Layout(TW(FixSize(240, 120), BR(8), Clip), func() {
+				BackgroundImage(FrameImage(stripes), ImageAttrs{Tile: true, TileSize: Vec2{30, 30}})
+			})
+			Label("Tiled background", Sz(12))
+		})
+		Layout(TW(Gap(4)), func() {
+			Layout(TW(FixSize(240, 120), Pad(16), Clip), func() {
+				BackgroundImage(FrameImage(panelSkin), ImageAttrs{Slice: N4(12)})
+				Label("Nine-slice panel", Clr(0, 0, 100, 1))
+			})
+			Label("Skinned", Sz(12))
+		})
+	})
+
+	Label("Animated (a gif made on the spot; it stops while it's scrolled out of view):", Sz(12))
+	Layout(TW(Row, CrossMid, Gap(16)), func() {
+		var attrs = DefaultImageAttrs()
+		attrs.Size = Vec2{64, 64}
+		AnimatedImageExt(spinner, attrs)
+
+		if spinner.Playing() {
+			if Button(0, "Pause") {
+				spinner.Pause()
+			}
+		} else if Button(0, "Play") {
+			spinner.Play()
+		}
+		if Button(0, "Restart") {
+			spinner.Restart()
+		}
+		var once = spinner.Loops == 1
+		CheckBox(&once, "Play once")
+		if once {
+			spinner.Loops = 1
+		} else {
+			spinner.Loops = 0
+		}
+	})
+}
+
+var spinner = func() *ImageAnimation {
+	const size, count = 32, 12
+	var palette = color.Palette{color.Transparent}
+	for i := range count {
+		palette = append(palette, HSLAColor(Vec4{210, 70, 50, float32(i+1) / count}))
+	}
+	var anim gif.GIF
+	for f := range count {
+		var img = image.NewPaletted(image.Rect(0, 0, size, size), palette)
+		for dot := range count {
+			var angle = float64(dot) * 2 * math.Pi / count
+			var cx, cy = size/2 + 11*math.Sin(angle), size/2 - 11*math.Cos(angle)
+			var shade = uint8((dot-f+count)%count + 1)
+			for y := range size {
+				for x := range size {
+					if math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy) < 3 {
+						img.SetColorIndex(x, y, shade)
+					}
+				}
+			}
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, 6)
+	}
+	var buf bytes.Buffer
+	gif.EncodeAll(&buf, &anim)
+	decoded, _ := DecodeImageAnimation(buf.Bytes())
+	return decoded
+}()
+
+// a frame with rounded corners and a lighter middle, for nine-slicing
+var panelSkin = func() *image.NRGBA {
+	const size, edge = 36, 12
+	var img = image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			// distance outside the rounded square
+			var dx = max(edge-x-1, x-(size-edge), 0)
+			var dy = max(edge-y-1, y-(size-edge), 0)
+			var d = math.Hypot(float64(dx), float64(dy))
+			switch {
+			case d > edge:
+			case d > edge-3:
+				img.SetNRGBA(x, y, color.NRGBA{30, 60, 110, 255})
+			default:
+				img.SetNRGBA(x, y, color.NRGBA{60, 110, 180, 255})
+			}
+		}
+	}
+	return img
+}()
+
+// ---- svg ----
+
+var svgSize float32 = 96
+
+func SVGDemo() {
+	Layout(TW(Row, CrossMid, Gap(10)), func() {
+		Label("Size:")
+		Slider(&svgSize, SliderAttrs{Min: 16, Max: 256, Step: 1, Width: 160})
+	})
+
+	Label("Vector, so sharp at any size:", Sz(12))
+	Layout(TW(Row, Wrap, CrossMid, Gap(16)), func() {
+		var attrs = DefaultImageAttrs()
+		attrs.Size = Vec2{svgSize, svgSize}
+		SVGImageExt(badgeSVG, attrs)
+		SVGImageExt(sunSVG, attrs)
+		SVGImageExt(gaugeSVG, attrs)
+	})
+
+	Label("Cropped and covering, like images:", Sz(12))
+	Layout(TW(Row, Gap(16)), func() {
+		var attrs = DefaultImageAttrs()
+		attrs.Size = Vec2{160, 80}
+		attrs.Fit = FitCover
+		attrs.Corners = N4(10)
+		SVGImageExt(sunSVG, attrs)
+
+		attrs.Crop = Rect{Origin: Vec2{50, 0}, Size: Vec2{50, 50}}
+		attrs.Fit = FitContain
+		attrs.Size = Vec2{80, 80}
+		SVGImageExt(badgeSVG, attrs)
+	})
+
+	Label("Icons take the text color for currentColor:", Sz(12))
+	Layout(TW(Row, CrossMid, Gap(12)), func() {
+		SVGIcon(bellSVG)
+		SVGIcon(bellSVG, Sz(24), Clr(210, 80, 45, 1))
+		SVGIcon(bellSVG, Sz(32), Clr(0, 75, 50, 1))
+		Layout(TW(Row, CrossMid, Gap(4), Pad2(4, 10), BR(4), BG(140, 50, 40, 1)), func() {
+			SVGIcon(bellSVG, Clr(0, 0, 100, 1))
+			Label("Notify", Clr(0, 0, 100, 1))
+		})
+	})
+}
+
+var badgeSVG, _ = ParseSVG([]byte(`
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100" width="100" height="100">
+  <defs>
+    <linearGradient id="sky" x1="0" y1="0" x2="0" y2="1">
+      <stop offset="0" stop-color="#4facfe"/>
+      <stop offset="1" stop-color="#00f2fe"/>
+    </linearGradient>
+    <radialGradient id="shine" cx="0.35" cy="0.3" r="0.6">
+      <stop offset="0" stop-color="white" stop-opacity="0.8"/>
+      <stop offset="1" stop-color="white" stop-opacity="0"/>
+    </radialGradient>
+  </defs>
+  <circle cx="50" cy="50" r="44" fill="url(#sky)" stroke="#1b4f8a" stroke-width="4"/>
+  <circle cx="50" cy="50" r="44" fill="url(#shine)"/>
+  <path d="M30 52 L44 66 L72 36" fill="none" stroke="white" stroke-width="8"
+        stroke-linecap="round" stroke-linejoin="round"/>
+</svg>`))
+
+var sunSVG, _ = ParseSVG([]byte(`
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100">
+  <rect width="100" height="100" rx="12" fill="#fff4d6"/>
+  <g transform="translate(50 50)" stroke="#f59e0b" stroke-width="5" stroke-linecap="round">
+    <line x1="0" y1="-42" x2="0" y2="-32"/>
+    <line x1="0" y1="-42" x2="0" y2="-32" transform="rotate(45)"/>
+    <line x1="0" y1="-42" x2="0" y2="-32" transform="rotate(90)"/>
+    <line x1="0" y1="-42" x2="0" y2="-32" transform="rotate(135)"/>
+    <line x1="0" y1="-42" x2="0" y2="-32" transform="rotate(180)"/>
+    <line x1="0" y1="-42" x2="0" y2="-32" transform="rotate(225)"/>
+    <line x1="0" y1="-42" x2="0" y2="-32" transform="rotate(270)"/>
+    <line x1="0" y1="-42" x2="0" y2="-32" transform="rotate(315)"/>
+  </g>
+  <circle cx="50" cy="50" r="22" style="fill: #fbbf24; stroke: #d97706; stroke-width: 3"/>
+  <ellipse cx="50" cy="88" rx="30" ry="4" fill="black" opacity="0.1"/>
+</svg>`))
+
+var gaugeSVG, _ = ParseSVG([]byte(`
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100">
+  <path d="M15 70 A40 40 0 1 1 85 70" fill="none" stroke="#e5e7eb" stroke-width="10" stroke-linecap="round"/>
+  <path d="M15 70 A40 40 0 0 1 50 10" fill="none" stroke="rgb(34, 197, 94)" stroke-width="10" stroke-linecap="round"/>
+  <path d="M15 70 A40 40 0 1 1 85 70" fill="none" stroke="#9ca3af" stroke-width="2" stroke-dasharray="2 6"/>
+  <polygon points="48,52 52,52 51,24 49,24" fill="#374151" transform="rotate(-30 50 50)"/>
+  <circle cx="50" cy="50" r="6" fill="#374151"/>
+  <rect x="30" y="78" width="40" height="12" rx="3" fill="#111827" fill-opacity="0.8"/>
+</svg>`))
+
+// a stroked bell in currentColor, like most icon sets
+var bellSVG, _ = ParseSVG([]byte(`
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24" fill="none" stroke="currentColor"
+     stroke-width="2" stroke-linecap="round" stroke-linejoin="round">
+  <path d="M18 8A6 6 0 0 0 6 8c0 7-3 9-3 9h18s-3-2-3-9"/>
+  <path d="M13.73 21a2 2 0 0 1-3.46 0"/>
+</svg>`))
@@ -88,9 +88,8 @@ type Tiler struct {
 	// if set, we split horizontally
 	Row bool
 
-	// offset of splitter from center point
-	// 0 means both view get equal size
-	Splitter float32
+	// position of the splitter; double click it to collapse a side
+	Split SplitState
 
 	A *Tiler
 	B *Tiler
@@ -108,8 +107,6 @@ func TileView(v View) {
 	ViewTile(tiler)
 }
 
-const splitterSize = 10
-
 func ViewTile(t *Tiler) {
 	var attrs Attrs
 	attrs.Row = t.Row
@@ -150,34 +147,15 @@ func ViewTile(t *Tiler) {
 				}
 			})
 		} else {
-			main, _ := MainCrossAxes(t.Row)
-			sz := GetAvailableSize()
-			sz[main] -= splitterSize
-			sz1 := sz
-			sz2 := sz
-			sz1[main] = (sz[main] / 2) - t.Splitter
-			sz2[main] = sz[main] - sz1[main]
-			Layout(TW(FixSizeV(sz1), Bo(0, 0, 0, 1), BW(1), Clip), func() {
+			var split = DefaultSplitAttrs()
+			split.Row = t.Row
+			split.MinFirst = 80
+			split.MinSecond = 80
+			SplitPane(&t.Split, split, func() {
 				ViewTile(t.A)
-			})
-			ViewSplitter(&t.Splitter, t.Row)
-			Layout(TW(FixSizeV(sz2), Bo(0, 0, 0, 1), BW(1), Clip), func() {
+			}, func() {
 				ViewTile(t.B)
 			})
 		}
 	})
 }
-
-func ViewSplitter(s *float32, row bool) {
-	var sz = Vec2{splitterSize, splitterSize}
-	Layout(TW(FixSizeV(sz), Expand, BG(0, 0, 70, 1)), func() {
-		PressAction()
-		if IsActive() {
-			if row {
-				*s -= FrameInput.Motion[0]
-			} else {
-				*s -= FrameInput.Motion[1]
-			}
-		}
-	})
-}
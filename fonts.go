@@ -298,6 +298,19 @@ func ScaleFactor(fontId FontId) float32 {
 	return face.InvUPM
 }
 
+// the baseline of a glyph is placed at this fraction of its box height
+const Baseline = 0.82
+
+// FontExtents returns the ascent and descent of the font at the given text size
+// (both are positive distances from the baseline)
+func FontExtents(fontId FontId, size f32) (ascent f32, descent f32) {
+	// make sure the head table is parsed
+	GetParsedFont(fontId)
+	face := GetFace(fontId)
+	scale := face.InvUPM * size
+	return face.Ascender * scale, -face.Descender * scale
+}
+
 const LOG_FONTS = false
 
 var _faceIdLock sync.Mutex
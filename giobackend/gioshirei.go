@@ -3,6 +3,7 @@ package giobackend
 import (
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
 	"time"
@@ -57,6 +58,12 @@ func Run(frameFn shirei.FrameFn) {
 
 	var lastEventTime time.Time
 
+	// for frames requested with shirei.RequestFrameAfter
+	wakeTimer := time.AfterFunc(time.Hour, func() {
+		window.Invalidate()
+	})
+	wakeTimer.Stop()
+
 	var tag = new(int) // just a thing that gio events can attach to
 	go func() {
 		for {
@@ -80,13 +87,18 @@ func Run(frameFn shirei.FrameFn) {
 				}.Push(ctx.Ops)
 
 				ctx.Execute(key.FocusCmd{Tag: tag})
+				// keep reporting the same text to the IME or it will drop the composition
+				ctx.Execute(key.SnippetCmd{
+					Tag:     tag,
+					Snippet: ime.snippet(),
+				})
 				ctx.Execute(key.SelectionCmd{
-					Tag: tag,
+					Tag:   tag,
+					Range: ime.selection,
 					Caret: key.Caret{
-						// arbitrary value!
-						Pos:     f32Point(shirei.CaretPos),
-						Ascent:  20,
-						Descent: 10,
+						Pos:     f32Point(shirei.Vec2Mul(shirei.CaretPos, dpi)),
+						Ascent:  shirei.CaretAscent * dpi,
+						Descent: shirei.CaretDescent * dpi,
 					},
 				})
 				event.Op(ctx.Ops, tag)
@@ -136,6 +148,8 @@ func Run(frameFn shirei.FrameFn) {
 						switch e.Kind {
 						case pointer.Press:
 							shirei.FrameInput.Mouse = shirei.MouseClick
+							// the caret is probably moving elsewhere
+							ime.reset()
 						case pointer.Release:
 							shirei.FrameInput.Mouse = shirei.MouseRelease
 						}
@@ -147,6 +161,12 @@ func Run(frameFn shirei.FrameFn) {
 						if e.State == key.Press {
 							// fmt.Println("Key:", e.Name)
 							shirei.FrameInput.Key = keyCode
+
+							// input methods consume command keys while composing, so
+							// seeing one means the typed text is no longer being edited
+							if isCommandKey(keyCode) || e.Modifiers.Contain(key.ModCtrl) || e.Modifiers.Contain(key.ModCommand) {
+								ime.reset()
+							}
 						}
 						if keyCode != 0 {
 							switch e.State {
@@ -169,19 +189,25 @@ func Run(frameFn shirei.FrameFn) {
 
 					case key.FocusEvent:
 						// fmt.Printf("Focus event: %#v\n", e)
+						ime.reset()
 					case key.EditEvent:
-						shirei.FrameInput.Text = e.Text
+						ime.edit(e)
 						// fmt.Printf("Edit: %#v\n", e)
 					case key.SnippetEvent:
+						// we always report the entire mirror as the snippet
 						// fmt.Printf("Snippet: %#v\n", e)
 					case key.SelectionEvent:
+						ime.setSelection(key.Range(e))
 						// fmt.Printf("Selection %#v\n", e)
 					default:
 						fmt.Printf("unhandled %#v\n", e)
 					}
 				}
 
+				ime.endFrame()
+
 				frameData := shirei.RunFrameFn(frameFn)
+				dropFreedImageOps()
 				callOp := renderSurfaces(frameData.Surfaces)
 				callOp.Add(ctx.Ops)
 				e.Frame(ctx.Ops)
@@ -201,6 +227,9 @@ func Run(frameFn shirei.FrameFn) {
 				if frameData.NextFrameRequested || time.Since(lastEventTime) < time.Second {
 					window.Invalidate()
 				}
+				if !frameData.WakeAt.IsZero() {
+					wakeTimer.Reset(time.Until(frameData.WakeAt))
+				}
 			}
 		}
 	}()
@@ -221,6 +250,28 @@ func imgPointMul(p image.Point, f int) image.Point {
 	}
 }
 
+// a clip to rect with its edges on device pixels, unless something rotates
+// it, so the pieces of a nine-slice image don't show seams where they touch
+func pushPixelRectClip(ops *op.Ops, rect shirei.Rect, transform f32.Affine2D) clip.Stack {
+	var lo, hi = f32Point(rect.Origin), f32Point(shirei.Vec2Add(rect.Origin, rect.Size))
+	if sx, hx, _, hy, sy, _ := transform.Elems(); hx == 0 && hy == 0 && sx != 0 && sy != 0 {
+		var inverse = transform.Invert()
+		snap := func(p f32.Point) f32.Point {
+			var d = transform.Transform(p)
+			return inverse.Transform(f32.Pt(float32(math.Round(float64(d.X))), float32(math.Round(float64(d.Y)))))
+		}
+		lo, hi = snap(lo), snap(hi)
+	}
+	var path clip.Path
+	path.Begin(ops)
+	path.MoveTo(lo)
+	path.LineTo(f32.Pt(hi.X, lo.Y))
+	path.LineTo(hi)
+	path.LineTo(f32.Pt(lo.X, hi.Y))
+	path.Close()
+	return clip.Outline{Path: path.End()}.Op().Push(ops)
+}
+
 func f32Point(v shirei.Vec2) f32.Point {
 	return f32.Pt(v[0], v[1])
 }
@@ -240,7 +291,30 @@ func renderSurfaces(surfaces []shirei.Surface) op.CallOp {
 	macro := op.Record(ops)
 
 	// support hidpi
-	op.Affine(f32.Affine2D{}.Scale(f32.Pt(0, 0), f32.Pt(dpi, dpi))).Add(ops)
+	var transform = f32.Affine2D{}.Scale(f32.Pt(0, 0), f32.Pt(dpi, dpi))
+	op.Affine(transform).Add(ops)
+
+	// container transforms; gradient stops are not transformed by gio, so we
+	// keep track of the full transform to place them
+	type transformEntry struct {
+		stack op.TransformStack
+		prev  f32.Affine2D
+	}
+	var transformStack []transformEntry
+	pushTransform := func(a shirei.Affine) {
+		local := f32.NewAffine2D(a[0], a[1], a[2], a[3], a[4], a[5])
+		transformStack = append(transformStack, transformEntry{stack: op.Affine(local).Push(ops), prev: transform})
+		transform = transform.Mul(local)
+	}
+	popTransform := func() {
+		if len(transformStack) == 0 {
+			panic("surface rendering: uneven push/pop transform stack")
+		}
+		last := transformStack[len(transformStack)-1]
+		last.stack.Pop()
+		transform = last.prev
+		transformStack = transformStack[:len(transformStack)-1]
+	}
 
 	var stackStack []clip.Stack
 
@@ -274,11 +348,14 @@ func renderSurfaces(surfaces []shirei.Surface) op.CallOp {
 	}
 
 	for _, s := range surfaces {
+		if s.TransformOp == shirei.ClipPush {
+			pushTransform(s.Affine)
+		}
+
 		r := s.Rect
 		grad := paint.LinearGradientOp{
-			// NOTE: I'm not sure why we need the dpi multiplication here but oh well
-			Stop1:  f32.Pt(r.Origin[0]*dpi, r.Origin[1]*dpi),
-			Stop2:  f32.Pt(r.Origin[0]*dpi, (r.Origin[1]+s.Rect.Size[1])*dpi),
+			Stop1:  transform.Transform(f32.Pt(r.Origin[0], r.Origin[1])),
+			Stop2:  transform.Transform(f32.Pt(r.Origin[0], r.Origin[1]+s.Rect.Size[1])),
 			Color1: shirei.HSLAColor(s.Color1),
 			Color2: shirei.HSLAColor(s.Color2),
 		}
@@ -327,7 +404,7 @@ func renderSurfaces(surfaces []shirei.Surface) op.CallOp {
 			affine = affine.Scale(f32.Pt(0, 0), f32.Pt(scale, scale))
 			affine = affine.Offset(f32Point(s.Rect.Origin))
 
-			affine = affine.Offset(f32.Pt(0, s.Rect.Size[1]*0.82)) // place the baseline at 0.82 point of the height
+			affine = affine.Offset(f32.Pt(0, s.Rect.Size[1]*shirei.Baseline)) // place the baseline at shirei.Baseline of the height
 
 			stack := op.Affine(affine).Push(ops)
 			stack2 := sh.Push(ops)
@@ -341,17 +418,25 @@ func renderSurfaces(surfaces []shirei.Surface) op.CallOp {
 			imgData := shirei.LookupImage(s.ImageId)
 			img := &imgData.RGBA
 
-			// FIXME: we should cache this op or something ..
-			imgOp := paint.NewImageOp(img)
+			imgOp := cachedImageOp(s.ImageId, imgData)
 
-			var affine f32.Affine2D
+			var src = s.ImageSrc
+			if src.Size == (shirei.Vec2{}) {
+				src.Size = shirei.Vec2{float32(img.Bounds().Dx()), float32(img.Bounds().Dy())}
+			}
 
-			// just like with glyphs, use the height as the deciding factor for scaling
-			if s.ImageScale {
-				var scale = s.Rect.Size[1] / float32(img.Bounds().Dy())
-				affine = affine.Scale(f32.Pt(0, 0), f32.Pt(scale, scale))
+			// a part of the image is drawn clipped to the surface
+			var cropped = s.ImageSrc != (shirei.Rect{})
+			var clipStack clip.Stack
+			if cropped {
+				clipStack = pushPixelRectClip(ops, s.Rect, transform)
 			}
 
+			var affine = f32.Affine2D{}.Offset(f32Point(shirei.Vec2Mul(src.Origin, -1)))
+			if s.ImageScale {
+				var scale = f32.Pt(s.Rect.Size[0]/src.Size[0], s.Rect.Size[1]/src.Size[1])
+				affine = affine.Scale(f32.Pt(0, 0), scale)
+			}
 			affine = affine.Offset(f32Point(s.Rect.Origin))
 
 			stack := op.Affine(affine).Push(ops)
@@ -359,6 +444,41 @@ func renderSurfaces(surfaces []shirei.Surface) op.CallOp {
 			imgOp.Add(ops)
 			paint.PaintOp{}.Add(ops)
 
+			stack.Pop()
+			if cropped {
+				clipStack.Pop()
+			}
+		} else if s.PathId > 0 {
+			var path clip.Path
+			path.Begin(ops)
+			for _, cmd := range shirei.LookupPath(s.PathId) {
+				var pts [3]f32.Point
+				for i, p := range cmd.Points {
+					pts[i] = f32Point(shirei.Vec2Add(p, s.Rect.Origin))
+				}
+				switch cmd.Verb {
+				case shirei.PathMoveTo:
+					path.MoveTo(pts[0])
+				case shirei.PathLineTo:
+					path.LineTo(pts[0])
+				case shirei.PathQuadTo:
+					path.QuadTo(pts[0], pts[1])
+				case shirei.PathCubeTo:
+					path.CubeTo(pts[0], pts[1], pts[2])
+				case shirei.PathClose:
+					path.Close()
+				}
+			}
+
+			stack := clip.Outline{Path: path.End()}.Op().Push(ops)
+
+			if s.GradientId > 0 {
+				paintGradient(ops, shirei.LookupGradient(s.GradientId), s.Rect, transform)
+			} else {
+				grad.Add(ops)
+				paint.PaintOp{}.Add(ops)
+			}
+
 			stack.Pop()
 		} else {
 			var sh clip.Op
@@ -373,8 +493,16 @@ func renderSurfaces(surfaces []shirei.Surface) op.CallOp {
 
 			stack := sh.Push(ops)
 
-			grad.Add(ops)
-			paint.PaintOp{}.Add(ops)
+			if s.GradientId > 0 && s.Stroke == 0 {
+				if s.Color1[3] > 0 {
+					paint.ColorOp{Color: shirei.HSLAColor(s.Color1)}.Add(ops)
+					paint.PaintOp{}.Add(ops)
+				}
+				paintGradient(ops, shirei.LookupGradient(s.GradientId), s.Rect, transform)
+			} else {
+				grad.Add(ops)
+				paint.PaintOp{}.Add(ops)
+			}
 
 			stack.Pop()
 		}
@@ -386,6 +514,10 @@ func renderSurfaces(surfaces []shirei.Surface) op.CallOp {
 		if s.Clip == shirei.ClipPop {
 			popClipMask()
 		}
+
+		if s.TransformOp == shirei.ClipPop {
+			popTransform()
+		}
 	}
 
 	if len(stackStack) != 0 {
@@ -452,6 +584,15 @@ func FontGlyphPathSpec(fontId shirei.FontId, glyphId shirei.GlyphId) clip.PathSp
 	}
 }
 
+// keys that act on the text rather than input it (modifiers excluded)
+func isCommandKey(code shirei.KeyCode) bool {
+	switch code {
+	case shirei.KeyCtrl, shirei.KeyShift, shirei.KeyAlt, shirei.KeySuper, shirei.KeyCommand, shirei.KeySpace:
+		return false
+	}
+	return code >= shirei.KeyLeft
+}
+
 func mapKeyCode(name key.Name) shirei.KeyCode {
 	switch name {
 	case key.NameLeftArrow:
@@ -0,0 +1,117 @@
+package giobackend
+
+import (
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"github.com/dboslee/lru"
+	"go.hasen.dev/shirei"
+)
+
+// radial and conic gradients are drawn from images, which only depend on the
+// stops (and the start angle of conic ones)
+var gradientImages = lru.New[shirei.Gradient, paint.ImageOp](lru.WithCapacity(64))
+
+// paintGradient fills the current clip with g laid over rect. gio doesn't
+// transform gradient stops, so it needs the full transform.
+func paintGradient(ops *op.Ops, g shirei.Gradient, rect shirei.Rect, transform f32.Affine2D) {
+	var stops = g.StopList()
+	switch {
+	case len(stops) == 0:
+		return
+	case len(stops) == 1:
+		paint.ColorOp{Color: shirei.HSLAColor(stops[0].Color)}.Add(ops)
+		paint.PaintOp{}.Add(ops)
+	case g.Kind == shirei.GradientLinear:
+		paintLinearGradient(ops, g, rect, transform)
+	default:
+		var key = g
+		key.Center, key.Radius = shirei.Vec2{}, shirei.Vec2{}
+		if g.Kind == shirei.GradientRadial {
+			key.Angle = 0
+		}
+		imgOp, ok := gradientImages.Get(key)
+		if !ok {
+			imgOp = paint.NewImageOp(shirei.GradientImage(g))
+			gradientImages.Set(key, imgOp)
+		}
+
+		var center, radius shirei.Vec2
+		if g.Kind == shirei.GradientRadial {
+			center, radius = g.RadialBox(rect)
+			// beyond the ellipse
+			paint.ColorOp{Color: shirei.HSLAColor(stops[len(stops)-1].Color)}.Add(ops)
+			paint.PaintOp{}.Add(ops)
+		} else {
+			var half float32
+			center, half = g.ConicBox(rect)
+			radius = shirei.Vec2{half, half}
+		}
+
+		var scale = shirei.Vec2Mul(radius, 2/float32(shirei.GradientImageSize))
+		var affine = f32.Affine2D{}.Scale(f32.Pt(0, 0), f32Point(scale)).Offset(f32Point(shirei.Vec2Sub(center, radius)))
+		stack := op.Affine(affine).Push(ops)
+		imgOp.Add(ops)
+		paint.PaintOp{}.Add(ops)
+		stack.Pop()
+	}
+}
+
+// a band across the gradient line for each pair of stops, each painted with
+// a two stop gradient
+func paintLinearGradient(ops *op.Ops, g shirei.Gradient, rect shirei.Rect, transform f32.Affine2D) {
+	var start, end = g.LinearPoints(rect)
+	var dir = shirei.Vec2Sub(end, start)
+	var dirLength = float32(math.Hypot(float64(dir[0]), float64(dir[1])))
+	if dirLength == 0 {
+		return
+	}
+	// long enough for the bands to cover the rect on either side of the line
+	var reach = rect.Size[0] + rect.Size[1]
+	var across = shirei.Vec2Mul(shirei.Vec2{-dir[1], dir[0]}, reach/dirLength)
+	at := func(t float32) shirei.Vec2 {
+		return shirei.Vec2Add(start, shirei.Vec2Mul(dir, t))
+	}
+
+	var stops = g.StopList()
+	for i := 0; i+1 < len(stops); i++ {
+		var a, b = stops[i], stops[i+1]
+		var t0, t1 = a.At, b.At
+		if t1 <= t0 {
+			continue // a hard stop
+		}
+		// the rect is within 0 and 1; the ends pad it. each band starts a
+		// pixel early so no seam shows between them
+		if i == 0 {
+			t0 = -0.01
+		} else {
+			t0 -= 1 / dirLength
+		}
+		if i+2 == len(stops) {
+			t1 = 1.01
+		}
+
+		var p0, p1 = at(t0), at(t1)
+		var path clip.Path
+		path.Begin(ops)
+		path.MoveTo(f32Point(shirei.Vec2Add(p0, across)))
+		path.LineTo(f32Point(shirei.Vec2Add(p1, across)))
+		path.LineTo(f32Point(shirei.Vec2Sub(p1, across)))
+		path.LineTo(f32Point(shirei.Vec2Sub(p0, across)))
+		path.Close()
+		stack := clip.Outline{Path: path.End()}.Op().Push(ops)
+
+		paint.LinearGradientOp{
+			Stop1:  transform.Transform(f32Point(at(a.At))),
+			Stop2:  transform.Transform(f32Point(at(b.At))),
+			Color1: shirei.HSLAColor(a.Color),
+			Color2: shirei.HSLAColor(b.Color),
+		}.Add(ops)
+		paint.PaintOp{}.Add(ops)
+
+		stack.Pop()
+	}
+}
@@ -0,0 +1,33 @@
+package giobackend
+
+import (
+	"gioui.org/op/paint"
+	"github.com/dboslee/lru"
+	"go.hasen.dev/shirei"
+)
+
+type imageOpEntry struct {
+	data *shirei.ImageData
+	op   paint.ImageOp
+}
+
+// the pixels of an image id never change, so its op can be kept until the
+// image is freed (see dropFreedImageOps); ids are reused after that, so the
+// data tells them apart too
+var imageOps = lru.New[shirei.ImageId, imageOpEntry](lru.WithCapacity(512))
+
+// the ops hold on to the pixels, which are let go with the image
+func dropFreedImageOps() {
+	shirei.DrainFreedImages(func(id shirei.ImageId) {
+		imageOps.Delete(id)
+	})
+}
+
+func cachedImageOp(id shirei.ImageId, data *shirei.ImageData) paint.ImageOp {
+	entry, ok := imageOps.Get(id)
+	if !ok || entry.data != data {
+		entry = imageOpEntry{data: data, op: paint.NewImageOp(&data.RGBA)}
+		imageOps.Set(id, entry)
+	}
+	return entry.op
+}
@@ -0,0 +1,133 @@
+package giobackend
+
+import (
+	"slices"
+
+	"gioui.org/io/key"
+	"go.hasen.dev/shirei"
+)
+
+// Gio's input methods edit a text buffer they believe the application owns:
+// they replace ranges of it (preedit updates, commits) and expect the snippet
+// and selection reported back to stay stable; on macOS, a change in either one
+// discards the text being composed.
+//
+// shirei widgets own their text, so the backend keeps a mirror of the text
+// typed recently and translates the edits applied to it into FrameInput.Text
+// and FrameInput.TextReplace, relative to the widget's caret. The composition
+// region is not exposed by Gio, so we infer it from the shape of the edits:
+//
+//   - inserting at a point is plain typing, and ends any composition
+//   - replacing a range with new text is a preedit update
+//   - replacing a range with the same text (or nothing) is a commit (or cancel)
+
+var noRange = key.Range{Start: -1, End: -1}
+
+type imeState struct {
+	text      []rune
+	selection key.Range
+	compose   key.Range // Start is -1 when not composing
+
+	// the text as it was last reported to the frame input
+	reported []rune
+}
+
+var ime = imeState{compose: noRange}
+
+// the mirror is only reset when no composition is in progress, so it can grow
+// with plain typing; we don't want to resend an unbounded snippet every frame
+const imeMaxText = 256
+
+func (s *imeState) composing() bool {
+	return s.compose.Start != -1
+}
+
+// forget the text typed so far (e.g. caret moved by other means)
+func (s *imeState) reset() {
+	s.flush()
+	s.text = s.text[:0]
+	s.reported = s.reported[:0]
+	s.selection = key.Range{}
+	s.compose = noRange
+}
+
+func (s *imeState) clampRange(r key.Range) key.Range {
+	if r.Start > r.End {
+		r.Start, r.End = r.End, r.Start
+	}
+	r.Start = max(0, min(r.Start, len(s.text)))
+	r.End = max(r.Start, min(r.End, len(s.text)))
+	return r
+}
+
+func (s *imeState) edit(e key.EditEvent) {
+	r := s.clampRange(e.Range)
+	runes := []rune(e.Text)
+	replaced := string(s.text[r.Start:r.End])
+
+	s.text = slices.Replace(s.text, r.Start, r.End, runes...)
+	edited := key.Range{Start: r.Start, End: r.Start + len(runes)}
+
+	switch {
+	case r.Start == r.End:
+		s.compose = noRange
+	case replaced == e.Text || len(runes) == 0:
+		s.compose = noRange
+	default:
+		s.compose = edited
+	}
+
+	// normally followed by a selection event, but just in case
+	s.selection = key.Range{Start: edited.End, End: edited.End}
+}
+
+func (s *imeState) setSelection(r key.Range) {
+	s.selection = s.clampRange(r)
+}
+
+func (s *imeState) snippet() key.Snippet {
+	return key.Snippet{
+		Range: key.Range{Start: 0, End: len(s.text)},
+		Text:  string(s.text),
+	}
+}
+
+// report the changes to the mirror text since the last flush as frame input
+func (s *imeState) flush() {
+	if slices.Equal(s.reported, s.text) {
+		return
+	}
+
+	// widgets insert at their caret, which is at the end of the mirror
+	var common int
+	for common < len(s.reported) && common < len(s.text) && s.reported[common] == s.text[common] {
+		common++
+	}
+	replace := len(s.reported) - common
+
+	// combine with what was already reported this frame
+	pending := []rune(shirei.FrameInput.Text)
+	undo := min(replace, len(pending))
+	pending = pending[:len(pending)-undo]
+	shirei.FrameInput.TextReplace += replace - undo
+	shirei.FrameInput.Text = string(append(pending, s.text[common:]...))
+
+	s.reported = append(s.reported[:0], s.text...)
+}
+
+// called after all the events of the frame are processed
+func (s *imeState) endFrame() {
+	s.flush()
+
+	shirei.InputState.Composition = ""
+	shirei.InputState.CompositionRange = [2]int{}
+	if s.composing() {
+		shirei.InputState.Composition = string(s.text[s.compose.Start:s.compose.End])
+		sel := s.clampRange(s.selection)
+		for i, pos := range [2]int{sel.Start, sel.End} {
+			shirei.InputState.CompositionRange[i] = max(0, min(pos, s.compose.End)-s.compose.Start)
+		}
+	} else if len(s.text) > imeMaxText {
+		s.reset()
+	}
+}
@@ -0,0 +1,122 @@
+package giobackend
+
+import (
+	"strings"
+	"testing"
+
+	"gioui.org/io/key"
+	"go.hasen.dev/shirei"
+)
+
+// the frame input and composition a frame of edits reports
+type imeFrame struct {
+	text        string
+	replace     int
+	composition string
+}
+
+func runIMEFrame(s *imeState, edits ...key.EditEvent) imeFrame {
+	shirei.FrameInput.Text = ""
+	shirei.FrameInput.TextReplace = 0
+	for _, e := range edits {
+		s.edit(e)
+	}
+	s.endFrame()
+	return imeFrame{shirei.FrameInput.Text, shirei.FrameInput.TextReplace, shirei.InputState.Composition}
+}
+
+func imeEdit(start, end int, text string) key.EditEvent {
+	return key.EditEvent{Range: key.Range{Start: start, End: end}, Text: text}
+}
+
+func TestIMESequences(t *testing.T) {
+	var cases = []struct {
+		name   string
+		frames [][]key.EditEvent
+		want   []imeFrame
+	}{
+		{
+			name:   "plain typing",
+			frames: [][]key.EditEvent{{imeEdit(0, 0, "a")}, {imeEdit(1, 1, "b")}},
+			want:   []imeFrame{{"a", 0, ""}, {"b", 0, ""}},
+		},
+		{
+			name: "preedit updates, then commit",
+			frames: [][]key.EditEvent{
+				{imeEdit(0, 0, "n")},
+				{imeEdit(0, 1, "に")},
+				{imeEdit(0, 1, "にほ")},
+				{imeEdit(0, 2, "にほ")},
+			},
+			want: []imeFrame{{"n", 0, ""}, {"に", 1, "に"}, {"ほ", 0, "にほ"}, {"", 0, ""}},
+		},
+		{
+			name: "commit with other text",
+			frames: [][]key.EditEvent{
+				{imeEdit(0, 0, "n")},
+				{imeEdit(0, 1, "にほ")},
+				{imeEdit(0, 2, "日本")},
+			},
+			want: []imeFrame{{"n", 0, ""}, {"にほ", 1, "にほ"}, {"日本", 2, "日本"}},
+		},
+		{
+			name: "cancel",
+			frames: [][]key.EditEvent{
+				{imeEdit(0, 0, "n")},
+				{imeEdit(0, 1, "にほ")},
+				{imeEdit(0, 2, "")},
+			},
+			want: []imeFrame{{"n", 0, ""}, {"にほ", 1, "にほ"}, {"", 2, ""}},
+		},
+		{
+			name:   "edits of one frame are reported together",
+			frames: [][]key.EditEvent{{imeEdit(0, 0, "n"), imeEdit(0, 1, "に"), imeEdit(0, 1, "にほ")}},
+			want:   []imeFrame{{"にほ", 0, "にほ"}},
+		},
+		{
+			name:   "out of range edits are clamped",
+			frames: [][]key.EditEvent{{imeEdit(0, 0, "ab")}, {imeEdit(5, 9, "c")}},
+			want:   []imeFrame{{"ab", 0, ""}, {"c", 0, ""}},
+		},
+	}
+	for _, c := range cases {
+		var s = imeState{compose: noRange}
+		for i, edits := range c.frames {
+			if got := runIMEFrame(&s, edits...); got != c.want[i] {
+				t.Errorf("%s, frame %d: got %+v, want %+v", c.name, i, got, c.want[i])
+			}
+		}
+	}
+}
+
+func TestIMECompositionRange(t *testing.T) {
+	var s = imeState{compose: noRange}
+	runIMEFrame(&s, imeEdit(0, 0, "ab"))
+	s.edit(imeEdit(2, 2, "x"))
+	s.edit(imeEdit(2, 3, "かな"))
+	s.setSelection(key.Range{Start: 3, End: 4})
+	s.endFrame()
+	if shirei.InputState.Composition != "かな" || shirei.InputState.CompositionRange != [2]int{1, 2} {
+		t.Errorf("got %q %v, want %q [1 2]", shirei.InputState.Composition, shirei.InputState.CompositionRange, "かな")
+	}
+}
+
+func TestIMEReset(t *testing.T) {
+	var s = imeState{compose: noRange}
+
+	// the caret moved between two keys: both are reported, the mirror is new
+	shirei.FrameInput.Text, shirei.FrameInput.TextReplace = "", 0
+	s.edit(imeEdit(0, 0, "a"))
+	s.reset()
+	s.edit(imeEdit(0, 0, "b"))
+	s.endFrame()
+	if shirei.FrameInput.Text != "ab" || shirei.FrameInput.TextReplace != 0 || string(s.text) != "b" {
+		t.Errorf("got %q replacing %d with mirror %q", shirei.FrameInput.Text, shirei.FrameInput.TextReplace, string(s.text))
+	}
+
+	// the mirror doesn't grow without bound while not composing
+	runIMEFrame(&s, imeEdit(1, 1, strings.Repeat("z", imeMaxText)))
+	if len(s.text) != 0 {
+		t.Errorf("mirror kept %d runes past the limit", len(s.text))
+	}
+}
@@ -0,0 +1,203 @@
+package shirei
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+//      Gradients
+// -----------------------------------------------------------------------------
+// A Gradient is a flat value with a fixed number of stops, kept with the frame
+// in one buffer; attrs and surfaces refer to it by id, like paths.
+
+type GradientKind uint8
+
+const (
+	GradientNone GradientKind = iota
+	GradientLinear
+	GradientRadial
+	GradientConic
+)
+
+type GradientStop struct {
+	At    f32 // from 0 to 1 along the gradient
+	Color Vec4
+}
+
+const MaxGradientStops = 8
+
+type Gradient struct {
+	Kind GradientKind
+
+	// in degrees. linear: the direction, like css (0 to the top, 90 to the
+	// right, 180 to the bottom); conic: where it starts, clockwise from the top
+	Angle f32
+
+	// radial and conic: from the center as a fraction of the size, like Pivot
+	Center Vec2
+
+	// radial: as a fraction of the size; zero reaches the farthest corner
+	Radius Vec2
+
+	Stops     [MaxGradientStops]GradientStop
+	StopCount uint8
+}
+
+func LinearGradient(angle f32, stops ...GradientStop) Gradient {
+	var g = Gradient{Kind: GradientLinear, Angle: angle}
+	g.setStops(stops)
+	return g
+}
+
+func RadialGradient(stops ...GradientStop) Gradient {
+	var g = Gradient{Kind: GradientRadial}
+	g.setStops(stops)
+	return g
+}
+
+func ConicGradient(angle f32, stops ...GradientStop) Gradient {
+	var g = Gradient{Kind: GradientConic, Angle: angle}
+	g.setStops(stops)
+	return g
+}
+
+// keeps the first MaxGradientStops, and makes sure they don't go backwards
+func (g *Gradient) setStops(stops []GradientStop) {
+	g.StopCount = uint8(copy(g.Stops[:], stops))
+	for i := range g.StopCount {
+		var stop = &g.Stops[i]
+		stop.At = max(0, min(1, stop.At))
+		if i > 0 {
+			stop.At = max(stop.At, g.Stops[i-1].At)
+		}
+	}
+}
+
+func (g Gradient) StopList() []GradientStop {
+	return g.Stops[:g.StopCount]
+}
+
+// ColorAt interpolates the stops in rgb, like the backends do, and pads
+// beyond the ends
+func (g Gradient) ColorAt(t f32) color.NRGBA {
+	var stops = g.StopList()
+	if len(stops) == 0 {
+		return color.NRGBA{}
+	}
+	if t <= stops[0].At {
+		return HSLAColor(stops[0].Color)
+	}
+	for i := 1; i < len(stops); i++ {
+		if t > stops[i].At {
+			continue
+		}
+		var a, b = HSLAColor(stops[i-1].Color), HSLAColor(stops[i].Color)
+		var span = stops[i].At - stops[i-1].At
+		if span <= 0 {
+			return b
+		}
+		var f = (t - stops[i-1].At) / span
+		mix := func(x, y uint8) uint8 {
+			return uint8(f32(x) + (f32(y)-f32(x))*f + 0.5)
+		}
+		return color.NRGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+	}
+	return HSLAColor(stops[len(stops)-1].Color)
+}
+
+func (g Gradient) center(rect Rect) Vec2 {
+	return Vec2{
+		rect.Origin[0] + rect.Size[0]*(0.5+g.Center[0]),
+		rect.Origin[1] + rect.Size[1]*(0.5+g.Center[1]),
+	}
+}
+
+// LinearPoints are where a linear gradient starts and ends over rect; like
+// css, the corners in its direction get the end colors
+func (g Gradient) LinearPoints(rect Rect) (start, end Vec2) {
+	var sin, cos = math.Sincos(float64(g.Angle) * math.Pi / 180)
+	var dir = Vec2{f32(sin), -f32(cos)}
+	var length = Absf32(rect.Size[0]*dir[0]) + Absf32(rect.Size[1]*dir[1])
+	var mid = Vec2Add(rect.Origin, Vec2Mul(rect.Size, 0.5))
+	var half = Vec2Mul(dir, length/2)
+	return Vec2Sub(mid, half), Vec2Add(mid, half)
+}
+
+// RadialBox is the center and radii of the ellipse a radial gradient ends on
+func (g Gradient) RadialBox(rect Rect) (center, radius Vec2) {
+	center = g.center(rect)
+	if g.Radius != (Vec2{}) {
+		return center, Vec2{rect.Size[0] * g.Radius[0], rect.Size[1] * g.Radius[1]}
+	}
+	// the farthest side on each axis, grown to pass through the corner
+	var end = Vec2Add(rect.Origin, rect.Size)
+	radius[0] = max(Absf32(center[0]-rect.Origin[0]), Absf32(end[0]-center[0])) * math.Sqrt2
+	radius[1] = max(Absf32(center[1]-rect.Origin[1]), Absf32(end[1]-center[1])) * math.Sqrt2
+	return center, radius
+}
+
+// ConicBox is a square around the center of a conic gradient that covers rect
+func (g Gradient) ConicBox(rect Rect) (center Vec2, half f32) {
+	center = g.center(rect)
+	var end = Vec2Add(rect.Origin, rect.Size)
+	var dx = max(Absf32(center[0]-rect.Origin[0]), Absf32(end[0]-center[0]))
+	var dy = max(Absf32(center[1]-rect.Origin[1]), Absf32(end[1]-center[1]))
+	return center, max(dx, dy)
+}
+
+const GradientImageSize = 256
+
+// GradientImage renders a radial or conic gradient over its box (see
+// RadialBox and ConicBox) for backends that can't draw them; it's smooth
+// enough to be scaled up
+func GradientImage(g Gradient) *image.NRGBA {
+	const size = GradientImageSize
+	var img = image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			// from -1 to 1 over the box
+			var u = (f32(x)+0.5)/size*2 - 1
+			var v = (f32(y)+0.5)/size*2 - 1
+			var t f32
+			if g.Kind == GradientConic {
+				var angle = math.Atan2(float64(u), float64(-v))*180/math.Pi - float64(g.Angle)
+				t = f32(math.Mod(angle+720, 360) / 360)
+			} else {
+				t = f32(math.Hypot(float64(u), float64(v)))
+			}
+			img.SetNRGBA(x, y, g.ColorAt(t))
+		}
+	}
+	return img
+}
+
+type GradientId uint32
+
+// reset every frame; the zero id is no gradient
+var gradients = make([]Gradient, 1, 64)
+
+// this function is mostly for the backend
+func LookupGradient(id GradientId) Gradient {
+	return gradients[id]
+}
+
+// FrameGradient keeps g with the frame, for Attrs.Fill. Like a path, the id is
+// only good in the frame it's made in, so attrs with a fill are made every
+// frame rather than kept in a var.
+func FrameGradient(g Gradient) GradientId {
+	if g.Kind == GradientNone {
+		return 0
+	}
+	return pushGradient(g)
+}
+
+func pushGradient(g Gradient) GradientId {
+	gradients = append(gradients, g)
+	return GradientId(len(gradients) - 1)
+}
+
+func resetGradients() {
+	gradients = gradients[:1]
+}
@@ -18,6 +18,21 @@ func Use[T any](itemKey any) *T {
 
 func UseWithInit[T any](itemKey any, initFn func() *T) *T {
 	var key = HookEntryKey{Data: CurrentId(), ItemKey: itemKey}
+
+	if current.hookGroup != nil {
+		var group = getHookGroup(current.hookGroup)
+		value, found := group.hooks[key]
+		if !found {
+			if initFn != nil {
+				value = initFn()
+			} else {
+				value = new(T)
+			}
+			group.hooks[key] = value
+		}
+		return value.(*T)
+	}
+
 	var value, found = hooksMap[key]
 	if found {
 		hooksMapNext[key] = value
@@ -36,6 +51,39 @@ func UseWithInit[T any](itemKey any, initFn func() *T) *T {
 	}
 }
 
+// hook groups keep the state of views that are not built every frame (e.g. the
+// content of inactive tabs): hooks used inside them are kept until the group is
+// forgotten, and so are the scroll offsets of their containers
+type hookGroup struct {
+	hooks  map[HookEntryKey]any
+	scroll map[any]Vec2
+}
+
+var hookGroups = make(map[any]*hookGroup)
+
+func getHookGroup(group any) *hookGroup {
+	g, found := hookGroups[group]
+	if !found {
+		g = &hookGroup{
+			hooks:  make(map[HookEntryKey]any),
+			scroll: make(map[any]Vec2),
+		}
+		hookGroups[group] = g
+	}
+	return g
+}
+
+// PersistHooks puts the hooks used by the children of the current container in
+// the given group, so they persist across frames where they are not used,
+// until ForgetHooks is called for the group
+func PersistHooks(group any) {
+	current.hookGroup = group
+}
+
+func ForgetHooks(group any) {
+	delete(hookGroups, group)
+}
+
 // data hooks, unlike ui hooks, do not disappear when you don't use them in a frame
 var dataHooks = make(map[HookEntryKey]any)
 
@@ -0,0 +1,224 @@
+package shirei
+
+import "math"
+
+// how an image fills its box, like css object-fit
+type ImageFit uint8
+
+const (
+	FitScaleDown ImageFit = iota // like contain, but never bigger than the image
+	FitContain
+	FitCover
+	FitFill
+	FitNone
+)
+
+type ImageAttrs struct {
+	// the box; zero on an axis follows the image, keeping its aspect
+	Size    Vec2
+	MaxSize Vec2
+
+	Fit ImageFit
+
+	// where the image sits in the box, from the center as a fraction, like
+	// css object-position: {-0.5, -0.5} keeps the top left corner in view
+	// when covering
+	Focus Vec2
+
+	// the part of the image shown, in pixels; zero is all of it
+	Crop Rect
+
+	Corners Vec4
+	Border
+
+	// repeats the image at TileSize (zero is its own size) instead of fitting
+	// it; one tile sits where Focus puts it, so {-0.5, -0.5} starts at the top left.
+	// Tiles grow when too many would be needed to cover the box.
+	Tile     bool
+	TileSize Vec2
+
+	// nine-slice: the edges of the image in pixels, css order, which keep
+	// their size while the middle stretches. Fit doesn't apply.
+	Slice Vec4
+}
+
+func DefaultImageAttrs() ImageAttrs {
+	return ImageAttrs{}
+}
+
+// what a container draws of an image; the layout parts of ImageAttrs are
+// already applied to the container
+type containerImage struct {
+	id       ImageId
+	fit      ImageFit
+	focus    Vec2
+	crop     Rect
+	tile     bool
+	tileSize Vec2
+	slice    Vec4
+
+	anim *ImageAnimation // playing while it's seen
+}
+
+// BackgroundImage draws an image over the background of the current
+// container, under its children, clipped to it; the container's size and
+// corners are used instead of the ones in attrs
+func BackgroundImage(id ImageId, attrs ImageAttrs) {
+	current.image = containerImage{
+		id:       id,
+		fit:      attrs.Fit,
+		focus:    attrs.Focus,
+		crop:     attrs.Crop,
+		tile:     attrs.Tile,
+		tileSize: attrs.TileSize,
+		slice:    attrs.Slice,
+	}
+}
+
+// the size of the box for an image of the natural size
+func imageBoxSize(natural Vec2, attrs ImageAttrs) Vec2 {
+	if attrs.Crop.Size != (Vec2{}) {
+		natural = attrs.Crop.Size
+	}
+	var size = attrs.Size
+	switch {
+	case size[0] == 0 && size[1] == 0:
+		size = RestrictedSize(natural, attrs.MaxSize)
+	case size[0] == 0 && natural[1] > 0:
+		size[0] = natural[0] * size[1] / natural[1]
+	case size[1] == 0 && natural[0] > 0:
+		size[1] = natural[1] * size[0] / natural[0]
+	}
+	return size
+}
+
+func imageBox(natural Vec2, id ImageId, attrs ImageAttrs, anim *ImageAnimation) {
+	var size = imageBoxSize(natural, attrs)
+	Layout(Attrs{MaxSize: size, MinSize: size, Clip: true, Corners: attrs.Corners, Border: attrs.Border}, func() {
+		if id > 0 {
+			BackgroundImage(id, attrs)
+			current.image.anim = anim
+		}
+	})
+}
+
+// FitImage is where an image of the given size goes in box
+func FitImage(size Vec2, box Rect, fit ImageFit, focus Vec2) Rect {
+	if size[0] <= 0 || size[1] <= 0 {
+		return box
+	}
+	var scale = Vec2{1, 1}
+	var sx, sy = box.Size[0] / size[0], box.Size[1] / size[1]
+	switch fit {
+	case FitScaleDown:
+		scale = Vec2{min(1, sx, sy), min(1, sx, sy)}
+	case FitContain:
+		scale = Vec2{min(sx, sy), min(sx, sy)}
+	case FitCover:
+		scale = Vec2{max(sx, sy), max(sx, sy)}
+	case FitFill:
+		scale = Vec2{sx, sy}
+	}
+	var drawn = Vec2{size[0] * scale[0], size[1] * scale[1]}
+	var space = Vec2Sub(box.Size, drawn)
+	return Rect{
+		Origin: Vec2Add(box.Origin, Vec2{space[0] * (0.5 + focus[0]), space[1] * (0.5 + focus[1])}),
+		Size:   drawn,
+	}
+}
+
+// more tiles than this are drawn bigger
+const maxImageTiles = 4096
+
+// where the first tile goes and how many cover rect; they're anchored at the
+// focus, then go back to cover the top left corner
+func tileGrid(rect Rect, tile Vec2, focus Vec2) (start Vec2, cols, rows int) {
+	start = FitImage(tile, rect, FitNone, focus).Origin
+	for axis := range 2 {
+		var back = f32(math.Ceil(float64((start[axis] - rect.Origin[axis]) / tile[axis])))
+		start[axis] -= back * tile[axis]
+	}
+	var end = Vec2Add(rect.Origin, rect.Size)
+	cols = int(math.Ceil(float64((end[0] - start[0]) / tile[0])))
+	rows = int(math.Ceil(float64((end[1] - start[1]) / tile[1])))
+	return start, cols, rows
+}
+
+func pushImageSurfaces(rect Rect, image *containerImage) {
+	var data = LookupImage(image.id)
+	if data == nil {
+		return
+	}
+	var src = image.crop
+	if src.Size == (Vec2{}) {
+		src = Rect{Size: Vec2{f32(data.Config.Width), f32(data.Config.Height)}}
+	}
+	if src.Size[0] <= 0 || src.Size[1] <= 0 {
+		return
+	}
+	push := func(dst Rect, src Rect) {
+		if dst.Size[0] <= 0 || dst.Size[1] <= 0 || src.Size[0] <= 0 || src.Size[1] <= 0 {
+			return
+		}
+		PushSurface(Surface{Rect: dst, ImageId: image.id, ImageScale: true, ImageSrc: src})
+	}
+
+	switch {
+	case image.slice != (Vec4{}):
+		pushNineSlice(rect, src, image.slice, push)
+
+	case image.tile:
+		var tile = image.tileSize
+		if tile[0] <= 0 {
+			tile[0] = src.Size[0]
+		}
+		if tile[1] <= 0 {
+			tile[1] = src.Size[1]
+		}
+		var start, cols, rows = tileGrid(rect, tile, image.focus)
+		for cols*rows > maxImageTiles {
+			// too small to draw them all: grow them, keeping their aspect
+			tile = Vec2Mul(tile, 1.05*f32(math.Sqrt(float64(cols*rows)/maxImageTiles)))
+			start, cols, rows = tileGrid(rect, tile, image.focus)
+		}
+		for row := range rows {
+			for col := range cols {
+				var origin = Vec2{start[0] + f32(col)*tile[0], start[1] + f32(row)*tile[1]}
+				push(Rect{Origin: origin, Size: tile}, src)
+			}
+		}
+
+	default:
+		push(FitImage(src.Size, rect, image.fit, image.focus), src)
+	}
+}
+
+// the corners keep their size, the edges stretch along, and the middle both
+// ways. When the box is too small for the edges, they shrink together.
+func pushNineSlice(rect Rect, src Rect, slice Vec4, push func(dst Rect, src Rect)) {
+	var top, right, bottom, left = slice[0], slice[1], slice[2], slice[3]
+	var dstEdges = Vec4{top, right, bottom, left}
+	if s := rect.Size[0] / (left + right); left+right > rect.Size[0] {
+		dstEdges[1], dstEdges[3] = right*s, left*s
+	}
+	if s := rect.Size[1] / (top + bottom); top+bottom > rect.Size[1] {
+		dstEdges[0], dstEdges[2] = top*s, bottom*s
+	}
+
+	cuts := func(origin, size, a, b f32) [4]f32 {
+		return [4]f32{origin, origin + a, origin + size - b, origin + size}
+	}
+	var srcX = cuts(src.Origin[0], src.Size[0], left, right)
+	var srcY = cuts(src.Origin[1], src.Size[1], top, bottom)
+	var dstX = cuts(rect.Origin[0], rect.Size[0], dstEdges[3], dstEdges[1])
+	var dstY = cuts(rect.Origin[1], rect.Size[1], dstEdges[0], dstEdges[2])
+
+	for row := range 3 {
+		for col := range 3 {
+			push(
+				Rect{Origin: Vec2{dstX[col], dstY[row]}, Size: Vec2{dstX[col+1] - dstX[col], dstY[row+1] - dstY[row]}},
+				Rect{Origin: Vec2{srcX[col], srcY[row]}, Size: Vec2{srcX[col+1] - srcX[col], srcY[row+1] - srcY[row]}},
+			)
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"log"
 	"os"
+	"reflect"
 
 	_ "golang.org/x/image/webp"
 )
@@ -30,18 +32,206 @@ func imageToRGBA(src image.Image) *image.RGBA {
 	return dst
 }
 
-// FIXME we need to manage images in a way that allows them to be added and removed dynamically without interfering with caching!
-// in other words, we need to use a handles system!
+// -----------------------------------------------------------------------------
+//      Image handles
+// -----------------------------------------------------------------------------
+// An ImageId is a handle to pixels that never change: new pixels get a new id,
+// so surfaces that look the same draw the same, and backends can cache by id.
+//
+// Handles are either retained, by RegisterImage, and freed after they're
+// released, or belong to the frame, by FrameImage, and freed when they go
+// unused. Either way an id is only reused once no surface of the last frame
+// refers to it.
+//
+// Like everything else, these must be called from the frame, or from
+// WithFrameLock.
+
 type ImageId uint32
 
-var imageIds = make([]*ImageData, 1, 1024) // first image is the zero image!
+type imageSlot struct {
+	data     *ImageData
+	refs     int32
+	lastUsed int64 // the last frame it was drawn or asked for
+}
+
+var imageIds = make([]imageSlot, 1, 1024) // first image is the zero image!
+var freeImageIds []ImageId
+var unownedImageIds []ImageId // candidates for freeing; checked every frame
+var freedImageIds []ImageId   // since the backend last asked
 var imageIdByPath = make(map[string]ImageId)
+var frameImageIds = make(map[frameImageKey]ImageId)
+
+var rejectedImageTypes = make(map[reflect.Type]bool) // logged once
+
+// images that can't be map keys (values holding slices) go by their pixels
+type frameImageKey struct {
+	img    image.Image
+	pix    *uint8
+	bounds image.Rectangle
+}
+
+func frameImageKeyOf(img image.Image) (frameImageKey, bool) {
+	if img == nil {
+		return frameImageKey{}, false
+	}
+	var v = reflect.ValueOf(img)
+	if v.Type().Comparable() {
+		return frameImageKey{img: img}, true
+	}
+	if v.Kind() == reflect.Struct {
+		if pix := v.FieldByName("Pix"); pix.Kind() == reflect.Slice && pix.Len() > 0 {
+			return frameImageKey{pix: (*uint8)(pix.Index(0).Addr().UnsafePointer()), bounds: img.Bounds()}, true
+		}
+	}
+	return frameImageKey{}, false
+}
 
 type ImageData struct {
 	image.Config
 	image.RGBA
 }
 
+func newImageData(src image.Image) *ImageData {
+	var rgba = imageToRGBA(src)
+	var data = new(ImageData)
+	if rgba != nil {
+		data.RGBA = *rgba
+		data.Config = image.Config{ColorModel: rgba.ColorModel(), Width: rgba.Rect.Dx(), Height: rgba.Rect.Dy()}
+	}
+	return data
+}
+
+func allocImage(data *ImageData, refs int32) ImageId {
+	var slot = imageSlot{data: data, refs: refs, lastUsed: FrameNumber}
+	var id ImageId
+	if n := len(freeImageIds); n > 0 {
+		id = freeImageIds[n-1]
+		freeImageIds = freeImageIds[:n-1]
+		imageIds[id] = slot
+	} else {
+		id = ImageId(len(imageIds))
+		imageIds = append(imageIds, slot)
+	}
+	if refs == 0 {
+		unownedImageIds = append(unownedImageIds, id)
+	}
+	return id
+}
+
+// RegisterImage keeps a copy of img's pixels (or img itself, when it's an
+// *image.RGBA, which then must not be changed) until ReleaseImage
+func RegisterImage(img image.Image) ImageId {
+	return allocImage(newImageData(img), 1)
+}
+
+// RegisterImageBytes decodes an encoded image (png, jpeg, gif, webp)
+func RegisterImageBytes(content []byte) (ImageId, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return 0, err
+	}
+	return RegisterImage(decoded), nil
+}
+
+func RetainImage(id ImageId) {
+	if id > 0 {
+		imageIds[id].refs++
+	}
+}
+
+// ReleaseImage drops a reference; without any, the image is freed once it
+// stops being drawn
+func ReleaseImage(id ImageId) {
+	if id == 0 || imageIds[id].refs <= 0 {
+		return
+	}
+	imageIds[id].refs--
+	if imageIds[id].refs == 0 {
+		unownedImageIds = append(unownedImageIds, id)
+	}
+}
+
+// ReplaceImage is for when the pixels change: it returns a new handle with
+// the references of the old one, which is released
+func ReplaceImage(id ImageId, img image.Image) ImageId {
+	if id == 0 {
+		return RegisterImage(img)
+	}
+	var refs = max(1, imageIds[id].refs)
+	var newId = allocImage(newImageData(img), refs)
+	imageIds[id].refs = 1
+	ReleaseImage(id)
+	return newId
+}
+
+// FrameImage is for generated images, like thumbnails and plots: call it
+// every frame the image is shown, and it's freed after it's not. The same img
+// gets the same id; after changing its pixels, call ImageChanged.
+//
+// img should be a pointer, like all the image types of the standard library;
+// other images need Pix, or they get no id.
+func FrameImage(img image.Image) ImageId {
+	key, valid := frameImageKeyOf(img)
+	if !valid {
+		if t := reflect.TypeOf(img); !rejectedImageTypes[t] {
+			rejectedImageTypes[t] = true
+			log.Printf("FrameImage: %T can't be told apart from other images; pass a pointer", img)
+		}
+		return 0
+	}
+	id, ok := frameImageIds[key]
+	if !ok || imageIds[id].data == nil {
+		id = allocImage(newImageData(img), 0)
+		frameImageIds[key] = id
+	}
+	imageIds[id].lastUsed = FrameNumber
+	return id
+}
+
+// ImageChanged gives img a new id the next time it's passed to FrameImage
+func ImageChanged(img image.Image) {
+	if key, valid := frameImageKeyOf(img); valid {
+		delete(frameImageIds, key)
+	}
+}
+
+// frees unowned images that no surface of this frame or the last one uses
+func sweepImages() {
+	var kept = unownedImageIds[:0]
+	for _, id := range unownedImageIds {
+		var slot = &imageIds[id]
+		if slot.data == nil || slot.refs > 0 {
+			continue // already freed, or retained again
+		}
+		if slot.lastUsed >= FrameNumber-1 {
+			kept = append(kept, id)
+			continue
+		}
+		*slot = imageSlot{}
+		freeImageIds = append(freeImageIds, id)
+		freedImageIds = append(freedImageIds, id)
+	}
+	unownedImageIds = kept
+	for img, id := range frameImageIds {
+		if imageIds[id].data == nil {
+			delete(frameImageIds, img)
+		}
+	}
+}
+
+// DrainFreedImages calls fn with the ids freed since it was last called, for
+// backends to drop what they keep per image
+func DrainFreedImages(fn func(id ImageId)) {
+	for _, id := range freedImageIds {
+		fn(id)
+	}
+	freedImageIds = freedImageIds[:0]
+}
+
+func markImageUsed(id ImageId) {
+	imageIds[id].lastUsed = FrameNumber
+}
+
 func LoadImageConfig(fpath string) image.Config {
 	const key = "image-config"
 	cfg, found := _getFileCacheContent[image.Config](fpath, key)
@@ -55,6 +245,9 @@ func LoadImageConfig(fpath string) image.Config {
 	return cfg
 }
 
+// files this big are decoded in the background
+const backgroundDecodeSize = 500 * 1024
+
 func LoadImage(fpath string) *ImageData {
 	const key = "image"
 	img, found := _getFileCacheContent[*ImageData](fpath, key)
@@ -65,17 +258,25 @@ func LoadImage(fpath string) *ImageData {
 	img = new(ImageData)
 	content := ReadFileContent(fpath)
 
+	// the id goes to the pixels once they're there; a changed file gets a new one
+	setPathImage := func() {
+		if old := imageIdByPath[fpath]; old > 0 {
+			ReleaseImage(old)
+		}
+		imageIdByPath[fpath] = allocImage(img, 1)
+	}
+
 	// read just the header
 	img.Config, _, _ = image.DecodeConfig(bytes.NewReader(content))
 
-	const threshold = 500 * 1024
-	if len(content) < threshold {
+	if len(content) < backgroundDecodeSize {
 		// small enough size; load immediately
 		decoded, _, _ := image.Decode(bytes.NewReader(content))
 		rgba := imageToRGBA(decoded)
 		if rgba != nil {
 			img.RGBA = *rgba
 		}
+		setPathImage()
 	} else {
 		// defer loading to background
 		go func() {
@@ -87,6 +288,7 @@ func LoadImage(fpath string) *ImageData {
 				// log.Println("Image actual size:", rgba.Bounds().Dx(), rgba.Bounds().Dy())
 				WithFrameLock(func() {
 					img.RGBA = *rgba
+					setPathImage()
 					RequestNextFrame()
 				})
 			}
@@ -95,46 +297,49 @@ func LoadImage(fpath string) *ImageData {
 
 	_setFileCacheContent(fpath, key, img)
 
-	// write the image to the image ids list
-	// note: the way this is currently setup, when the image on disk changes,
-	// its imageid will point to the new version!
-	// this means it's not straight forward to load an image and just keep it there!
-	// (unless you do it via some mechanis, other than this LoadImage function)
-	imageId := imageIdByPath[fpath]
-	if imageId == 0 {
-		// FIXME: we should only set this after the image is actually loaded!
-		// the way we currently do it violate the unwritten assumption that an
-		// unchanged surface data indicates unchanged pixel output!
-		imageIdByPath[fpath] = ImageId(len(imageIds))
-		imageIds = append(imageIds, img)
-	} else {
-		imageIds[imageId] = img
-	}
-
 	return img
 }
 
 // this function is mostly for the backend
 func LookupImage(id ImageId) *ImageData {
-	return imageIds[int(id)]
+	return imageIds[int(id)].data
 }
 
+// zero until the pixels are loaded
 func GetImageId(fpath string) ImageId {
 	return imageIdByPath[fpath]
 }
 
-// FIXME: we shuold be able to also specify minSize and border radius, perhaps border color too!
 func Image(fpath string, maxSize Vec2) {
+	var attrs = DefaultImageAttrs()
+	attrs.MaxSize = maxSize
+	ImageExt(fpath, attrs)
+}
+
+// ImageExt draws an image file, sized, fitted and clipped by attrs
+func ImageExt(fpath string, attrs ImageAttrs) {
 	img := LoadImage(fpath)
 	if img == nil {
 		// FIXME: use a default non-sensical white image or something
 		return
 	}
-	size := Vec2{f32(img.Config.Width), f32(img.Config.Height)}
-	size = RestrictedSize(size, maxSize)
-	Layout(Attrs{MaxSize: size, MinSize: size, Clip: true}, func() {
-		current.imageId = GetImageId(fpath)
-	})
+	// the size is known before the pixels are
+	imageBox(Vec2{f32(img.Config.Width), f32(img.Config.Height)}, GetImageId(fpath), attrs, nil)
+}
+
+// ImageById draws a registered image, like Image does a file
+func ImageById(id ImageId, maxSize Vec2) {
+	var attrs = DefaultImageAttrs()
+	attrs.MaxSize = maxSize
+	ImageByIdExt(id, attrs)
+}
+
+func ImageByIdExt(id ImageId, attrs ImageAttrs) {
+	img := LookupImage(id)
+	if img == nil {
+		return
+	}
+	imageBox(Vec2{f32(img.Config.Width), f32(img.Config.Height)}, id, attrs, nil)
 }
 
 func RestrictedSize(size Vec2, maxSize Vec2) Vec2 {
@@ -101,3 +101,29 @@ func ActiveCombo() KeyCombo {
 		Mod: InputState.Modifiers,
 	}
 }
+
+var keyNames = map[KeyCode]string{
+	KeyLeft: "Left", KeyRight: "Right", KeyUp: "Up", KeyDown: "Down",
+	KeyEnter: "Enter", KeyEscape: "Esc", KeyHome: "Home", KeyEnd: "End",
+	KeyDeleteBackward: "Backspace", KeyDeleteForward: "Delete",
+	KeyPageUp: "PageUp", KeyPageDown: "PageDown", KeyTab: "Tab", KeySpace: "Space",
+	KeyF1: "F1", KeyF2: "F2", KeyF3: "F3", KeyF4: "F4", KeyF5: "F5", KeyF6: "F6",
+	KeyF7: "F7", KeyF8: "F8", KeyF9: "F9", KeyF10: "F10", KeyF11: "F11", KeyF12: "F12",
+}
+
+// the combo as shown in menus, e.g. "Ctrl+Shift+S"
+func (c KeyCombo) String() string {
+	var text string
+	for _, mod := range []struct {
+		mod  Modifiers
+		name string
+	}{{ModCtrl, "Ctrl+"}, {ModCmd, "Cmd+"}, {ModAlt, "Alt+"}, {ModShift, "Shift+"}, {ModSuper, "Super+"}} {
+		if c.Mod&mod.mod != 0 {
+			text += mod.name
+		}
+	}
+	if name, ok := keyNames[c.Key]; ok {
+		return text + name
+	}
+	return text + string(rune(c.Key))
+}
@@ -0,0 +1,787 @@
+package shirei
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	g "go.hasen.dev/generic"
+)
+
+// -----------------------------------------------------------------------------
+//      Vector paths
+// -----------------------------------------------------------------------------
+// Paths are recorded during the frame into flat buffers and referenced from
+// surfaces by id, so the Surface struct stays flat. Strokes (with their caps,
+// joins and dashes) are expanded into outlines here; backends only ever fill
+// paths with the non-zero winding rule, so even-odd fills are rewound here to
+// match.
+
+type PathId uint32
+
+type PathVerb uint8
+
+const (
+	PathMoveTo PathVerb = iota
+	PathLineTo
+	PathQuadTo
+	PathCubeTo
+	PathClose
+)
+
+// PathCmd is one path command; the control points come first and the end
+// point last: LineTo uses Points[0], QuadTo Points[:2] and CubeTo Points[:3]
+type PathCmd struct {
+	Verb   PathVerb
+	Points [3]Vec2
+}
+
+func (c PathCmd) end() Vec2 {
+	switch c.Verb {
+	case PathQuadTo:
+		return c.Points[1]
+	case PathCubeTo:
+		return c.Points[2]
+	}
+	return c.Points[0]
+}
+
+type LineCap uint8
+
+const (
+	CapButt LineCap = iota
+	CapRound
+	CapSquare
+)
+
+type LineJoin uint8
+
+const (
+	JoinMiter LineJoin = iota
+	JoinRound
+	JoinBevel
+)
+
+type FillRule uint8
+
+const (
+	FillNonZero FillRule = iota
+	// a point is inside when a ray from it crosses the outline an odd number
+	// of times, e.g. subpaths inside others make holes
+	FillEvenOdd
+)
+
+type StrokeStyle struct {
+	Width f32
+	Cap   LineCap
+	Join  LineJoin
+
+	MiterLimit f32 // miters longer than MiterLimit*Width become bevels; 0 means 4
+
+	// alternating lengths of dashes and gaps; a zero length dash with round or
+	// square caps makes a dot. An odd count is repeated to make it even.
+	Dashes     []f32
+	DashOffset f32
+}
+
+type pathShape struct {
+	start, end int32 // range in pathCmds
+	color      Vec4
+
+	// with a gradient, the commands are relative to the origin of its box
+	gradient GradientId
+	box      Rect
+}
+
+// the surface of a shape painted on rect
+func (s *pathShape) surface(id PathId, rect Rect) Surface {
+	if s.gradient > 0 {
+		rect = Rect{Origin: Vec2Add(rect.Origin, s.box.Origin), Size: s.box.Size}
+	}
+	return Surface{Rect: rect, Color1: s.color, Color2: s.color, PathId: id, GradientId: s.gradient}
+}
+
+// reset every frame; the zero id is no path
+var pathCmds = make([]PathCmd, 0, 1024)
+var pathShapes = make([]pathShape, 1, 256)
+
+// this function is mostly for the backend; coordinates are relative to the
+// surface origin
+func LookupPath(id PathId) []PathCmd {
+	var shape = pathShapes[id]
+	return pathCmds[shape.start:shape.end]
+}
+
+func resetPaths() {
+	g.ResetSlice(&pathCmds)
+	pathShapes = pathShapes[:1]
+}
+
+// Path builds vector shapes for Draw and Canvas. Fill and Stroke paint what was
+// built so far and keep it, so a shape can be filled then stroked; Begin
+// starts over.
+type Path struct {
+	container *Container
+	cmds      []PathCmd
+
+	// without a container, painting pushes surfaces at this rect right away;
+	// for drawing while rendering to surfaces
+	rect Rect
+
+	pen      Vec2
+	start    Vec2 // of the current subpath
+	hasPoint bool
+
+	FillRule FillRule // for Fill and FillGradient
+}
+
+var pathScratch []PathCmd
+
+// Draw paints vector paths on the current container, over its background and
+// under its children; coordinates are relative to its top left corner
+func Draw(fn func(p *Path)) {
+	var p = Path{container: current, cmds: pathScratch[:0]}
+	pathScratch = nil // in case fn draws again
+	fn(&p)
+	pathScratch = p.cmds[:0]
+}
+
+// Canvas is an element of the given size to draw on; see Draw
+func Canvas(size Vec2, fn func(p *Path)) {
+	Layout(Attrs{MinSize: size, MaxSize: size}, func() {
+		Draw(fn)
+	})
+}
+
+func (p *Path) Begin() {
+	p.cmds = p.cmds[:0]
+	p.hasPoint = false
+}
+
+func (p *Path) MoveTo(to Vec2) {
+	p.cmds = append(p.cmds, PathCmd{Verb: PathMoveTo, Points: [3]Vec2{to}})
+	p.pen, p.start, p.hasPoint = to, to, true
+}
+
+// starts a subpath at the point when there's none, like a MoveTo
+func (p *Path) ensurePoint(at Vec2) bool {
+	if !p.hasPoint {
+		p.MoveTo(at)
+		return false
+	}
+	return true
+}
+
+func (p *Path) LineTo(to Vec2) {
+	if p.ensurePoint(to) {
+		p.cmds = append(p.cmds, PathCmd{Verb: PathLineTo, Points: [3]Vec2{to}})
+		p.pen = to
+	}
+}
+
+func (p *Path) QuadTo(ctrl, to Vec2) {
+	p.ensurePoint(ctrl)
+	p.cmds = append(p.cmds, PathCmd{Verb: PathQuadTo, Points: [3]Vec2{ctrl, to}})
+	p.pen = to
+}
+
+func (p *Path) CubeTo(ctrl1, ctrl2, to Vec2) {
+	p.ensurePoint(ctrl1)
+	p.cmds = append(p.cmds, PathCmd{Verb: PathCubeTo, Points: [3]Vec2{ctrl1, ctrl2, to}})
+	p.pen = to
+}
+
+// Arc adds a circular arc, with a line to its start from the current point.
+// Angles are in radians; since y points down, positive sweeps go clockwise.
+func (p *Path) Arc(center Vec2, radius f32, start f32, sweep f32) {
+	at := func(angle float64) Vec2 {
+		return Vec2{center[0] + radius*f32(math.Cos(angle)), center[1] + radius*f32(math.Sin(angle))}
+	}
+	p.LineTo(at(float64(start)))
+
+	// cubics of a quarter circle at most
+	var count = max(1, int(math.Ceil(math.Abs(float64(sweep))/(math.Pi/2)-1e-4)))
+	var step = float64(sweep) / float64(count)
+	var k = f32(4.0 / 3.0 * math.Tan(step/4))
+	var a0 = float64(start)
+	for range count {
+		var a1 = a0 + step
+		var p0, p3 = at(a0), at(a1)
+		var t0 = Vec2{-f32(math.Sin(a0)), f32(math.Cos(a0))}
+		var t1 = Vec2{-f32(math.Sin(a1)), f32(math.Cos(a1))}
+		p.CubeTo(Vec2Add(p0, Vec2Mul(t0, k*radius)), Vec2Sub(p3, Vec2Mul(t1, k*radius)), p3)
+		a0 = a1
+	}
+}
+
+func (p *Path) Close() {
+	if p.hasPoint {
+		p.cmds = append(p.cmds, PathCmd{Verb: PathClose})
+		p.pen = p.start
+	}
+}
+
+func (p *Path) Rect(r Rect) {
+	var end = Vec2Add(r.Origin, r.Size)
+	p.MoveTo(r.Origin)
+	p.LineTo(Vec2{end[0], r.Origin[1]})
+	p.LineTo(end)
+	p.LineTo(Vec2{r.Origin[0], end[1]})
+	p.Close()
+}
+
+func (p *Path) Circle(center Vec2, radius f32) {
+	p.MoveTo(Vec2{center[0] + radius, center[1]})
+	p.Arc(center, radius, 0, 2*math.Pi)
+	p.Close()
+}
+
+// Fill paints the inside of the path, by p.FillRule
+func (p *Path) Fill(color Vec4) {
+	var start = len(pathCmds)
+	p.fillOutline()
+	p.paint(pathShape{start: int32(start), color: color})
+}
+
+// FillGradient is Fill with a gradient laid over box
+func (p *Path) FillGradient(gradient Gradient, box Rect) {
+	var start = len(pathCmds)
+	p.fillOutline()
+	p.paint(pathShape{start: int32(start), gradient: pushGradient(gradient), box: box})
+}
+
+func (p *Path) Stroke(color Vec4, style StrokeStyle) {
+	var start = len(pathCmds)
+	p.strokeOutline(style)
+	p.paint(pathShape{start: int32(start), color: color})
+}
+
+func (p *Path) StrokeGradient(gradient Gradient, box Rect, style StrokeStyle) {
+	var start = len(pathCmds)
+	p.strokeOutline(style)
+	p.paint(pathShape{start: int32(start), gradient: pushGradient(gradient), box: box})
+}
+
+func (p *Path) fillOutline() {
+	if p.FillRule == FillEvenOdd {
+		evenOddOutline(p.cmds)
+	} else {
+		pathCmds = append(pathCmds, p.cmds...)
+	}
+}
+
+func (p *Path) strokeOutline(style StrokeStyle) {
+	if style.Width <= 0 {
+		return
+	}
+	flattenPath(p.cmds)
+	if len(style.Dashes) > 0 {
+		dashPolylines(style.Dashes, style.DashOffset)
+	}
+	for _, line := range polylines {
+		strokePolyline(polyPoints[line.start:line.end], line.closed, style)
+	}
+}
+
+func (p *Path) paint(shape pathShape) {
+	shape.end = int32(len(pathCmds))
+	if shape.end == shape.start {
+		return
+	}
+	if shape.gradient > 0 {
+		var cmds = pathCmds[shape.start:shape.end]
+		for i := range cmds {
+			for j := range cmds[i].Points {
+				cmds[i].Points[j] = Vec2Sub(cmds[i].Points[j], shape.box.Origin)
+			}
+		}
+	}
+	g.Append(&pathShapes, shape)
+	var id = PathId(len(pathShapes) - 1)
+	if p.container != nil {
+		p.container.paths = append(p.container.paths, id)
+	} else {
+		PushSurface(shape.surface(id, p.rect))
+	}
+}
+
+// -----------------------------------------------------------------------------
+//      Stroking
+// -----------------------------------------------------------------------------
+
+// curves are flattened to within this distance, in pixels
+const pathTolerance = 0.25
+
+type polyline struct {
+	start, end int // range in polyPoints
+	closed     bool
+}
+
+// scratch buffers for stroking
+var polyPoints []Vec2
+var polylines []polyline
+
+func vecLength(v Vec2) f32 {
+	return f32(math.Hypot(float64(v[0]), float64(v[1])))
+}
+
+func vecNormalize(v Vec2) Vec2 {
+	var l = vecLength(v)
+	if l == 0 {
+		return Vec2{}
+	}
+	return Vec2{v[0] / l, v[1] / l}
+}
+
+func vecLerp(a, b Vec2, t f32) Vec2 {
+	return Vec2{a[0] + (b[0]-a[0])*t, a[1] + (b[1]-a[1])*t}
+}
+
+func flattenPath(cmds []PathCmd) {
+	g.ResetSlice(&polyPoints)
+	g.ResetSlice(&polylines)
+
+	// a subpath only takes points once something is drawn from its anchor, so
+	// a lone MoveTo draws nothing
+	var started bool
+	var anchor Vec2
+	var line polyline
+	finish := func(closed bool) {
+		if started {
+			var pts = polyPoints[line.start:]
+			if closed && len(pts) > 1 && pts[len(pts)-1] == pts[0] {
+				polyPoints = polyPoints[:len(polyPoints)-1]
+			}
+			line.end = len(polyPoints)
+			line.closed = closed
+			g.Append(&polylines, line)
+		}
+		started = false
+	}
+	add := func(v Vec2) {
+		if !started {
+			started = true
+			line = polyline{start: len(polyPoints)}
+			g.Append(&polyPoints, anchor)
+		}
+		if polyPoints[len(polyPoints)-1] != v {
+			g.Append(&polyPoints, v)
+		}
+	}
+
+	var pen Vec2
+	for _, c := range cmds {
+		switch c.Verb {
+		case PathMoveTo:
+			finish(false)
+			anchor = c.Points[0]
+		case PathLineTo:
+			add(c.Points[0])
+		case PathQuadTo:
+			var p1, p2 = c.Points[0], c.Points[1]
+			var dd = vecLength(Vec2Add(Vec2Sub(pen, Vec2Mul(p1, 2)), p2))
+			var n = max(1, min(100, int(math.Ceil(math.Sqrt(float64(dd/(4*pathTolerance)))))))
+			for i := 1; i <= n; i++ {
+				var t = f32(i) / f32(n)
+				add(vecLerp(vecLerp(pen, p1, t), vecLerp(p1, p2, t), t))
+			}
+		case PathCubeTo:
+			var p1, p2, p3 = c.Points[0], c.Points[1], c.Points[2]
+			var dd = max(
+				vecLength(Vec2Add(Vec2Sub(pen, Vec2Mul(p1, 2)), p2)),
+				vecLength(Vec2Add(Vec2Sub(p1, Vec2Mul(p2, 2)), p3)),
+			)
+			var n = max(1, min(100, int(math.Ceil(math.Sqrt(float64(3*dd/(4*pathTolerance)))))))
+			for i := 1; i <= n; i++ {
+				var t = f32(i) / f32(n)
+				var a, b, c = vecLerp(pen, p1, t), vecLerp(p1, p2, t), vecLerp(p2, p3, t)
+				add(vecLerp(vecLerp(a, b, t), vecLerp(b, c, t), t))
+			}
+		case PathClose:
+			// the closing segment is implied; drawing on continues from the
+			// start of the subpath
+			finish(true)
+			pen = anchor
+			continue
+		}
+		pen = c.end()
+	}
+	finish(false)
+}
+
+// appends the subpaths flattened, so that filling them by non-zero fills them
+// by even-odd. Each is wound by how deep it's nested, so a hole turns against
+// what it's in and cancels it. That doesn't work once edges cross, so then the
+// shape is cut into bands where no edges cross, each filled between every
+// other edge.
+func evenOddOutline(cmds []PathCmd) {
+	flattenPath(cmds)
+	if collectFillEdges() {
+		bandsOutline()
+		return
+	}
+	for i, line := range polylines {
+		var pts = polyPoints[line.start:line.end]
+		if len(pts) < 3 {
+			continue
+		}
+		var depth int
+		for j, other := range polylines {
+			if j != i && polygonContains(polyPoints[other.start:other.end], pts[0]) {
+				depth++
+			}
+		}
+		var reverse = (polygonArea(pts) < 0) != (depth%2 == 1)
+		for k := range pts {
+			var pt = pts[k]
+			if reverse {
+				pt = pts[len(pts)-1-k]
+			}
+			var verb = PathLineTo
+			if k == 0 {
+				verb = PathMoveTo
+			}
+			g.Append(&pathCmds, PathCmd{Verb: verb, Points: [3]Vec2{pt}})
+		}
+		g.Append(&pathCmds, PathCmd{Verb: PathClose})
+	}
+}
+
+// an edge of a flattened fill, going down; flat ones are in no band
+type fillEdge struct{ a, b Vec2 }
+
+func (e fillEdge) xAt(y f32) f32 {
+	return e.a[0] + (y-e.a[1])*(e.b[0]-e.a[0])/(e.b[1]-e.a[1])
+}
+
+// scratch buffers for even-odd fills
+var fillEdges []fillEdge
+var bandYs []f32
+
+// collects the edges of polylines (closing each) into fillEdges, and the y of
+// every vertex and crossing into bandYs. Reports whether any edges cross.
+func collectFillEdges() bool {
+	g.ResetSlice(&fillEdges)
+	g.ResetSlice(&bandYs)
+	for _, line := range polylines {
+		var pts = polyPoints[line.start:line.end]
+		if len(pts) < 3 {
+			continue
+		}
+		for i, a := range pts {
+			var b = pts[(i+1)%len(pts)]
+			if a[1] > b[1] {
+				a, b = b, a
+			}
+			g.Append(&fillEdges, fillEdge{a, b})
+			g.Append(&bandYs, a[1])
+		}
+	}
+	slices.SortFunc(fillEdges, func(e, f fillEdge) int { return cmp.Compare(e.a[1], f.a[1]) })
+
+	const eps = 1e-6
+	var crossed bool
+	for i, e := range fillEdges {
+		var d1 = Vec2Sub(e.b, e.a)
+		for _, f := range fillEdges[i+1:] {
+			if f.a[1] >= e.b[1] {
+				break // sorted by top: no later edge reaches e
+			}
+			if max(e.a[0], e.b[0]) < min(f.a[0], f.b[0]) || max(f.a[0], f.b[0]) < min(e.a[0], e.b[0]) {
+				continue
+			}
+			var d2 = Vec2Sub(f.b, f.a)
+			var den = d1[0]*d2[1] - d1[1]*d2[0]
+			if den == 0 {
+				continue
+			}
+			var d = Vec2Sub(f.a, e.a)
+			var t = (d[0]*d2[1] - d[1]*d2[0]) / den
+			var u = (d[0]*d1[1] - d[1]*d1[0]) / den
+			if t > eps && t < 1-eps && u > eps && u < 1-eps {
+				crossed = true
+				g.Append(&bandYs, e.a[1]+t*d1[1])
+			}
+		}
+	}
+	if crossed {
+		for _, e := range fillEdges {
+			g.Append(&bandYs, e.b[1])
+		}
+		slices.Sort(bandYs)
+		bandYs = slices.Compact(bandYs)
+	}
+	return crossed
+}
+
+// appends a quad for each span of each band between bandYs that's inside an
+// odd number of fillEdges; they're all wound the same way
+func bandsOutline() {
+	type span struct{ top, mid, bottom f32 }
+	var spans []span
+	for i := 0; i+1 < len(bandYs); i++ {
+		var y0, y1 = bandYs[i], bandYs[i+1]
+		var mid = (y0 + y1) / 2
+		spans = spans[:0]
+		for _, e := range fillEdges {
+			if e.a[1] <= y0 && e.b[1] >= y1 {
+				spans = append(spans, span{e.xAt(y0), e.xAt(mid), e.xAt(y1)})
+			}
+		}
+		slices.SortFunc(spans, func(a, b span) int { return cmp.Compare(a.mid, b.mid) })
+		for k := 0; k+1 < len(spans); k += 2 {
+			var l, r = spans[k], spans[k+1]
+			g.Append(&pathCmds, PathCmd{Verb: PathMoveTo, Points: [3]Vec2{{l.top, y0}}})
+			g.Append(&pathCmds, PathCmd{Verb: PathLineTo, Points: [3]Vec2{{r.top, y0}}})
+			g.Append(&pathCmds, PathCmd{Verb: PathLineTo, Points: [3]Vec2{{r.bottom, y1}}})
+			g.Append(&pathCmds, PathCmd{Verb: PathLineTo, Points: [3]Vec2{{l.bottom, y1}}})
+			g.Append(&pathCmds, PathCmd{Verb: PathClose})
+		}
+	}
+}
+
+// twice the signed area; positive is clockwise, since y points down
+func polygonArea(pts []Vec2) f32 {
+	var area f32
+	for i, a := range pts {
+		var b = pts[(i+1)%len(pts)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	return area
+}
+
+// by counting crossings of a ray to the right
+func polygonContains(pts []Vec2, pt Vec2) bool {
+	var inside bool
+	for i, a := range pts {
+		var b = pts[(i+1)%len(pts)]
+		if (a[1] > pt[1]) != (b[1] > pt[1]) && pt[0] < a[0]+(pt[1]-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// replaces polylines with the dashes along them
+func dashPolylines(dashes []f32, offset f32) {
+	var pattern = dashes
+	if len(pattern)%2 == 1 {
+		pattern = append(append([]f32(nil), dashes...), dashes...)
+	}
+	var total f32
+	for _, d := range pattern {
+		if d < 0 {
+			return
+		}
+		total += d
+	}
+	if total <= 0 {
+		return
+	}
+
+	var source = append([]polyline(nil), polylines...)
+	var points = append([]Vec2(nil), polyPoints...)
+	g.ResetSlice(&polyPoints)
+	g.ResetSlice(&polylines)
+
+	for _, line := range source {
+		var pts = points[line.start:line.end]
+		if line.closed && len(pts) > 1 {
+			pts = append(pts[:len(pts):len(pts)], pts[0])
+		}
+
+		// where in the pattern the subpath starts
+		var index = 0
+		var left = f32(math.Mod(float64(offset), float64(total)))
+		if left < 0 {
+			left += total
+		}
+		for left > 0 && left >= pattern[index] {
+			left -= pattern[index]
+			index = (index + 1) % len(pattern)
+		}
+		left = pattern[index] - left
+
+		var dashStart = -1
+		var dir Vec2
+		begin := func(at Vec2) {
+			dashStart = len(polyPoints)
+			g.Append(&polyPoints, at)
+		}
+		end := func(at Vec2) {
+			if polyPoints[len(polyPoints)-1] != at {
+				g.Append(&polyPoints, at)
+			}
+			if len(polyPoints)-dashStart == 1 {
+				// a dot still needs a direction for its caps
+				g.Append(&polyPoints, Vec2Add(at, Vec2Mul(dir, 1e-3)))
+			}
+			g.Append(&polylines, polyline{start: dashStart, end: len(polyPoints)})
+			dashStart = -1
+		}
+
+		if len(pts) == 1 {
+			// a lone point
+			if index%2 == 0 {
+				g.Append(&polylines, polyline{start: len(polyPoints), end: len(polyPoints) + 1})
+				g.Append(&polyPoints, pts[0])
+			}
+			continue
+		}
+		if index%2 == 0 {
+			begin(pts[0])
+		}
+		for i := 1; i < len(pts); i++ {
+			var a, b = pts[i-1], pts[i]
+			var length = vecLength(Vec2Sub(b, a))
+			dir = vecNormalize(Vec2Sub(b, a))
+			var at f32
+			for length-at >= left {
+				at += left
+				var p = vecLerp(a, b, at/length)
+				if index%2 == 0 {
+					end(p)
+				} else {
+					begin(p)
+				}
+				index = (index + 1) % len(pattern)
+				left = pattern[index]
+			}
+			left -= length - at
+			if dashStart != -1 {
+				g.Append(&polyPoints, b)
+			}
+		}
+		if dashStart != -1 {
+			end(pts[len(pts)-1])
+		}
+	}
+}
+
+// appends a polygon to pathCmds, wound the same way as all the others so they
+// add up under the non-zero rule
+func addPolygon(points ...Vec2) {
+	var area f32
+	for i, a := range points {
+		var b = points[(i+1)%len(points)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	if area == 0 {
+		return
+	}
+	if area < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+	g.Append(&pathCmds, PathCmd{Verb: PathMoveTo, Points: [3]Vec2{points[0]}})
+	for _, p := range points[1:] {
+		g.Append(&pathCmds, PathCmd{Verb: PathLineTo, Points: [3]Vec2{p}})
+	}
+	g.Append(&pathCmds, PathCmd{Verb: PathClose})
+}
+
+var circlePoints []Vec2
+
+func addCircle(center Vec2, radius f32) {
+	// enough sides to stay within the tolerance
+	var sides = 6
+	if radius > pathTolerance {
+		sides = max(6, min(128, int(math.Ceil(math.Pi/math.Acos(float64(1-pathTolerance/radius))))))
+	}
+	g.ResetSlice(&circlePoints)
+	for i := range sides {
+		var a = 2 * math.Pi * float64(i) / float64(sides)
+		g.Append(&circlePoints, Vec2{center[0] + radius*f32(math.Cos(a)), center[1] + radius*f32(math.Sin(a))})
+	}
+	addPolygon(circlePoints...)
+}
+
+func strokePolyline(pts []Vec2, closed bool, style StrokeStyle) {
+	var hw = style.Width / 2
+	if len(pts) == 0 {
+		return
+	}
+	if len(pts) == 1 {
+		// zero length: only caps show
+		switch style.Cap {
+		case CapRound:
+			addCircle(pts[0], hw)
+		case CapSquare:
+			var p = pts[0]
+			addPolygon(Vec2{p[0] - hw, p[1] - hw}, Vec2{p[0] + hw, p[1] - hw}, Vec2{p[0] + hw, p[1] + hw}, Vec2{p[0] - hw, p[1] + hw})
+		}
+		return
+	}
+	if len(pts) == 2 {
+		closed = false
+	}
+
+	var miterLimit = style.MiterLimit
+	if miterLimit <= 0 {
+		miterLimit = 4
+	}
+
+	var count = len(pts) - 1
+	if closed {
+		count = len(pts)
+	}
+	segment := func(i int) (a, b, dir, normal Vec2) {
+		a, b = pts[i], pts[(i+1)%len(pts)]
+		dir = vecNormalize(Vec2Sub(b, a))
+		normal = Vec2{-dir[1] * hw, dir[0] * hw}
+		return
+	}
+
+	for i := range count {
+		var a, b, _, n = segment(i)
+		addPolygon(Vec2Add(a, n), Vec2Add(b, n), Vec2Sub(b, n), Vec2Sub(a, n))
+	}
+
+	// joins
+	for i := range count {
+		if !closed && i == count-1 {
+			break
+		}
+		var _, v, d0, n0 = segment(i)
+		var _, _, d1, n1 = segment((i + 1) % len(pts))
+		var cross = d0[0]*d1[1] - d0[1]*d1[0]
+		var dot = d0[0]*d1[0] + d0[1]*d1[1]
+		if Absf32(cross) < 1e-6 && dot > 0 {
+			continue // straight
+		}
+		if style.Join == JoinRound {
+			addCircle(v, hw)
+			continue
+		}
+		// the outer side of the turn
+		if cross > 0 {
+			n0, n1 = Vec2Mul(n0, -1), Vec2Mul(n1, -1)
+		}
+		var o0, o1 = Vec2Add(v, n0), Vec2Add(v, n1)
+		var bisector = vecNormalize(Vec2Add(n0, n1))
+		var cosHalf = (bisector[0]*n0[0] + bisector[1]*n0[1]) / hw
+		if style.Join == JoinMiter && cosHalf > 0 && 1/cosHalf <= miterLimit {
+			addPolygon(v, o0, Vec2Add(v, Vec2Mul(bisector, hw/cosHalf)), o1)
+		} else {
+			addPolygon(v, o0, o1)
+		}
+	}
+
+	if closed {
+		return
+	}
+	// caps
+	var first, _, d0, n0 = segment(0)
+	var _, last, d1, n1 = segment(len(pts) - 2)
+	switch style.Cap {
+	case CapRound:
+		addCircle(first, hw)
+		addCircle(last, hw)
+	case CapSquare:
+		var back = Vec2Mul(d0, -hw)
+		addPolygon(Vec2Add(first, n0), Vec2Add(Vec2Add(first, n0), back), Vec2Add(Vec2Sub(first, n0), back), Vec2Sub(first, n0))
+		var ahead = Vec2Mul(d1, hw)
+		addPolygon(Vec2Add(last, n1), Vec2Add(Vec2Add(last, n1), ahead), Vec2Add(Vec2Sub(last, n1), ahead), Vec2Sub(last, n1))
+	}
+}
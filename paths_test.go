@@ -0,0 +1,73 @@
+package shirei
+
+import (
+	"testing"
+
+	g "go.hasen.dev/generic"
+)
+
+// the non-zero winding number of closed polygons around pt
+func windingAt(cmds []PathCmd, pt Vec2) int {
+	var winding int
+	var pts []Vec2
+	var end = func() {
+		for i, a := range pts {
+			var b = pts[(i+1)%len(pts)]
+			if (a[1] > pt[1]) != (b[1] > pt[1]) && pt[0] < a[0]+(pt[1]-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+				if b[1] > a[1] {
+					winding++
+				} else {
+					winding--
+				}
+			}
+		}
+		pts = nil
+	}
+	for _, cmd := range cmds {
+		switch cmd.Verb {
+		case PathMoveTo:
+			end()
+			pts = append(pts, cmd.Points[0])
+		case PathLineTo:
+			pts = append(pts, cmd.Points[0])
+		case PathClose:
+			end()
+		}
+	}
+	end()
+	return winding
+}
+
+// filling the even-odd outline by non-zero must cover the points inside an odd
+// number of times and nothing else
+func TestEvenOddOutline(t *testing.T) {
+	var cases = []struct {
+		name string
+		d    string
+	}{
+		{"nested squares", "M0 0H10V10H0Z M3 3H7V7H3Z"},
+		{"nested squares, same winding", "M0 0H10V10H0Z M3 3V7H7V3Z"},
+		{"crossing squares", "M0 0H6V6H0Z M4 4H10V10H4Z"},
+		{"pentagram", "M5 0L8 10L0 4H10L2 10Z"},
+		{"bowtie", "M0 0L10 10V0L0 10Z"},
+		{"square crossing a ring", "M0 0H10V10H0Z M3 3H7V7H3Z M5 -2H12V5H5Z"},
+	}
+	for _, c := range cases {
+		svg, err := ParseSVG([]byte(`<svg viewBox="0 0 10 10"><path d="` + c.d + `"/></svg>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cmds = svg.shapes[0].cmds
+		g.ResetSlice(&pathCmds)
+		evenOddOutline(cmds)
+		for y := f32(-1.75); y < 12; y += 0.5 {
+			for x := f32(-1.75); x < 13; x += 0.5 {
+				var pt = Vec2{x, y}
+				var want = windingAt(cmds, pt)%2 != 0
+				if got := windingAt(pathCmds, pt) != 0; got != want {
+					t.Errorf("%s: filled at %v is %v, want %v", c.name, pt, got, want)
+				}
+			}
+		}
+	}
+}
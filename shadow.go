@@ -11,69 +11,171 @@ import (
 )
 
 type ShadowMapKey struct {
-	w  int
-	h  int
-	c0 uint8
-	c1 uint8
-	c2 uint8
-	c3 uint8
-	r  uint8
-	a  uint8
+	w      int
+	h      int
+	c0     uint8
+	c1     uint8
+	c2     uint8
+	c3     uint8
+	r      uint8
+	color  color.NRGBA
+	spread int16
+	inset  bool
+	dx     int16 // the offset only matters for inset shadows
+	dy     int16
 }
 
-var _shadowsMap = make(map[ShadowMapKey]ImageId)
+type shadowEntry struct {
+	imageId  ImageId
+	lastUsed int64
+}
+
+// the cache is swept of shadows not drawn in the last two frames when it gets
+// this big, and their images are released
+const shadowCacheSize = 256
+
+var _shadowsMap = make(map[ShadowMapKey]*shadowEntry)
+
+func (s *Shadow) color() Vec4 {
+	if s.Color != (Vec4{}) {
+		return s.Color
+	}
+	return Vec4{0, 0, 0, s.Alpha}
+}
+
+func (s *Shadow) visible() bool {
+	return s.color()[3] > 0
+}
+
+// ShadowLayers are the shadows of a container past the first, kept with the
+// frame in one buffer like gradients, so attrs without them stay small
+type ShadowLayers struct {
+	start, count uint32
+}
+
+// reset every frame
+var shadowLayers = make([]Shadow, 0, 64)
+
+func resetShadowLayers() {
+	shadowLayers = shadowLayers[:0]
+}
+
+func (l ShadowLayers) list() []Shadow {
+	if int(l.start+l.count) > len(shadowLayers) {
+		return nil // from another frame
+	}
+	return shadowLayers[l.start : l.start+l.count]
+}
+
+// AddShadow adds a shadow layer: the first one goes in Shadow, and up to
+// MaxShadows more in Shadows, which are only good in the frame they're added
+// in, like gradients
+func (a *Attrs) AddShadow(s Shadow) {
+	if a.Shadow == (Shadow{}) {
+		a.Shadow = s
+		return
+	}
+	var l = a.Shadows
+	if l.count >= MaxShadows {
+		return
+	}
+	// the layers grow in place when they end the buffer; otherwise (a copy of
+	// these attrs added to them since) they're moved to the end first
+	if l.count == 0 || int(l.start+l.count) != len(shadowLayers) {
+		var start = len(shadowLayers)
+		shadowLayers = append(shadowLayers, l.list()...)
+		l.start = uint32(start)
+	}
+	shadowLayers = append(shadowLayers, s)
+	l.count++
+	a.Shadows = l
+}
+
+// the container's own shadow, then the extra layers
+func (a *Attrs) allShadows() [MaxShadows + 1]Shadow {
+	var out [MaxShadows + 1]Shadow
+	out[0] = a.Shadow
+	copy(out[1:], a.Shadows.list())
+	return out
+}
+
+// where the image of an outer shadow goes for a container at rect
+func outerShadowRect(rect Rect, s *Shadow) Rect {
+	var pad = s.Blur*2 + s.Spread
+	return Rect{
+		Origin: Vec2Add(rect.Origin, Vec2Sub(s.Offset, Vec2{pad, pad})),
+		Size:   Vec2Add(rect.Size, Vec2{pad * 2, pad * 2}),
+	}
+}
+
+// and of an inset one; it's clipped to the container
+func insetShadowRect(rect Rect, s *Shadow) Rect {
+	var pad = s.Blur * 2
+	return Rect{
+		Origin: Vec2Sub(rect.Origin, Vec2{pad, pad}),
+		Size:   Vec2Add(rect.Size, Vec2{pad * 2, pad * 2}),
+	}
+}
 
 // returns an image handle!
-func _IMBlurShadow(size Vec2, corners Vec4, radius float32, alpha float32) ImageId {
+func _IMBlurShadow(size Vec2, corners Vec4, s *Shadow) ImageId {
 	var params = ShadowMapKey{
-		w:  int(size[0]),
-		h:  int(size[1]),
-		c0: uint8(corners[0]),
-		c1: uint8(corners[1]),
-		c2: uint8(corners[2]),
-		c3: uint8(corners[3]),
-		r:  uint8(radius * 10),
-		a:  uint8(alpha * 0xff),
-	}
-	imageId, ok := _shadowsMap[params]
+		w:      int(size[0]),
+		h:      int(size[1]),
+		c0:     uint8(corners[0]),
+		c1:     uint8(corners[1]),
+		c2:     uint8(corners[2]),
+		c3:     uint8(corners[3]),
+		r:      uint8(s.Blur * 10),
+		color:  HSLAColor(s.color()),
+		spread: int16(s.Spread),
+		inset:  s.Inset,
+	}
+	if s.Inset {
+		params.dx, params.dy = int16(s.Offset[0]), int16(s.Offset[1])
+	}
+	entry, ok := _shadowsMap[params]
 	if ok {
-		return imageId
-	} else {
-		// fmt.Println("Generating shadow:", params) // DEBUG!
-		img := _GenerateBlurShadow(size, corners, radius, alpha)
-		imageId = ImageId(len(imageIds))
-		imageIds = append(imageIds, img)
-		_shadowsMap[params] = imageId
-		return imageId
+		entry.lastUsed = FrameNumber
+		return entry.imageId
 	}
-}
 
-func _GenerateBlurShadow(size Vec2, corners Vec4, radius float32, alpha float32) *ImageData {
-	// the size is the size of the rect plus space for the blurring radius!
-	width := size[0] + radius*4
-	height := size[1] + radius*4
-	// fmt.Println("Size:", size, "Blur:", radius, "width, height:", width, height)
+	// fmt.Println("Generating shadow:", params) // DEBUG!
+	if len(_shadowsMap) >= shadowCacheSize {
+		_sweepShadows()
+	}
+	img := _GenerateBlurShadow(size, corners, s)
+	imageId := allocImage(img, 1)
+	_shadowsMap[params] = &shadowEntry{imageId: imageId, lastUsed: FrameNumber}
+	return imageId
+}
 
-	var rect = image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
-	// fmt.Println("image width:", rect.Bounds().Dx())
+// drops the shadows not drawn in this frame or the one before
+func _sweepShadows() {
+	for key, entry := range _shadowsMap {
+		if entry.lastUsed < FrameNumber-1 {
+			ReleaseImage(entry.imageId)
+			delete(_shadowsMap, key)
+		}
+	}
+}
 
-	var p = vector.NewRasterizer(int(width), int(height))
-	p.DrawOp = draw.Over
-	// based on gio/op/clip/shapes.go
-	// based on https://pomax.github.io/bezierinfo/#circles_cubic
+// adds a rounded rect to the rasterizer
+// based on gio/op/clip/shapes.go
+// based on https://pomax.github.io/bezierinfo/#circles_cubic
+func _rasterRoundedRect(p *vector.Rasterizer, rect Rect, corners Vec4) {
 	const q = 4 * (math.Sqrt2 - 1) / 3
 	const iq = 1 - q
 	// corners order: top-left | top-right | bottom-right | bottom-left
-	// in other words: nw, ne, sw, se
-	nw := corners[0]
-	ne := corners[1]
-	sw := corners[2]
-	se := corners[3]
-	// draw the rect at a location so that we can blur with the given radius!
-	w := radius * 2
-	n := radius * 2
-	e := w + size[0]
-	s := n + size[1]
+	var limit = min(rect.Size[0], rect.Size[1]) / 2
+	nw := max(0, min(corners[0], limit))
+	ne := max(0, min(corners[1], limit))
+	se := max(0, min(corners[2], limit))
+	sw := max(0, min(corners[3], limit))
+	w := rect.Origin[0]
+	n := rect.Origin[1]
+	e := w + rect.Size[0]
+	s := n + rect.Size[1]
 	p.MoveTo(w+nw, n)
 	p.LineTo(e-ne, n) // N
 	p.CubeTo(         // NE
@@ -96,10 +198,58 @@ func _GenerateBlurShadow(size Vec2, corners Vec4, radius float32, alpha float32)
 		w+nw*iq, n,
 		w+nw, n)
 	p.ClosePath()
+}
 
-	src := image.NewUniform(color.RGBA{0, 0, 0, uint8(alpha * 0xff)})
+// corners grow and shrink with the spread, like css, but square ones stay square
+func spreadCorners(corners Vec4, spread f32) Vec4 {
+	for i, c := range corners {
+		if c > 0 {
+			corners[i] = max(0, c+spread)
+		}
+	}
+	return corners
+}
+
+func _GenerateBlurShadow(size Vec2, corners Vec4, s *Shadow) *ImageData {
+	var radius = s.Blur
+	// the size is the size of the rect plus space for the blurring radius!
+	var pad = radius * 2
+	if !s.Inset {
+		pad += s.Spread
+	}
+	width := max(1, size[0]+pad*2)
+	height := max(1, size[1]+pad*2)
+	// fmt.Println("Size:", size, "Blur:", radius, "width, height:", width, height)
+
+	var rect = image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	// fmt.Println("image width:", rect.Bounds().Dx())
 
-	p.Draw(rect, rect.Bounds(), src, image.Point{})
+	var p = vector.NewRasterizer(int(width), int(height))
+	p.DrawOp = draw.Over
+
+	// draw the shape at a location so that we can blur with the given radius!
+	var shape = Rect{Origin: Vec2{pad, pad}, Size: size}
+	src := image.NewUniform(HSLAColor(s.color()))
+	if !s.Inset {
+		shape.Origin = Vec2Sub(shape.Origin, Vec2{s.Spread, s.Spread})
+		shape.Size = Vec2Add(shape.Size, Vec2{s.Spread * 2, s.Spread * 2})
+		shape.Size = Vec2{max(0, shape.Size[0]), max(0, shape.Size[1])}
+		_rasterRoundedRect(p, shape, spreadCorners(corners, s.Spread))
+		p.Draw(rect, rect.Bounds(), src, image.Point{})
+	} else {
+		// everything but the hole, which is the container moved by the offset
+		// and shrunk by the spread
+		shape.Origin = Vec2Add(shape.Origin, Vec2Add(s.Offset, Vec2{s.Spread, s.Spread}))
+		shape.Size = Vec2Sub(shape.Size, Vec2{s.Spread * 2, s.Spread * 2})
+		shape.Size = Vec2{max(0, shape.Size[0]), max(0, shape.Size[1])}
+		var mask = image.NewAlpha(rect.Bounds())
+		_rasterRoundedRect(p, shape, spreadCorners(corners, -s.Spread))
+		p.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+		for i, a := range mask.Pix {
+			mask.Pix[i] = 0xff - a
+		}
+		draw.DrawMask(rect, rect.Bounds(), src, image.Point{}, mask, image.Point{}, draw.Over)
+	}
 
 	var image = new(ImageData)
 	image.Config.ColorModel = rect.ColorModel()
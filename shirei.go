@@ -31,11 +31,23 @@ func RequestNextFrame() {
 	requested = true
 }
 
+var wakeAt time.Time
+
+// RequestFrameAfter asks for a frame once d has passed (e.g. for hover delays)
+// without running frames until then
+func RequestFrameAfter(d time.Duration) {
+	var t = time.Now().Add(d)
+	if wakeAt.IsZero() || t.Before(wakeAt) {
+		wakeAt = t
+	}
+}
+
 type MouseButton uint8
 
-// mirrors the values in gioui
+// mirrors the values in gioui; InputState.MouseButton holds the buttons that
+// are down, so test them with &
 const (
-	MousePrimary MouseButton = iota
+	MousePrimary MouseButton = 1 << iota
 	MouseSecondary
 	MouseTertiary
 )
@@ -70,7 +82,11 @@ var InputState struct {
 	// control keys state
 	Modifiers Modifiers
 
-	Composition string // text being input via IME
+	// text being composed via IME (preedit). It has already been delivered
+	// through FrameInput.Text and sits right before the caret; widgets use it
+	// to decorate that part of the text until the IME commits it
+	Composition      string
+	CompositionRange [2]int // IME selection within Composition (rune offsets)
 }
 
 // transient (frame level) input state
@@ -81,11 +97,15 @@ var FrameInput struct {
 
 	Key KeyCode
 
-	Text string // text inputted this frame (could come from IME completion)
+	Text        string // text inputted this frame (could come from IME completion)
+	TextReplace int    // number of runes before the caret that Text replaces (IME preedit updates)
 }
 
 // applications can set this to make the IME box appears in the right place
+// CaretPos is on the baseline; ascent and descent extend above and below it
 var CaretPos Vec2
+var CaretAscent f32
+var CaretDescent f32
 
 // to be set by backend
 var WindowSize Vec2
@@ -122,6 +142,8 @@ type FrameOutputData struct {
 
 	NextFrameRequested bool
 	FrameHasChanges    bool
+
+	WakeAt time.Time // when a frame is needed again; see RequestFrameAfter
 }
 
 // RunFrame is meant to be called by the app & rendering backend
@@ -148,7 +170,7 @@ func RunFrameFn(frameFn FrameFn) FrameOutputData {
 	directHovered = nil
 	g.ResetSlice(&hoverList)
 	for _, hoverable := range slices.Backward(hoverables) {
-		if RectContainsPoint(hoverable.Rect, InputState.MousePoint) {
+		if hoverable.contains(InputState.MousePoint) {
 			c := hoverable.Container
 			directHovered = c.Id
 			for c != nil {
@@ -163,7 +185,14 @@ func RunFrameFn(frameFn FrameFn) FrameOutputData {
 
 	// surfaces
 	g.ResetSlice(&surfaces)
+	resetPaths()
+	resetGradients()
+	resetShadowLayers()
+	resetBorderColors()
+	sweepImages()
 	requested = false
+	wakeAt = time.Time{}
+	advanceAnimations()
 
 	type root_type int
 
@@ -197,6 +226,7 @@ func RunFrameFn(frameFn FrameFn) FrameOutputData {
 		output.FrameHasChanges = true
 	}
 	output.NextFrameRequested = requested || output.FrameHasChanges
+	output.WakeAt = wakeAt
 	// output.NextFrameRequested = requested
 	surfaceHash = newSurfacesHash
 
@@ -292,7 +322,8 @@ type Surface struct {
 
 	Stroke     float32 // for borders!
 	ImageId    ImageId
-	ImageScale bool // if set, scales image down to fit surface!
+	ImageScale bool // if set, scales the source to fit the surface
+	ImageSrc   Rect // the part of the image drawn, in pixels; zero is all of it
 
 	FontId      FontId
 	GlyphId     GlyphId
@@ -306,7 +337,14 @@ type Surface struct {
 	// applies to both image and glyph
 	// ContentScale float32
 
-	// TODO: image, glyph, shape (vector)
+	PathId PathId // a vector path, filled with the colors
+
+	GradientId GradientId // fills instead of the colors
+
+	// pushes Affine on top of the current transform before drawing, or pops
+	// it after
+	TransformOp ClipStackOp
+	Affine      Affine
 }
 
 func Vec2Add(v1 Vec2, v2 Vec2) Vec2 {
@@ -351,6 +389,9 @@ func Vec4Sub(v1 Vec4, v2 Vec4) Vec4 {
 var surfaces = make([]Surface, 0, 1024*16)
 
 func PushSurface(s Surface) {
+	if s.ImageId > 0 {
+		markImageUsed(s.ImageId)
+	}
 	g.Append(&surfaces, s)
 }
 
@@ -365,6 +406,10 @@ func computeSurfacesHash(ss []Surface) uint64 {
 		// this relies on Surface being a flat plain object with no pointers
 		h.Write(generic.UnsafeRawBytes(&s))
 	}
+	// surfaces refer to paths by id, so their data has to go in too
+	h.Write(generic.UnsafeSliceBytes(pathCmds))
+	h.Write(generic.UnsafeSliceBytes(pathShapes))
+	h.Write(generic.UnsafeSliceBytes(gradients))
 	return h.Sum64()
 }
 
@@ -387,11 +432,6 @@ const (
 	ALPHA      = 3
 )
 
-type Border struct {
-	BorderColor Vec4
-	BorderWidth f32
-}
-
 type Alignment int
 
 const (
@@ -428,9 +468,16 @@ type Attrs struct {
 	Background Vec4
 	Gradient   Vec4 // diff applied to background
 
+	// angled, multi-stop, radial or conic (see FrameGradient); when set, it's
+	// drawn over Background instead of Gradient
+	Fill GradientId
+
 	Border
 
 	Shadow
+	Shadows ShadowLayers // more layers, drawn after Shadow in order
+
+	Transform
 
 	// css order: top-left, top-right, bottom-right, bottom-left
 	Corners Vec4
@@ -463,8 +510,14 @@ type Shadow struct {
 	Offset Vec2
 	Blur   f32
 	Alpha  f32
+
+	Color  Vec4 // when set, it's used instead of black at Alpha
+	Spread f32  // grows the shape before blurring; shrinks the hole of inset ones
+	Inset  bool // inside the container, over its background
 }
 
+const MaxShadows = 4
+
 const PAD_TOP = 0
 const PAD_RIGHT = 1
 const PAD_BOTTOM = 2
@@ -490,7 +543,10 @@ type Container struct {
 	scope scopeId
 
 	// image!
-	imageId ImageId
+	image containerImage
+
+	// vector paths drawn on it
+	paths []PathId
 
 	// text!
 	fontId      FontId
@@ -502,6 +558,7 @@ type Container struct {
 	resolvedOrigin Vec2
 
 	ScreenRect Rect // resolved size / origin clipped by parent clipping region
+	clipRect   Rect // the parent clipping region
 
 	ScrollOffset Vec2
 
@@ -512,6 +569,9 @@ type Container struct {
 	parent     *Container
 	children   []*Container
 	nextAutoId int
+
+	hookGroup any  // see PersistHooks
+	trapFocus bool // see TrapFocus
 }
 
 type _WrapLine struct {
@@ -606,13 +666,29 @@ func LayoutId(id any, attrs Attrs, builder func()) {
 	c.scope = newScope
 	c.Attrs = attrs
 	c.parent = current
+	c.hookGroup = current.hookGroup
 	current = c
-	c.ScrollOffset = renderData[c.Id].ScrollOffset
+
+	var group *hookGroup
+	if c.hookGroup != nil {
+		group = getHookGroup(c.hookGroup)
+	}
+	if rd, found := renderData[c.Id]; found || group == nil {
+		c.ScrollOffset = rd.ScrollOffset
+	} else {
+		c.ScrollOffset = group.scroll[c.Id]
+	}
 
 	if builder != nil {
 		builder()
 	}
 
+	if group != nil {
+		if _, found := group.scroll[c.Id]; found || c.ScrollOffset != (Vec2{}) {
+			group.scroll[c.Id] = c.ScrollOffset
+		}
+	}
+
 	resolveSizeFromInside(c)
 
 	current = c.parent
@@ -863,7 +939,12 @@ func resolveOrigins(container *Container) {
 				// animateVec4From(&child.Gradient, prev.Gradient, rate, clrCutoff)
 				// animateVec4From(&child.BorderColor, prev.BorderColor, rate, clrCutoff)
 				animateFrom(&child.BorderWidth, prev.BorderWidth, rate, distCutoff)
+				animateVec4From(&child.BorderSides, prev.BorderSides, rate, distCutoff)
 				animateFrom(&child.Transperancy, prev.Transperancy, rate, clrCutoff)
+				animateFrom(&child.Rotation, prev.Rotation, rate, 0.5)
+				child.Scale = child.Transform.scale()
+				animateVec2From(&child.Scale, prev.Transform.scale(), rate, 0.005)
+				animateVec2From(&child.Translate, prev.Translate, rate, distCutoff)
 			}
 
 			// apply relative origins **after** animations!
@@ -894,6 +975,8 @@ func resolveOrigins(container *Container) {
 	}
 }
 
+var unclipped = Rect{Origin: Vec2{-1e9, -1e9}, Size: Vec2{2e9, 2e9}}
+
 // this is called after resolving origins for everything
 // it doesn't actually "clip" the view; it determines what
 // the screen rect is when clipping is taken into account
@@ -902,7 +985,14 @@ func applyClipping(container *Container, clipRect Rect) {
 		Origin: container.resolvedOrigin,
 		Size:   container.resolvedSize,
 	}
-	container.ScreenRect = RectIntersect(clipRect, resolvedRect)
+	container.clipRect = clipRect
+	var transformed = !container.Transform.isIdentity()
+	if transformed {
+		// clipRect is outside the transform; hit-testing deals with it
+		container.ScreenRect = resolvedRect
+	} else {
+		container.ScreenRect = RectIntersect(clipRect, resolvedRect)
+	}
 	// renderDataNext is already filled; don't override it; just set the screen rect
 	render := renderDataNext[container.Id]
 	render.screenRect = container.ScreenRect
@@ -911,6 +1001,9 @@ func applyClipping(container *Container, clipRect Rect) {
 	nextClipRect := container.ScreenRect
 	if !container.Clip {
 		nextClipRect = clipRect
+		if transformed {
+			nextClipRect = unclipped
+		}
 	}
 	for _, child := range container.children {
 		applyClipping(child, nextClipRect)
@@ -1080,8 +1173,34 @@ func resolveSizesFromOutside(container *Container) {
 type HoverableArtifacts struct {
 	Rect      Rect
 	Container *Container
+
+	inverse Affine     // from the screen to the space of Rect
+	outer   *hoverClip // clipping from outside of transformed containers
+}
+
+// a clip rect in the space outside a transformed container
+type hoverClip struct {
+	rect    Rect
+	inverse Affine
+	parent  *hoverClip
+}
+
+func (h *HoverableArtifacts) contains(p Vec2) bool {
+	if !RectContainsPoint(h.Rect, h.inverse.Apply(p)) {
+		return false
+	}
+	for c := h.outer; c != nil; c = c.parent {
+		if !RectContainsPoint(c.rect, c.inverse.Apply(p)) {
+			return false
+		}
+	}
+	return true
 }
 
+// the transform of the container being rendered, and its inverse
+var renderWorld, renderInverse Affine
+var renderOuterClip *hoverClip
+
 var hoverables []HoverableArtifacts
 var focusables []any
 
@@ -1092,6 +1211,9 @@ var hovered []any
 var prevFocused any // to know when focus changes!
 var nextFocused any // requested focus!
 
+// the range of focusables inside the last container that traps focus
+var trapStart, trapEnd int
+
 var SurfaceCount int
 
 func beginRenderToSurfaces(root *Container) {
@@ -1100,6 +1222,8 @@ func beginRenderToSurfaces(root *Container) {
 	g.ResetSlice(&surfaces)
 	g.ResetSlice(&hoverables)
 	g.ResetSlice(&focusables)
+	trapStart, trapEnd = 0, 0
+	renderWorld, renderInverse, renderOuterClip = IdentityAffine, IdentityAffine, nil
 
 	_renderToSurfaces(root)
 	SurfaceCount = len(surfaces)
@@ -1132,44 +1256,95 @@ func _renderToSurfaces(container *Container) {
 		Size:   container.resolvedSize,
 	}
 
-	if container.Shadow.Alpha > 0 {
-		shRect := resolvedRect
-
-		shRect.Origin = Vec2Add(shRect.Origin, container.Shadow.Offset)
+	// the transform applies to the container's own surfaces too, so it's
+	// pushed with the first of them and popped with the last
+	var transformed = !container.Transform.isIdentity()
+	var pushTransform, popTransform ClipStackOp
+	var local Affine
+	var outerWorld, outerInverse, outerClip = renderWorld, renderInverse, renderOuterClip
+	if transformed {
+		pushTransform, popTransform = ClipPush, ClipPop
+		local = container.Transform.affine(resolvedRect)
+		// clipping from outside is tested in the space outside
+		renderOuterClip = &hoverClip{rect: container.clipRect, inverse: renderInverse, parent: renderOuterClip}
+		renderWorld = renderWorld.Mul(local)
+		renderInverse = renderWorld.Invert()
+	}
 
+	var shadows = container.allShadows()
+	for i := range shadows {
+		var shadow = &shadows[i]
+		if shadow.Inset || !shadow.visible() {
+			continue
+		}
 		// due to the way the shadow image is generated .. padding is added to make
 		// room for hte blur!
-		shRect.Origin = Vec2Add(shRect.Origin, Vec2{-container.Shadow.Blur * 2, -container.Shadow.Blur * 2})
-
 		PushSurface(Surface{
-			Rect:       shRect,
-			ImageId:    _IMBlurShadow(shRect.Size, container.Corners, container.Shadow.Blur, container.Shadow.Alpha),
+			Rect:       outerShadowRect(resolvedRect, shadow),
+			ImageId:    _IMBlurShadow(resolvedRect.Size, container.Corners, shadow),
 			ImageScale: false,
+
+			TransformOp: pushTransform,
+			Affine:      local,
 		})
+		pushTransform = 0
 	}
 
 	PushSurface(Surface{
-		Rect:    resolvedRect,
-		Color1:  container.Background,
-		Color2:  Vec4Add(container.Background, container.Gradient),
-		Corners: container.Corners,
+		Rect:       resolvedRect,
+		Color1:     container.Background,
+		Color2:     Vec4Add(container.Background, container.Gradient),
+		Corners:    container.Corners,
+		GradientId: container.Fill,
 
-		ImageId:      container.imageId,
-		ImageScale:   true,
 		FontId:       container.fontId,
 		GlyphId:      container.glyphId,
 		GlyphOffset:  container.glyphOffset,
 		Clip:         clip1,
 		Transperancy: container.Transperancy,
+
+		TransformOp: pushTransform,
+		Affine:      local,
 	})
 
+	// clipped to the container, since covering and tiling go past it
+	if container.image.id > 0 {
+		PushSurface(Surface{Rect: resolvedRect, Corners: container.Corners, Clip: ClipPush})
+		pushImageSurfaces(resolvedRect, &container.image)
+		PushSurface(Surface{Rect: resolvedRect, Corners: container.Corners, Clip: ClipPop})
+		if anim := container.image.anim; anim != nil && container.ScreenRect.Size[0] > 0 && container.ScreenRect.Size[1] > 0 {
+			anim.markVisible()
+		}
+	}
+
+	// inset shadows are clipped to the container
+	for i := range shadows {
+		var shadow = &shadows[i]
+		if !shadow.Inset || !shadow.visible() {
+			continue
+		}
+		PushSurface(Surface{Rect: resolvedRect, Corners: container.Corners, Clip: ClipPush})
+		PushSurface(Surface{
+			Rect:    insetShadowRect(resolvedRect, shadow),
+			ImageId: _IMBlurShadow(resolvedRect.Size, container.Corners, shadow),
+			Clip:    ClipPop,
+		})
+	}
+
+	for _, id := range container.paths {
+		PushSurface(pathShapes[id].surface(id, resolvedRect))
+	}
+
 	if !container.ClickThrough {
 		g.Append(&hoverables, HoverableArtifacts{
 			// Rect:      resolvedRect,
 			Rect:      container.ScreenRect,
 			Container: container,
+			inverse:   renderInverse,
+			outer:     renderOuterClip,
 		})
 	}
+	var focusablesStart = len(focusables)
 	if container.Focusable {
 		g.Append(&focusables, container.Id)
 	}
@@ -1192,19 +1367,42 @@ func _renderToSurfaces(container *Container) {
 		_renderToSurfaces(child)
 	}
 
+	if container.trapFocus {
+		trapStart, trapEnd = focusablesStart, len(focusables)
+	}
+
 	// border and clipping
-	if container.BorderWidth > 0 || shouldClip || container.Transperancy > 0 {
+	// plain borders are a stroke of the last surface; the others are paths
+	var borderRect, borderCorners = resolvedRect, container.Corners
+	var borderColor, borderWidth = container.BorderColor, container.BorderWidth
+	var plain = container.isPlain()
+	if shouldClip && container.hasBorder() && container.BorderAlign != StrokeInside && !(plain && container.BorderAlign == StrokeCenter) {
+		// the border reaches outside, where the clip would cut it
+		PushSurface(Surface{Rect: resolvedRect, Corners: container.Corners, Clip: ClipPop})
+		clip2 = 0
+	}
+	if plain {
+		borderRect, borderCorners = plainBorderRect(resolvedRect, container.Corners, &container.Border)
+	} else {
+		if container.hasBorder() {
+			pushBorderSurfaces(resolvedRect, container.Corners, &container.Border)
+		}
+		borderColor, borderWidth = Vec4{}, 0
+	}
+	if borderWidth > 0 || clip2 == ClipPop || container.Transperancy > 0 || transformed {
 		PushSurface(Surface{
-			Rect:    resolvedRect,
-			Color1:  container.BorderColor,
-			Color2:  container.BorderColor,
-			Corners: container.Corners,
-			Stroke:  container.BorderWidth,
+			Rect:    borderRect,
+			Color1:  borderColor,
+			Color2:  borderColor,
+			Corners: borderCorners,
+			Stroke:  borderWidth,
 			Clip:    clip2,
 
 			PopTransperancy: container.Transperancy > 0,
+			TransformOp:     popTransform,
 		})
 	}
+	renderWorld, renderInverse, renderOuterClip = outerWorld, outerInverse, outerClip
 }
 
 func Focus() {
@@ -1237,18 +1435,32 @@ func AutoFocus() {
 
 // dir should be 1 or -1, but an arbitrary number should work too ..
 func CycleFocus(dir int) {
-	idx := slices.Index(focusables, focused)
+	var candidates = focusables
+	if trapEnd > trapStart {
+		candidates = focusables[trapStart:trapEnd]
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	idx := slices.Index(candidates, focused)
 	if idx == -1 {
 		// special case
 		if dir < 0 {
-			idx = len(focusables)
+			idx = len(candidates)
 		}
 	}
-	nextIdx := (idx + dir) % len(focusables)
+	nextIdx := (idx + dir) % len(candidates)
 	if nextIdx < 0 {
-		nextIdx += len(focusables)
+		nextIdx += len(candidates)
 	}
-	nextFocused = focusables[nextIdx]
+	nextFocused = candidates[nextIdx]
+}
+
+// TrapFocus keeps focus cycling (see CycleFocus) within the current container,
+// e.g. for modal dialogs. When several containers trap focus, the last one
+// rendered wins.
+func TrapFocus() {
+	current.trapFocus = true
 }
 
 func CycleFocusOnTab() {
@@ -1262,7 +1474,8 @@ func _cycleFocusOnTab(currentId any) {
 		return
 	}
 
-	if FrameInput.Key == KeyTab {
+	// ctrl+tab is left for switching tabs
+	if FrameInput.Key == KeyTab && InputState.Modifiers&ModCtrl == 0 {
 		var dir = 1
 		if InputState.Modifiers&ModShift != 0 {
 			dir = -1
@@ -1284,6 +1497,11 @@ func IdHasFocus(id any) bool {
 	return focused == id
 }
 
+// the id of the focused container, or nil
+func FocusedId() any {
+	return focused
+}
+
 func isChild(target any) bool {
 	for target != nil {
 		if target == current.Id {
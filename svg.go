@@ -0,0 +1,1131 @@
+package shirei
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+//      SVG
+// -----------------------------------------------------------------------------
+// The common subset of SVG: paths and basic shapes, solid and gradient fills
+// and strokes, transforms and the viewBox. A file is parsed once into shapes
+// in viewBox space, which are drawn as vector paths every frame, so they stay
+// sharp at any size.
+//
+// Not supported: text, <use>, clip paths, masks, filters, patterns and css
+// stylesheets (style attributes are fine). Group opacity is folded into the
+// shapes.
+
+type SVG struct {
+	ViewBox Rect
+	Size    Vec2 // from the width and height attributes, or the viewBox
+
+	shapes []svgShape
+}
+
+type svgPaintKind uint8
+
+const (
+	svgPaintNone svgPaintKind = iota
+	svgPaintColor
+	svgPaintCurrent // currentColor
+	svgPaintGradient
+)
+
+type svgPaint struct {
+	kind     svgPaintKind
+	color    Vec4
+	ref      string // the gradient's id, until it's resolved
+	gradient *svgGradient
+}
+
+type svgGradient struct {
+	radial    bool
+	userSpace bool
+	transform Affine // gradientTransform, for user space ones
+
+	x1, y1, x2, y2 f32
+	cx, cy, r      f32
+
+	stops []GradientStop
+}
+
+type svgShape struct {
+	cmds []PathCmd // in viewBox space
+	bbox Rect
+	ctm  Affine // from the shape's own space, where user space gradients are
+
+	fill, stroke  svgPaint
+	fillOpacity   f32
+	strokeOpacity f32
+	fillRule      FillRule
+	style         StrokeStyle
+}
+
+// inherited down the tree
+type svgState struct {
+	fill, stroke  svgPaint
+	fillOpacity   f32
+	strokeOpacity f32
+	fillRule      FillRule
+	opacity       f32 // not inherited in svg, but multiplied into the children here
+	style         StrokeStyle
+	ctm           Affine
+	hidden        bool
+}
+
+var errNotSVG = errors.New("not an svg")
+
+// LoadSVG parses an svg file; nil when it can't be
+func LoadSVG(fpath string) *SVG {
+	const key = "svg"
+	svg, found := _getFileCacheContent[*SVG](fpath, key)
+	if found {
+		return svg
+	}
+	svg, _ = ParseSVG(ReadFileContent(fpath))
+	_setFileCacheContent(fpath, key, svg)
+	return svg
+}
+
+// SVGImage draws an svg file, like Image: at its own size unless that's
+// bigger than maxSize
+func SVGImage(fpath string, maxSize Vec2) {
+	var attrs = DefaultImageAttrs()
+	attrs.MaxSize = maxSize
+	SVGImageExt(LoadSVG(fpath), attrs)
+}
+
+// SVGImageExt draws an svg sized, fitted and clipped by attrs like ImageExt
+// does an image, with Crop in viewBox units; Tile and Slice don't apply.
+// currentColor is the text color.
+func SVGImageExt(svg *SVG, attrs ImageAttrs) {
+	SVGImageColor(svg, attrs, DefaultTextStyle().Color)
+}
+
+// SVGImageColor is SVGImageExt with currentColor as color, so the same file
+// can be drawn in different colors, like an icon font
+func SVGImageColor(svg *SVG, attrs ImageAttrs, color Vec4) {
+	if svg == nil {
+		return
+	}
+	var size = imageBoxSize(svg.Size, attrs)
+	Layout(Attrs{MaxSize: size, MinSize: size, Clip: true, Corners: attrs.Corners, Border: attrs.Border}, func() {
+		var src = attrs.Crop
+		if src.Size == (Vec2{}) {
+			src = svg.ViewBox
+		}
+		// the crop is drawn at the svg's own scale, then fitted
+		var scale = Vec2{svg.Size[0] / svg.ViewBox.Size[0], svg.Size[1] / svg.ViewBox.Size[1]}
+		var natural = Vec2{src.Size[0] * scale[0], src.Size[1] * scale[1]}
+		var dest = FitImage(natural, Rect{Size: size}, attrs.Fit, attrs.Focus)
+		Draw(func(p *Path) {
+			svg.Draw(p, src, dest, color)
+		})
+	})
+}
+
+type svgGradientDef struct {
+	radial bool
+	attrs  map[string]string
+	stops  []GradientStop
+}
+
+func ParseSVG(content []byte) (*SVG, error) {
+	var svg = new(SVG)
+	var decoder = xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+
+	var root = svgState{
+		fill:          svgPaint{kind: svgPaintColor, color: Vec4{0, 0, 0, 1}},
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		opacity:       1,
+		style:         StrokeStyle{Width: 1},
+		ctm:           IdentityAffine,
+	}
+	var stack = []svgState{root}
+	var seenRoot bool
+	var defs = make(map[string]*svgGradientDef)
+	var gradient *svgGradientDef // the one being read, for its stops
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			var attrs = svgAttrs(token.Attr)
+			var state = stack[len(stack)-1]
+			state.apply(attrs)
+			if t, ok := attrs["transform"]; ok {
+				state.ctm = state.ctm.Mul(parseSVGTransform(t))
+			}
+			stack = append(stack, state)
+
+			switch token.Name.Local {
+			case "svg":
+				if !seenRoot {
+					seenRoot = true
+					svg.readViewport(attrs)
+				}
+			case "linearGradient", "radialGradient":
+				gradient = &svgGradientDef{radial: token.Name.Local == "radialGradient", attrs: attrs}
+				if id := attrs["id"]; id != "" {
+					defs[id] = gradient
+				}
+			case "stop":
+				if gradient != nil {
+					gradient.stops = append(gradient.stops, parseSVGStop(attrs))
+				}
+			case "defs", "clipPath", "mask", "symbol", "pattern", "marker", "text", "style", "title", "desc", "metadata":
+				// read for the gradients inside, but not drawn
+				stack[len(stack)-1].hidden = true
+			default:
+				if state.hidden {
+					break
+				}
+				if cmds := svgShapeCmds(token.Name.Local, attrs, state.ctm); len(cmds) > 0 {
+					svg.shapes = append(svg.shapes, state.shape(cmds))
+				}
+			}
+
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			if token.Name.Local == "linearGradient" || token.Name.Local == "radialGradient" {
+				gradient = nil
+			}
+		}
+	}
+	if !seenRoot {
+		return nil, errNotSVG
+	}
+
+	var resolved = make(map[string]*svgGradient)
+	resolve := func(paint *svgPaint) {
+		if paint.kind != svgPaintGradient {
+			return
+		}
+		if g, ok := resolved[paint.ref]; ok {
+			paint.gradient = g
+		} else if def := defs[paint.ref]; def != nil {
+			paint.gradient = svg.buildGradient(def, defs)
+			resolved[paint.ref] = paint.gradient
+		}
+		if paint.gradient == nil || len(paint.gradient.stops) == 0 {
+			paint.kind = svgPaintNone
+		}
+	}
+	for i := range svg.shapes {
+		resolve(&svg.shapes[i].fill)
+		resolve(&svg.shapes[i].stroke)
+	}
+	return svg, nil
+}
+
+func svgAttrs(list []xml.Attr) map[string]string {
+	var attrs = make(map[string]string, len(list))
+	for _, attr := range list {
+		attrs[attr.Name.Local] = strings.TrimSpace(attr.Value)
+	}
+	// the style attribute wins over the presentation ones
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok {
+			attrs[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return attrs
+}
+
+func (svg *SVG) readViewport(attrs map[string]string) {
+	var width, height = parseSVGLength(attrs["width"]), parseSVGLength(attrs["height"])
+	if nums := parseSVGNumbers(attrs["viewBox"]); len(nums) == 4 && nums[2] > 0 && nums[3] > 0 {
+		svg.ViewBox = Rect{Origin: Vec2{nums[0], nums[1]}, Size: Vec2{nums[2], nums[3]}}
+	} else {
+		svg.ViewBox = Rect{Size: Vec2{width, height}}
+	}
+	// a missing side follows the viewBox's aspect
+	var box = svg.ViewBox.Size
+	switch {
+	case width > 0 && height > 0:
+		svg.Size = Vec2{width, height}
+	case width > 0 && box[0] > 0:
+		svg.Size = Vec2{width, width * box[1] / box[0]}
+	case height > 0 && box[1] > 0:
+		svg.Size = Vec2{height * box[0] / box[1], height}
+	default:
+		svg.Size = box
+	}
+	if svg.ViewBox.Size[0] <= 0 || svg.ViewBox.Size[1] <= 0 {
+		svg.ViewBox.Size = Vec2{max(1, svg.Size[0]), max(1, svg.Size[1])}
+	}
+}
+
+func (state *svgState) apply(attrs map[string]string) {
+	for name, value := range attrs {
+		switch name {
+		case "fill":
+			state.fill = parseSVGPaint(value, state.fill)
+		case "stroke":
+			state.stroke = parseSVGPaint(value, state.stroke)
+		case "fill-opacity":
+			state.fillOpacity = parseSVGFraction(value, state.fillOpacity)
+		case "stroke-opacity":
+			state.strokeOpacity = parseSVGFraction(value, state.strokeOpacity)
+		case "fill-rule":
+			switch value {
+			case "nonzero":
+				state.fillRule = FillNonZero
+			case "evenodd":
+				state.fillRule = FillEvenOdd
+			}
+		case "opacity":
+			state.opacity *= parseSVGFraction(value, 1)
+		case "stroke-width":
+			state.style.Width = parseSVGLength(value)
+		case "stroke-linecap":
+			switch value {
+			case "butt":
+				state.style.Cap = CapButt
+			case "round":
+				state.style.Cap = CapRound
+			case "square":
+				state.style.Cap = CapSquare
+			}
+		case "stroke-linejoin":
+			switch value {
+			case "miter", "miter-clip", "arcs":
+				state.style.Join = JoinMiter
+			case "round":
+				state.style.Join = JoinRound
+			case "bevel":
+				state.style.Join = JoinBevel
+			}
+		case "stroke-miterlimit":
+			state.style.MiterLimit = parseSVGLength(value)
+		case "stroke-dasharray":
+			state.style.Dashes = nil
+			if value != "none" {
+				state.style.Dashes = parseSVGNumbers(value)
+			}
+		case "stroke-dashoffset":
+			state.style.DashOffset = parseSVGLength(value)
+		case "display":
+			if value == "none" {
+				state.hidden = true
+			}
+		case "visibility":
+			state.hidden = state.hidden || value == "hidden" || value == "collapse"
+		}
+	}
+}
+
+// a shape in viewBox space, with the stroke scaled like its points
+func (state *svgState) shape(cmds []PathCmd) svgShape {
+	var shape = svgShape{
+		cmds:          cmds,
+		ctm:           state.ctm,
+		fill:          state.fill,
+		stroke:        state.stroke,
+		fillOpacity:   state.fillOpacity * state.opacity,
+		strokeOpacity: state.strokeOpacity * state.opacity,
+		fillRule:      state.fillRule,
+		style:         state.style,
+	}
+	var scale = affineScaleFactor(state.ctm)
+	shape.style.Width *= scale
+	shape.style.DashOffset *= scale
+	if len(shape.style.Dashes) > 0 {
+		var dashes = make([]f32, len(shape.style.Dashes))
+		var total f32
+		for i, d := range shape.style.Dashes {
+			dashes[i] = d * scale
+			total += d
+		}
+		shape.style.Dashes = dashes
+		if total <= 0 {
+			shape.style.Dashes = nil
+		}
+	}
+
+	var lo = Vec2{f32(math.Inf(1)), f32(math.Inf(1))}
+	var hi = Vec2{f32(math.Inf(-1)), f32(math.Inf(-1))}
+	for _, cmd := range cmds {
+		for _, p := range cmd.Points[:svgVerbPoints(cmd.Verb)] {
+			lo = Vec2{min(lo[0], p[0]), min(lo[1], p[1])}
+			hi = Vec2{max(hi[0], p[0]), max(hi[1], p[1])}
+		}
+	}
+	shape.bbox = Rect{Origin: lo, Size: Vec2Sub(hi, lo)}
+	return shape
+}
+
+func svgVerbPoints(verb PathVerb) int {
+	switch verb {
+	case PathMoveTo, PathLineTo:
+		return 1
+	case PathQuadTo:
+		return 2
+	case PathCubeTo:
+		return 3
+	}
+	return 0
+}
+
+// how much an affine scales lengths, on average
+func affineScaleFactor(m Affine) f32 {
+	return f32(math.Sqrt(math.Abs(float64(m[0]*m[4] - m[1]*m[3]))))
+}
+
+// ---- drawing ----
+
+// Draw draws the src part of the viewBox (all of it when zero) stretched
+// over dest with p; currentColor is color
+func (svg *SVG) Draw(p *Path, src Rect, dest Rect, color Vec4) {
+	if src.Size[0] <= 0 || src.Size[1] <= 0 {
+		src = svg.ViewBox
+	}
+	var m = AffineTranslate(dest.Origin).
+		Mul(AffineScale(Vec2{dest.Size[0] / src.Size[0], dest.Size[1] / src.Size[1]})).
+		Mul(AffineTranslate(Vec2Mul(src.Origin, -1)))
+	var scale = affineScaleFactor(m)
+	defer func(rule FillRule) { p.FillRule = rule }(p.FillRule)
+
+	for i := range svg.shapes {
+		var shape = &svg.shapes[i]
+		p.Begin()
+		for _, cmd := range shape.cmds {
+			switch cmd.Verb {
+			case PathMoveTo:
+				p.MoveTo(m.Apply(cmd.Points[0]))
+			case PathLineTo:
+				p.LineTo(m.Apply(cmd.Points[0]))
+			case PathQuadTo:
+				p.QuadTo(m.Apply(cmd.Points[0]), m.Apply(cmd.Points[1]))
+			case PathCubeTo:
+				p.CubeTo(m.Apply(cmd.Points[0]), m.Apply(cmd.Points[1]), m.Apply(cmd.Points[2]))
+			case PathClose:
+				p.Close()
+			}
+		}
+		var bbox = Rect{Origin: m.Apply(shape.bbox.Origin), Size: Vec2{shape.bbox.Size[0] * m[0], shape.bbox.Size[1] * m[4]}}
+
+		p.FillRule = shape.fillRule
+		switch paint := shape.fill; paint.kind {
+		case svgPaintColor, svgPaintCurrent:
+			p.Fill(paint.colorWith(color, shape.fillOpacity))
+		case svgPaintGradient:
+			g, box := paint.gradient.toGradient(bbox, m.Mul(shape.ctm), shape.fillOpacity)
+			p.FillGradient(g, box)
+		}
+
+		if shape.style.Width <= 0 {
+			continue
+		}
+		var style = shape.style
+		style.Width *= scale
+		style.DashOffset *= scale
+		if len(style.Dashes) > 0 {
+			style.Dashes = make([]f32, len(shape.style.Dashes))
+			for i, d := range shape.style.Dashes {
+				style.Dashes[i] = d * scale
+			}
+		}
+		switch paint := shape.stroke; paint.kind {
+		case svgPaintColor, svgPaintCurrent:
+			p.Stroke(paint.colorWith(color, shape.strokeOpacity), style)
+		case svgPaintGradient:
+			g, box := paint.gradient.toGradient(bbox, m.Mul(shape.ctm), shape.strokeOpacity)
+			p.StrokeGradient(g, box, style)
+		}
+	}
+}
+
+func (paint svgPaint) colorWith(current Vec4, opacity f32) Vec4 {
+	var color = paint.color
+	if paint.kind == svgPaintCurrent {
+		color = current
+	}
+	color[3] *= opacity
+	return color
+}
+
+// the gradient and its box in drawing space; bbox is the shape's there, and m
+// maps the shape's own space to it
+func (sg *svgGradient) toGradient(bbox Rect, m Affine, opacity f32) (Gradient, Rect) {
+	var stops = make([]GradientStop, len(sg.stops))
+	for i, stop := range sg.stops {
+		stop.Color[3] *= opacity
+		stops[i] = stop
+	}
+	if sg.userSpace {
+		m = m.Mul(sg.transform)
+	}
+	at := func(x, y f32) Vec2 {
+		if sg.userSpace {
+			return m.Apply(Vec2{x, y})
+		}
+		return Vec2{bbox.Origin[0] + x*bbox.Size[0], bbox.Origin[1] + y*bbox.Size[1]}
+	}
+
+	if sg.radial {
+		var g = RadialGradient(stops...)
+		if !sg.userSpace {
+			g.Center = Vec2{sg.cx - 0.5, sg.cy - 0.5}
+			g.Radius = Vec2{max(sg.r, 1e-3), max(sg.r, 1e-3)}
+			return g, bbox
+		}
+		var r = max(sg.r*affineScaleFactor(m), 1e-3)
+		var center = at(sg.cx, sg.cy)
+		g.Radius = Vec2{0.5, 0.5}
+		return g, Rect{Origin: Vec2Sub(center, Vec2{r, r}), Size: Vec2{r * 2, r * 2}}
+	}
+
+	// a square around the middle of the line, sized so the gradient's own
+	// line across it (see LinearPoints) is this one
+	var p1, p2 = at(sg.x1, sg.y1), at(sg.x2, sg.y2)
+	var dir = Vec2Sub(p2, p1)
+	var length = vecLength(dir)
+	if length == 0 {
+		var last = stops[len(stops)-1]
+		last.At = 0
+		return LinearGradient(0, last), bbox
+	}
+	var angle = f32(math.Atan2(float64(dir[0]), float64(-dir[1])) * 180 / math.Pi)
+	var side = length / (Absf32(dir[0])/length + Absf32(dir[1])/length)
+	var mid = Vec2Mul(Vec2Add(p1, p2), 0.5)
+	return LinearGradient(angle, stops...), Rect{Origin: Vec2Sub(mid, Vec2{side / 2, side / 2}), Size: Vec2{side, side}}
+}
+
+// follows href for the attributes and stops it doesn't have
+func (svg *SVG) buildGradient(def *svgGradientDef, defs map[string]*svgGradientDef) *svgGradient {
+	var attrs = make(map[string]string)
+	var stops []GradientStop
+	for depth := 0; def != nil && depth < 8; depth++ {
+		for name, value := range def.attrs {
+			if _, ok := attrs[name]; !ok {
+				attrs[name] = value
+			}
+		}
+		if stops == nil {
+			stops = def.stops
+		}
+		var href = def.attrs["href"]
+		if !strings.HasPrefix(href, "#") {
+			break
+		}
+		def = defs[href[1:]]
+	}
+	if def == nil {
+		def = &svgGradientDef{}
+	}
+
+	var sg = &svgGradient{
+		userSpace: attrs["gradientUnits"] == "userSpaceOnUse",
+		transform: IdentityAffine,
+		stops:     stops,
+	}
+	if t, ok := attrs["gradientTransform"]; ok {
+		sg.transform = parseSVGTransform(t)
+	}
+	_, sg.radial = attrs["r"]
+	sg.radial = sg.radial || attrs["cx"] != "" || attrs["fx"] != ""
+	coord := func(name string, fallback f32, ref f32) f32 {
+		value, ok := attrs[name]
+		if !ok {
+			if sg.userSpace {
+				return fallback * ref
+			}
+			return fallback
+		}
+		if strings.HasSuffix(value, "%") {
+			var n, _ = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 32)
+			if sg.userSpace {
+				return f32(n) / 100 * ref
+			}
+			return f32(n) / 100
+		}
+		return parseSVGLength(value)
+	}
+	var w, h = svg.ViewBox.Size[0], svg.ViewBox.Size[1]
+	var diag = f32(math.Hypot(float64(w), float64(h)) / math.Sqrt2)
+	sg.x1, sg.y1 = coord("x1", 0, w), coord("y1", 0, h)
+	sg.x2, sg.y2 = coord("x2", 1, w), coord("y2", 0, h)
+	sg.cx, sg.cy, sg.r = coord("cx", 0.5, w), coord("cy", 0.5, h), coord("r", 0.5, diag)
+	return sg
+}
+
+func parseSVGStop(attrs map[string]string) GradientStop {
+	var stop GradientStop
+	var offset = attrs["offset"]
+	if strings.HasSuffix(offset, "%") {
+		var n, _ = strconv.ParseFloat(strings.TrimSuffix(offset, "%"), 32)
+		stop.At = f32(n) / 100
+	} else {
+		stop.At = parseSVGLength(offset)
+	}
+	stop.Color = Vec4{0, 0, 0, 1}
+	if c, ok := parseSVGColor(attrs["stop-color"]); ok {
+		stop.Color = c
+	}
+	stop.Color[3] *= parseSVGFraction(attrs["stop-opacity"], 1)
+	return stop
+}
+
+// ---- shapes ----
+
+func svgShapeCmds(element string, attrs map[string]string, ctm Affine) []PathCmd {
+	var b = svgPathBuilder{ctm: ctm}
+	num := func(name string) f32 {
+		return parseSVGLength(attrs[name])
+	}
+	switch element {
+	case "path":
+		b.parse(attrs["d"])
+	case "rect":
+		var x, y, w, h = num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		var rx, ry = num("rx"), num("ry")
+		if _, ok := attrs["rx"]; !ok {
+			rx = ry
+		}
+		if _, ok := attrs["ry"]; !ok {
+			ry = rx
+		}
+		rx, ry = min(max(rx, 0), w/2), min(max(ry, 0), h/2)
+		if rx == 0 || ry == 0 {
+			b.moveTo(Vec2{x, y})
+			b.lineTo(Vec2{x + w, y})
+			b.lineTo(Vec2{x + w, y + h})
+			b.lineTo(Vec2{x, y + h})
+		} else {
+			b.moveTo(Vec2{x + rx, y})
+			b.lineTo(Vec2{x + w - rx, y})
+			b.arcTo(rx, ry, 0, false, true, Vec2{x + w, y + ry})
+			b.lineTo(Vec2{x + w, y + h - ry})
+			b.arcTo(rx, ry, 0, false, true, Vec2{x + w - rx, y + h})
+			b.lineTo(Vec2{x + rx, y + h})
+			b.arcTo(rx, ry, 0, false, true, Vec2{x, y + h - ry})
+			b.lineTo(Vec2{x, y + ry})
+			b.arcTo(rx, ry, 0, false, true, Vec2{x + rx, y})
+		}
+		b.close()
+	case "circle", "ellipse":
+		var cx, cy = num("cx"), num("cy")
+		var rx, ry = num("rx"), num("ry")
+		if element == "circle" {
+			rx, ry = num("r"), num("r")
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		b.moveTo(Vec2{cx + rx, cy})
+		b.arcTo(rx, ry, 0, false, true, Vec2{cx - rx, cy})
+		b.arcTo(rx, ry, 0, false, true, Vec2{cx + rx, cy})
+		b.close()
+	case "line":
+		b.moveTo(Vec2{num("x1"), num("y1")})
+		b.lineTo(Vec2{num("x2"), num("y2")})
+	case "polyline", "polygon":
+		var nums = parseSVGNumbers(attrs["points"])
+		for i := 0; i+1 < len(nums); i += 2 {
+			if i == 0 {
+				b.moveTo(Vec2{nums[0], nums[1]})
+			} else {
+				b.lineTo(Vec2{nums[i], nums[i+1]})
+			}
+		}
+		if element == "polygon" {
+			b.close()
+		}
+	}
+	return b.cmds
+}
+
+// builds commands in viewBox space from ones in the element's space
+type svgPathBuilder struct {
+	ctm  Affine
+	cmds []PathCmd
+
+	start, pen Vec2 // in the element's space
+	ctrl       Vec2 // the last control point, for S and T
+	lastVerb   byte
+}
+
+func (b *svgPathBuilder) add(verb PathVerb, points ...Vec2) {
+	var cmd = PathCmd{Verb: verb}
+	for i, p := range points {
+		cmd.Points[i] = b.ctm.Apply(p)
+	}
+	b.cmds = append(b.cmds, cmd)
+}
+
+func (b *svgPathBuilder) moveTo(p Vec2) {
+	b.add(PathMoveTo, p)
+	b.start, b.pen, b.ctrl = p, p, p
+}
+
+func (b *svgPathBuilder) lineTo(p Vec2) {
+	b.add(PathLineTo, p)
+	b.pen, b.ctrl = p, p
+}
+
+func (b *svgPathBuilder) quadTo(c, p Vec2) {
+	b.add(PathQuadTo, c, p)
+	b.pen, b.ctrl = p, c
+}
+
+func (b *svgPathBuilder) cubeTo(c1, c2, p Vec2) {
+	b.add(PathCubeTo, c1, c2, p)
+	b.pen, b.ctrl = p, c2
+}
+
+func (b *svgPathBuilder) close() {
+	b.add(PathClose)
+	b.pen, b.ctrl = b.start, b.start
+}
+
+// an elliptic arc as cubics, by the svg spec's endpoint to center conversion
+func (b *svgPathBuilder) arcTo(rx, ry, rotation f32, large, sweep bool, to Vec2) {
+	var from = b.pen
+	if from == to {
+		return
+	}
+	if rx == 0 || ry == 0 {
+		b.lineTo(to)
+		return
+	}
+	var rX, rY = math.Abs(float64(rx)), math.Abs(float64(ry))
+	var sin, cos = math.Sincos(float64(rotation) * math.Pi / 180)
+	var dx, dy = float64(from[0]-to[0]) / 2, float64(from[1]-to[1]) / 2
+	var x1, y1 = cos*dx + sin*dy, -sin*dx + cos*dy
+
+	if lambda := x1*x1/(rX*rX) + y1*y1/(rY*rY); lambda > 1 {
+		rX, rY = rX*math.Sqrt(lambda), rY*math.Sqrt(lambda)
+	}
+	var num = rX*rX*rY*rY - rX*rX*y1*y1 - rY*rY*x1*x1
+	var den = rX*rX*y1*y1 + rY*rY*x1*x1
+	var coef = math.Sqrt(max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	var cx1, cy1 = coef * rX * y1 / rY, -coef * rY * x1 / rX
+	var cx = cos*cx1 - sin*cy1 + float64(from[0]+to[0])/2
+	var cy = sin*cx1 + cos*cy1 + float64(from[1]+to[1])/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	var ux, uy = (x1 - cx1) / rX, (y1 - cy1) / rY
+	var vx, vy = (-x1 - cx1) / rX, (-y1 - cy1) / rY
+	var start = angle(1, 0, ux, uy)
+	var delta = angle(ux, uy, vx, vy)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	var count = max(1, int(math.Ceil(math.Abs(delta)/(math.Pi/2)-1e-6)))
+	var step = delta / float64(count)
+	var k = 4.0 / 3.0 * math.Tan(step/4)
+	point := func(t float64) Vec2 {
+		var st, ct = math.Sincos(t)
+		return Vec2{f32(cx + rX*ct*cos - rY*st*sin), f32(cy + rX*ct*sin + rY*st*cos)}
+	}
+	tangent := func(t float64) Vec2 {
+		var st, ct = math.Sincos(t)
+		return Vec2{f32(k * (-rX*st*cos - rY*ct*sin)), f32(k * (-rX*st*sin + rY*ct*cos))}
+	}
+	for i := range count {
+		var t0, t1 = start + float64(i)*step, start + float64(i+1)*step
+		var p0, p1 = point(t0), point(t1)
+		if i == count-1 {
+			p1 = to
+		}
+		b.cubeTo(Vec2Add(p0, tangent(t0)), Vec2Sub(p1, tangent(t1)), p1)
+	}
+}
+
+// parses path data into the builder; stops at the first error, keeping what
+// came before, like browsers do
+func (b *svgPathBuilder) parse(d string) {
+	var s = svgScanner{text: d}
+	var verb byte
+	for {
+		s.skipSeparators()
+		if s.done() {
+			return
+		}
+		if c := s.peek(); c >= 'A' && c <= 'z' && c != 'e' && c != 'E' {
+			verb = c
+			s.pos++
+		} else if verb == 0 {
+			return
+		}
+
+		var rel = verb >= 'a'
+		var origin Vec2
+		if rel {
+			origin = b.pen
+		}
+		pt := func() (Vec2, bool) {
+			x, ok1 := s.number()
+			y, ok2 := s.number()
+			return Vec2Add(origin, Vec2{x, y}), ok1 && ok2
+		}
+
+		switch verb {
+		case 'M', 'm':
+			p, ok := pt()
+			if !ok {
+				return
+			}
+			b.moveTo(p)
+			// more pairs are lines
+			if rel {
+				verb = 'l'
+			} else {
+				verb = 'L'
+			}
+		case 'L', 'l':
+			p, ok := pt()
+			if !ok {
+				return
+			}
+			b.lineTo(p)
+		case 'H', 'h':
+			x, ok := s.number()
+			if !ok {
+				return
+			}
+			b.lineTo(Vec2{origin[0] + x, b.pen[1]})
+		case 'V', 'v':
+			y, ok := s.number()
+			if !ok {
+				return
+			}
+			b.lineTo(Vec2{b.pen[0], origin[1] + y})
+		case 'C', 'c':
+			c1, ok1 := pt()
+			c2, ok2 := pt()
+			p, ok3 := pt()
+			if !ok1 || !ok2 || !ok3 {
+				return
+			}
+			b.cubeTo(c1, c2, p)
+		case 'S', 's':
+			var c1 = b.pen
+			if b.lastVerb == 'C' || b.lastVerb == 'S' {
+				c1 = Vec2Sub(Vec2Mul(b.pen, 2), b.ctrl)
+			}
+			c2, ok1 := pt()
+			p, ok2 := pt()
+			if !ok1 || !ok2 {
+				return
+			}
+			b.cubeTo(c1, c2, p)
+		case 'Q', 'q':
+			c, ok1 := pt()
+			p, ok2 := pt()
+			if !ok1 || !ok2 {
+				return
+			}
+			b.quadTo(c, p)
+		case 'T', 't':
+			var c = b.pen
+			if b.lastVerb == 'Q' || b.lastVerb == 'T' {
+				c = Vec2Sub(Vec2Mul(b.pen, 2), b.ctrl)
+			}
+			p, ok := pt()
+			if !ok {
+				return
+			}
+			b.quadTo(c, p)
+		case 'A', 'a':
+			rx, ok1 := s.number()
+			ry, ok2 := s.number()
+			rotation, ok3 := s.number()
+			large, ok4 := s.flag()
+			sweep, ok5 := s.flag()
+			p, ok6 := pt()
+			if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 {
+				return
+			}
+			b.arcTo(rx, ry, rotation, large, sweep, p)
+		case 'Z', 'z':
+			b.close()
+			// takes no numbers, so one after it is an error
+			b.lastVerb = 'Z'
+			verb = 0
+			continue
+		default:
+			return
+		}
+		// upper case, for S and T to know what came before
+		b.lastVerb = verb &^ 0x20
+	}
+}
+
+type svgScanner struct {
+	text string
+	pos  int
+}
+
+func (s *svgScanner) done() bool {
+	return s.pos >= len(s.text)
+}
+
+func (s *svgScanner) peek() byte {
+	return s.text[s.pos]
+}
+
+func (s *svgScanner) skipSeparators() {
+	for !s.done() {
+		switch s.peek() {
+		case ' ', '\t', '\n', '\r', ',':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// numbers can run into each other: "1.5.5-2" is 1.5, .5 and -2
+func (s *svgScanner) number() (f32, bool) {
+	s.skipSeparators()
+	var start = s.pos
+	if !s.done() && (s.peek() == '-' || s.peek() == '+') {
+		s.pos++
+	}
+	var digits, dot bool
+	for !s.done() {
+		var c = s.peek()
+		if c >= '0' && c <= '9' {
+			digits = true
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		s.pos++
+	}
+	if digits && !s.done() && (s.peek() == 'e' || s.peek() == 'E') {
+		var save = s.pos
+		s.pos++
+		if !s.done() && (s.peek() == '-' || s.peek() == '+') {
+			s.pos++
+		}
+		var exp bool
+		for !s.done() && s.peek() >= '0' && s.peek() <= '9' {
+			s.pos++
+			exp = true
+		}
+		if !exp {
+			s.pos = save
+		}
+	}
+	if !digits {
+		s.pos = start
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s.text[start:s.pos], 32)
+	return f32(n), err == nil
+}
+
+// arc flags are a single digit, and can be written without separators
+func (s *svgScanner) flag() (bool, bool) {
+	s.skipSeparators()
+	if s.done() || (s.peek() != '0' && s.peek() != '1') {
+		return false, false
+	}
+	s.pos++
+	return s.text[s.pos-1] == '1', true
+}
+
+// ---- values ----
+
+func parseSVGNumbers(text string) []f32 {
+	var s = svgScanner{text: text}
+	var nums []f32
+	for {
+		n, ok := s.number()
+		if !ok {
+			return nums
+		}
+		nums = append(nums, n)
+	}
+}
+
+// a length in pixels; percentages and font units aren't supported
+func parseSVGLength(text string) f32 {
+	var s = svgScanner{text: text}
+	n, ok := s.number()
+	if !ok {
+		return 0
+	}
+	switch strings.TrimSpace(text[s.pos:]) {
+	case "pt":
+		n *= 4.0 / 3
+	case "pc":
+		n *= 16
+	case "mm":
+		n *= 96 / 25.4
+	case "cm":
+		n *= 96 / 2.54
+	case "in":
+		n *= 96
+	}
+	return n
+}
+
+func parseSVGFraction(text string, fallback f32) f32 {
+	if text == "" {
+		return fallback
+	}
+	if strings.HasSuffix(text, "%") {
+		return max(0, min(1, parseSVGLength(strings.TrimSuffix(text, "%"))/100))
+	}
+	return max(0, min(1, parseSVGLength(text)))
+}
+
+func parseSVGPaint(text string, inherited svgPaint) svgPaint {
+	switch {
+	case text == "none" || text == "transparent":
+		return svgPaint{kind: svgPaintNone}
+	case text == "currentColor":
+		return svgPaint{kind: svgPaintCurrent}
+	case text == "inherit":
+		return inherited
+	case strings.HasPrefix(text, "url("):
+		var ref, _, _ = strings.Cut(text[4:], ")")
+		ref = strings.Trim(ref, " '\"")
+		return svgPaint{kind: svgPaintGradient, ref: strings.TrimPrefix(ref, "#")}
+	}
+	if c, ok := parseSVGColor(text); ok {
+		return svgPaint{kind: svgPaintColor, color: c}
+	}
+	return inherited
+}
+
+var svgNamedColors = map[string]uint32{
+	"black": 0x000000, "white": 0xffffff, "red": 0xff0000, "green": 0x008000,
+	"blue": 0x0000ff, "yellow": 0xffff00, "orange": 0xffa500, "purple": 0x800080,
+	"gray": 0x808080, "grey": 0x808080, "silver": 0xc0c0c0, "maroon": 0x800000,
+	"navy": 0x000080, "teal": 0x008080, "olive": 0x808000, "lime": 0x00ff00,
+	"aqua": 0x00ffff, "cyan": 0x00ffff, "fuchsia": 0xff00ff, "magenta": 0xff00ff,
+	"pink": 0xffc0cb, "brown": 0xa52a2a, "gold": 0xffd700, "darkgray": 0xa9a9a9,
+	"lightgray": 0xd3d3d3, "darkgrey": 0xa9a9a9, "lightgrey": 0xd3d3d3,
+}
+
+// #rgb, #rgba, #rrggbb, #rrggbbaa, rgb(), rgba() and the basic color names,
+// as hsla
+func parseSVGColor(text string) (Vec4, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	var r, g, b, a f32 = 0, 0, 0, 1
+	switch {
+	case strings.HasPrefix(text, "#"):
+		var hex = text[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			var long []byte
+			for i := range len(hex) {
+				long = append(long, hex[i], hex[i])
+			}
+			hex = string(long)
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 8 || err != nil {
+			return Vec4{}, false
+		}
+		r, g, b, a = f32(v>>24)/255, f32(v>>16&0xff)/255, f32(v>>8&0xff)/255, f32(v&0xff)/255
+	case strings.HasPrefix(text, "rgb"):
+		var open, close = strings.IndexByte(text, '('), strings.LastIndexByte(text, ')')
+		if open < 0 || close < open {
+			return Vec4{}, false
+		}
+		var parts = strings.FieldsFunc(text[open+1:close], func(c rune) bool {
+			return c == ',' || c == ' ' || c == '/'
+		})
+		if len(parts) < 3 {
+			return Vec4{}, false
+		}
+		channel := func(part string) f32 {
+			if strings.HasSuffix(part, "%") {
+				return parseSVGFraction(part, 0)
+			}
+			return max(0, min(1, parseSVGLength(part)/255))
+		}
+		r, g, b = channel(parts[0]), channel(parts[1]), channel(parts[2])
+		if len(parts) > 3 {
+			a = parseSVGFraction(parts[3], 1)
+		}
+	default:
+		v, ok := svgNamedColors[text]
+		if !ok {
+			return Vec4{}, false
+		}
+		r, g, b = f32(v>>16)/255, f32(v>>8&0xff)/255, f32(v&0xff)/255
+	}
+	var h, s, l = FloatRGBToHSL(r, g, b)
+	return Vec4{h * 360, s * 100, l * 100, a}, true
+}
+
+// a list of transforms, the last applied first
+func parseSVGTransform(text string) Affine {
+	var m = IdentityAffine
+	for {
+		var open = strings.IndexByte(text, '(')
+		var close = strings.IndexByte(text, ')')
+		if open < 0 || close < open {
+			return m
+		}
+		var name = strings.Trim(text[:open], " \t\n\r,")
+		var args = parseSVGNumbers(text[open+1 : close])
+		text = text[close+1:]
+		arg := func(i int, fallback f32) f32 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+
+		var t = IdentityAffine
+		switch name {
+		case "matrix":
+			if len(args) == 6 {
+				t = Affine{args[0], args[2], args[4], args[1], args[3], args[5]}
+			}
+		case "translate":
+			t = AffineTranslate(Vec2{arg(0, 0), arg(1, 0)})
+		case "scale":
+			t = AffineScale(Vec2{arg(0, 1), arg(1, arg(0, 1))})
+		case "rotate":
+			var pivot = Vec2{arg(1, 0), arg(2, 0)}
+			t = AffineTranslate(pivot).Mul(AffineRotate(arg(0, 0))).Mul(AffineTranslate(Vec2Mul(pivot, -1)))
+		case "skewX":
+			t = Affine{1, f32(math.Tan(float64(arg(0, 0)) * math.Pi / 180)), 0, 0, 1, 0}
+		case "skewY":
+			t = Affine{1, 0, 0, f32(math.Tan(float64(arg(0, 0)) * math.Pi / 180)), 1, 0}
+		}
+		m = m.Mul(t)
+	}
+}
@@ -0,0 +1,59 @@
+package shirei
+
+import (
+	"testing"
+
+	g "go.hasen.dev/generic"
+)
+
+func TestSVGPathStopsAtNumberAfterClose(t *testing.T) {
+	svg, err := ParseSVG([]byte(`<svg viewBox="0 0 10 10"><path d="M1 1 L5 5 Z 3"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svg.shapes) != 1 {
+		t.Fatalf("got %d shapes, want 1", len(svg.shapes))
+	}
+	var verbs []PathVerb
+	for _, cmd := range svg.shapes[0].cmds {
+		verbs = append(verbs, cmd.Verb)
+	}
+	var want = []PathVerb{PathMoveTo, PathLineTo, PathClose}
+	if len(verbs) != len(want) {
+		t.Fatalf("got verbs %v, want %v", verbs, want)
+	}
+	for i := range want {
+		if verbs[i] != want[i] {
+			t.Fatalf("got verbs %v, want %v", verbs, want)
+		}
+	}
+}
+
+// a square with a square hole: under non-zero, the hole must wind against
+// the outside to cancel it
+func TestSVGEvenOddHole(t *testing.T) {
+	svg, err := ParseSVG([]byte(`<svg viewBox="0 0 10 10"><path fill-rule="evenodd" d="M0 0H10V10H0Z M3 3H7V7H3Z"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svg.shapes[0].fillRule != FillEvenOdd {
+		t.Fatal("fill-rule not read")
+	}
+	g.ResetSlice(&pathCmds)
+	evenOddOutline(svg.shapes[0].cmds)
+
+	var areas []f32
+	var pts []Vec2
+	for _, cmd := range pathCmds {
+		switch cmd.Verb {
+		case PathMoveTo, PathLineTo:
+			pts = append(pts, cmd.Points[0])
+		case PathClose:
+			areas = append(areas, polygonArea(pts))
+			pts = nil
+		}
+	}
+	if len(areas) != 2 || (areas[0] > 0) == (areas[1] > 0) {
+		t.Fatalf("got areas %v, want two of opposite signs", areas)
+	}
+}
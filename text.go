@@ -54,7 +54,14 @@ func DefaultTextAttrs() TextAttrs {
 	}
 }
 
+// GlyphColorFn picks the color of a glyph by the index of its rune in the text
+type GlyphColorFn func(runeIndex int) Vec4
+
 func ShapedTextLineLayout(line *ShapedTextLine, attrs TextAttrs, baseDir Direction, selectionFrom int, selectionTo int, nextLinePaddingTop *f32) {
+	shapedTextLineLayout(line, attrs, baseDir, selectionFrom, selectionTo, nextLinePaddingTop, nil)
+}
+
+func shapedTextLineLayout(line *ShapedTextLine, attrs TextAttrs, baseDir Direction, selectionFrom int, selectionTo int, nextLinePaddingTop *f32, colorFn GlyphColorFn) {
 	// expand-across is necessary for the alignment to work
 	var lineAttrs Attrs
 	lineAttrs.Row = true
@@ -96,6 +103,9 @@ func ShapedTextLineLayout(line *ShapedTextLine, attrs TextAttrs, baseDir Directi
 				a.MinSize[0] = g.XAdvance
 				a.MinSize[1] = attrs.Size
 				a.Background = attrs.Color
+				if colorFn != nil {
+					a.Background = colorFn(int(g.Cluster))
+				}
 
 				Layout(a, func() {
 					current.fontId = g.FontId
@@ -108,6 +118,12 @@ func ShapedTextLineLayout(line *ShapedTextLine, attrs TextAttrs, baseDir Directi
 }
 
 func ShapedTextLayout(shaped ShapedText, attrs TextAttrs, selectionFrom int, selectionTo int) {
+	ShapedTextLayoutColored(shaped, attrs, selectionFrom, selectionTo, nil)
+}
+
+// ShapedTextLayoutColored is like ShapedTextLayout but the color of each glyph
+// is given by colorFn instead of attrs.Color
+func ShapedTextLayoutColored(shaped ShapedText, attrs TextAttrs, selectionFrom int, selectionTo int, colorFn GlyphColorFn) {
 	// defer profiler.Time("ShapedTextLayout")()
 
 	var blockAttrs Attrs
@@ -122,7 +138,7 @@ func ShapedTextLayout(shaped ShapedText, attrs TextAttrs, selectionFrom int, sel
 	Layout(blockAttrs, func() {
 		for idx := range shaped.Lines {
 			line := &shaped.Lines[idx]
-			ShapedTextLineLayout(line, attrs, shaped.BaseDir, selectionFrom, selectionTo, &nextLinePaddingTop)
+			shapedTextLineLayout(line, attrs, shaped.BaseDir, selectionFrom, selectionTo, &nextLinePaddingTop, colorFn)
 		}
 	})
 }
@@ -407,7 +423,8 @@ type ShapedTextLine struct {
 	Height   float32
 }
 
-var shapeCache = lru.New[uint64, ShapedText](lru.WithCapacity(100))
+// large enough to hold every visible line of a full screen code view
+var shapeCache = lru.New[uint64, ShapedText](lru.WithCapacity(500))
 
 func ShapeText(text string, attrs TextAttrs) ShapedText {
 	// defer profiler.Time("ShapeText")()
@@ -0,0 +1,95 @@
+package shirei
+
+import "math"
+
+// Transform turns, scales and moves a container and everything drawn in it,
+// hit-testing included, without affecting layout. The zero value is no
+// transform.
+type Transform struct {
+	Rotation  f32  // degrees, clockwise
+	Scale     Vec2 // 0 means 1 on that axis
+	Translate Vec2
+
+	// the point rotation and scaling are around, from the center as a
+	// fraction of the size: {-0.5, -0.5} is the top left corner
+	Pivot Vec2
+}
+
+// Affine maps points as x' = a*x + b*y + c, y' = d*x + e*y + f
+type Affine [6]f32
+
+var IdentityAffine = Affine{1, 0, 0, 0, 1, 0}
+
+func AffineTranslate(v Vec2) Affine {
+	return Affine{1, 0, v[0], 0, 1, v[1]}
+}
+
+func AffineScale(s Vec2) Affine {
+	return Affine{s[0], 0, 0, 0, s[1], 0}
+}
+
+// degrees, clockwise (y points down)
+func AffineRotate(degrees f32) Affine {
+	var sin, cos = math.Sincos(float64(degrees) * math.Pi / 180)
+	return Affine{f32(cos), -f32(sin), 0, f32(sin), f32(cos), 0}
+}
+
+func (m Affine) Apply(p Vec2) Vec2 {
+	return Vec2{
+		m[0]*p[0] + m[1]*p[1] + m[2],
+		m[3]*p[0] + m[4]*p[1] + m[5],
+	}
+}
+
+// Mul returns the transform that applies n first, then m
+func (m Affine) Mul(n Affine) Affine {
+	return Affine{
+		m[0]*n[0] + m[1]*n[3], m[0]*n[1] + m[1]*n[4], m[0]*n[2] + m[1]*n[5] + m[2],
+		m[3]*n[0] + m[4]*n[3], m[3]*n[1] + m[4]*n[4], m[3]*n[2] + m[4]*n[5] + m[5],
+	}
+}
+
+// Invert returns the reverse mapping; a degenerate transform (scaled to 0)
+// maps everything far away, so nothing hits it
+func (m Affine) Invert() Affine {
+	var det = m[0]*m[4] - m[1]*m[3]
+	if det == 0 {
+		return Affine{0, 0, f32(math.Inf(1)), 0, 0, f32(math.Inf(1))}
+	}
+	var a, b, d, e = m[4] / det, -m[1] / det, -m[3] / det, m[0] / det
+	return Affine{
+		a, b, -(a*m[2] + b*m[5]),
+		d, e, -(d*m[2] + e*m[5]),
+	}
+}
+
+func (t Transform) scale() Vec2 {
+	var s = t.Scale
+	if s[0] == 0 {
+		s[0] = 1
+	}
+	if s[1] == 0 {
+		s[1] = 1
+	}
+	return s
+}
+
+// the affine of the transform for a container at rect, in the same space as
+// the rect
+func (t Transform) affine(rect Rect) Affine {
+	if t.Rotation == 0 && t.scale() == (Vec2{1, 1}) {
+		return AffineTranslate(t.Translate)
+	}
+	var pivot = Vec2{
+		rect.Origin[0] + rect.Size[0]*(0.5+t.Pivot[0]),
+		rect.Origin[1] + rect.Size[1]*(0.5+t.Pivot[1]),
+	}
+	return AffineTranslate(Vec2Add(pivot, t.Translate)).
+		Mul(AffineRotate(t.Rotation)).
+		Mul(AffineScale(t.scale())).
+		Mul(AffineTranslate(Vec2Mul(pivot, -1)))
+}
+
+func (t Transform) isIdentity() bool {
+	return t.Rotation == 0 && t.scale() == (Vec2{1, 1}) && t.Translate == (Vec2{})
+}
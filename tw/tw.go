@@ -217,6 +217,53 @@ func Bo(h, s, l, a float32) AttrsFn {
 	}
 }
 
+// border widths per side, css order
+func BW4(t, r, b, l f32) AttrsFn {
+	return func(attrs *Attrs) {
+		attrs.BorderSides = Vec4{t, r, b, l}
+	}
+}
+
+// border colors per side, css order; a transparent one hides its side
+func Bo4(t, r, b, l Vec4) AttrsFn {
+	return func(attrs *Attrs) {
+		for side, c := range [4]Vec4{t, r, b, l} {
+			attrs.SetSideColor(side, c)
+		}
+	}
+}
+
+// the border color of one side, css order (0 is the top)
+func BoSide(side int, c Vec4) AttrsFn {
+	return func(attrs *Attrs) {
+		attrs.SetSideColor(side, c)
+	}
+}
+
+func Dashed(a *Attrs) {
+	a.BorderStyle = BorderDashed
+}
+
+func Dotted(a *Attrs) {
+	a.BorderStyle = BorderDotted
+}
+
+// dash and gap lengths for dashed borders
+func Dash(dash, gap f32) AttrsFn {
+	return func(attrs *Attrs) {
+		attrs.BorderDash = Vec2{dash, gap}
+	}
+}
+
+// the border within the edge instead of centered on it
+func BInside(a *Attrs) {
+	a.BorderAlign = StrokeInside
+}
+
+func BOutside(a *Attrs) {
+	a.BorderAlign = StrokeOutside
+}
+
 func BGV(v Vec4) AttrsFn {
 	return func(attrs *Attrs) {
 		attrs.Background = v
@@ -254,6 +301,62 @@ func Shd(r float32) AttrsFn {
 	}
 }
 
+func ShdClr(h, s, l, a float32) AttrsFn {
+	return func(attrs *Attrs) {
+		attrs.Shadow.Color = Vec4{h, s, l, a}
+	}
+}
+
+func ShdSpread(v float32) AttrsFn {
+	return func(a *Attrs) {
+		a.Shadow.Spread = v
+	}
+}
+
+// an inset shadow, for pressed buttons and wells
+func InShd(r float32) AttrsFn {
+	return func(a *Attrs) {
+		a.Shadow.Alpha = 0.4
+		a.Shadow.Blur = r
+		a.Shadow.Offset[1] = 1
+		a.Shadow.Inset = true
+	}
+}
+
+// adds a shadow layer: the first one goes in Shadow, the rest in Shadows
+func ShdV(s Shadow) AttrsFn {
+	return func(a *Attrs) {
+		a.AddShadow(s)
+	}
+}
+
+// gradient fills, drawn over the background; like FrameGradient, they are
+// only good in the frame they are made in
+
+func Stop(at, h, s, l, a float32) GradientStop {
+	return GradientStop{At: at, Color: Vec4{h, s, l, a}}
+}
+
+// angle like css: 0 to the top, 90 to the right, 180 to the bottom
+func LinearGrad(angle float32, stops ...GradientStop) AttrsFn {
+	return FillV(LinearGradient(angle, stops...))
+}
+
+func RadialGrad(stops ...GradientStop) AttrsFn {
+	return FillV(RadialGradient(stops...))
+}
+
+// angle is where it starts, clockwise from the top
+func ConicGrad(angle float32, stops ...GradientStop) AttrsFn {
+	return FillV(ConicGradient(angle, stops...))
+}
+
+func FillV(g Gradient) AttrsFn {
+	return func(a *Attrs) {
+		a.Fill = FrameGradient(g)
+	}
+}
+
 func Extrinsic(a *Attrs) {
 	a.ExtrinsicSize = true
 }
@@ -321,6 +424,40 @@ func ClickThrough(a *Attrs) {
 	a.ClickThrough = true
 }
 
+// transforms; they don't affect layout
+
+// degrees, clockwise
+func Rotate(deg float32) AttrsFn {
+	return func(a *Attrs) {
+		a.Rotation = deg
+	}
+}
+
+func Scale(s float32) AttrsFn {
+	return func(a *Attrs) {
+		a.Transform.Scale = Vec2{s, s}
+	}
+}
+
+func Scale2(x, y float32) AttrsFn {
+	return func(a *Attrs) {
+		a.Transform.Scale = Vec2{x, y}
+	}
+}
+
+func Translate(x, y float32) AttrsFn {
+	return func(a *Attrs) {
+		a.Translate = Vec2{x, y}
+	}
+}
+
+// what to rotate and scale around, from the center as a fraction of the size
+func Pivot(x, y float32) AttrsFn {
+	return func(a *Attrs) {
+		a.Pivot = Vec2{x, y}
+	}
+}
+
 // text
 
 type TextAttrsFn func(*TextAttrs)
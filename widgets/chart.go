@@ -0,0 +1,729 @@
+package widgets
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+// Charts keep what takes a pass over the values (their range, and the points
+// drawn at the current scale) from frame to frame, by the values' backing
+// array, its length and Version, so appending only looks at what's new.
+type ChartSeries struct {
+	Name   string
+	Values []f32
+	X      []f32 // ascending, one per value; when nil, values are at 0, 1, 2..
+	Color  Vec4  // one from ChartPalette when zero
+
+	// change it whenever values (or X) are changed in place, e.g. on each
+	// write to a ring buffer
+	Version uint64
+}
+
+type ChartAttrs struct {
+	Size Vec2
+
+	// the value range; it fits the data (on nice ticks) when YMax <= YMin
+	YMin f32
+	YMax f32
+
+	// for tick labels and the hover readout; plain numbers when nil
+	XFormat func(x f32) string
+	YFormat func(y f32) string
+
+	Categories []string // labels of the bar groups, for BarChart
+	NoLegend   bool
+}
+
+func DefaultChartAttrs() ChartAttrs {
+	return ChartAttrs{
+		Size: Vec2{400, 240},
+	}
+}
+
+var ChartPalette = []Vec4{
+	{210, 70, 50, 1},
+	{25, 85, 55, 1},
+	{140, 55, 40, 1},
+	{350, 70, 55, 1},
+	{270, 50, 55, 1},
+	{45, 90, 45, 1},
+	{185, 70, 40, 1},
+}
+
+func seriesColor(s ChartSeries, index int) Vec4 {
+	if s.Color != (Vec4{}) {
+		return s.Color
+	}
+	return ChartPalette[index%len(ChartPalette)]
+}
+
+func seriesX(s ChartSeries, i int) f32 {
+	if i < len(s.X) {
+		return s.X[i]
+	}
+	return f32(i)
+}
+
+// the index of the value nearest to x
+func seriesNearest(s ChartSeries, x f32) int {
+	var n = len(s.Values)
+	if n == 0 {
+		return -1
+	}
+	if s.X == nil {
+		return max(0, min(n-1, int(Roundf32(x))))
+	}
+	var i = sort.Search(n, func(i int) bool { return seriesX(s, i) >= x })
+	if i == n {
+		return n - 1
+	}
+	if i > 0 && x-seriesX(s, i-1) < seriesX(s, i)-x {
+		i--
+	}
+	return i
+}
+
+func isNaN(v f32) bool {
+	return v != v
+}
+
+// a step of 1, 2 or 5 times a power of ten that cuts span in about count parts
+func niceStep(span f32, count int) f32 {
+	var raw = float64(span) / float64(max(1, count))
+	var magnitude = math.Pow(10, math.Floor(math.Log10(raw)))
+	var step float64
+	switch norm := raw / magnitude; {
+	case norm <= 1.5:
+		step = 1
+	case norm <= 3:
+		step = 2
+	case norm <= 7:
+		step = 5
+	default:
+		step = 10
+	}
+	return f32(step * magnitude)
+}
+
+func ticksBetween(lo, hi, step f32) []f32 {
+	var ticks []f32
+	for i := math.Ceil(float64(lo/step) - 1e-4); i <= math.Floor(float64(hi/step)+1e-4) && len(ticks) < 100; i++ {
+		ticks = append(ticks, f32(i)*step)
+	}
+	return ticks
+}
+
+// enough decimals to tell ticks step apart
+func stepDecimals(step f32) int {
+	return max(0, int(math.Ceil(-math.Log10(float64(step))-1e-4)))
+}
+
+// at most four points per pixel column (the first, lowest, highest and last),
+// which draw the same line as all of them; x maps to pixels as x*scale+offset
+func decimateSeries(s ChartSeries, scale, offset f32, toPx func(x, y f32) Vec2, out []Vec2) []Vec2 {
+	var column = math.MinInt
+	var first, last, lo, hi int
+	var loValue, hiValue f32
+	flush := func() {
+		if column == math.MinInt {
+			return
+		}
+		var picks = [4]int{first, lo, hi, last}
+		if picks[1] > picks[2] {
+			picks[1], picks[2] = picks[2], picks[1]
+		}
+		for j, i := range picks {
+			if j == 0 || i != picks[j-1] {
+				out = append(out, toPx(seriesX(s, i), s.Values[i]))
+			}
+		}
+	}
+	var hasX = s.X != nil && len(s.X) >= len(s.Values)
+	for i, v := range s.Values {
+		if v != v {
+			continue // NaN
+		}
+		var x = f32(i)
+		if hasX {
+			x = s.X[i]
+		}
+		var c = int(math.Floor(float64(x*scale + offset)))
+		if c != column {
+			flush()
+			column = c
+			first, lo, hi = i, i, i
+			loValue, hiValue = v, v
+		}
+		last = i
+		if v < loValue {
+			lo, loValue = i, v
+		} else if v > hiValue {
+			hi, hiValue = i, v
+		}
+	}
+	flush()
+	return out
+}
+
+// like decimateSeries, for bars starting at x0 + i*step: bars sharing a pixel
+// column become one, from the lowest to the highest value among them
+func decimateBars(s ChartSeries, x0, step, width, base f32, toY func(v f32) f32, out []Rect) []Rect {
+	var column = math.MinInt
+	var left, right, top, bottom f32
+	flush := func() {
+		if column != math.MinInt {
+			out = append(out, Rect{Origin: Vec2{left, top}, Size: Vec2{right - left, bottom - top}})
+		}
+	}
+	for i, v := range s.Values {
+		if isNaN(v) {
+			continue
+		}
+		var x = x0 + f32(i)*step
+		var y = toY(v)
+		if c := int(math.Floor(float64(x))); c != column {
+			flush()
+			column = c
+			left, top, bottom = x, min(y, base), max(y, base)
+		} else {
+			top, bottom = min(top, y, base), max(bottom, y, base)
+		}
+		right = x + width
+	}
+	flush()
+	return out
+}
+
+// what a series was last drawn at; its points are drawn again while it's the
+// same
+type chartView struct {
+	xlo, xhi, ylo, yhi f32
+	plot               Rect
+	x                  *f32 // the X array, which the points depend on too
+	count, slot, slots int  // the bar's place in its group
+}
+
+type seriesCache struct {
+	n        int // values seen
+	version  uint64
+	lo, hi   f32
+	lastUsed int64
+
+	view   chartView
+	drawn  bool
+	points []Vec2 // decimated, for lines
+	bars   []Rect
+}
+
+var seriesCaches = make(map[*f32]*seriesCache)
+
+// swept of the series not drawn in the last two frames when it's this big
+const seriesCacheSize = 64
+
+func cachedSeries(values []f32, version uint64) *seriesCache {
+	if len(values) == 0 {
+		return &seriesCache{lo: f32(math.Inf(1)), hi: f32(math.Inf(-1))}
+	}
+	var key = &values[0]
+	c, ok := seriesCaches[key]
+	if !ok || c.n > len(values) || c.version != version {
+		if !ok && len(seriesCaches) >= seriesCacheSize {
+			for k, old := range seriesCaches {
+				if old.lastUsed < FrameNumber-1 {
+					delete(seriesCaches, k)
+				}
+			}
+		}
+		c = &seriesCache{version: version, lo: f32(math.Inf(1)), hi: f32(math.Inf(-1))}
+		seriesCaches[key] = c
+	}
+	if c.n < len(values) {
+		// NaN fails both
+		for _, v := range values[c.n:] {
+			if v < c.lo {
+				c.lo = v
+			}
+			if v > c.hi {
+				c.hi = v
+			}
+		}
+		c.n = len(values)
+		c.drawn = false
+	}
+	c.lastUsed = FrameNumber
+	return c
+}
+
+func seriesXPtr(s ChartSeries) *f32 {
+	if len(s.X) == 0 {
+		return nil
+	}
+	return &s.X[0]
+}
+
+type chartKind uint8
+
+const (
+	chartLine chartKind = iota
+	chartArea
+	chartBar
+)
+
+type chartState struct {
+	xLabels map[f32]string
+	yLabels map[f32]string
+	xStep   f32 // the labels were formatted for
+	yStep   f32
+
+	// the hover readout and the values it was formatted from
+	readout       []string
+	readoutValues []f32
+}
+
+const chartMarginLeft = 48
+const chartMarginRight = 14
+const chartMarginTop = 10
+const chartMarginBottom = 22
+
+// LineChart plots series as lines, with auto-scaled axes, a legend, and a
+// readout of the values under the mouse. Long series are decimated to the
+// pixels they cover.
+func LineChart(series []ChartSeries) {
+	chartExt(chartLine, series, DefaultChartAttrs())
+}
+
+func LineChartExt(series []ChartSeries, attrs ChartAttrs) {
+	chartExt(chartLine, series, attrs)
+}
+
+// AreaChart is a LineChart filled down to zero
+func AreaChart(series []ChartSeries) {
+	chartExt(chartArea, series, DefaultChartAttrs())
+}
+
+func AreaChartExt(series []ChartSeries, attrs ChartAttrs) {
+	chartExt(chartArea, series, attrs)
+}
+
+// BarChart groups the nth values of all series side by side; their X is
+// ignored, and attrs.Categories labels the groups
+func BarChart(series []ChartSeries) {
+	chartExt(chartBar, series, DefaultChartAttrs())
+}
+
+func BarChartExt(series []ChartSeries, attrs ChartAttrs) {
+	chartExt(chartBar, series, attrs)
+}
+
+func chartExt(kind chartKind, series []ChartSeries, attrs ChartAttrs) {
+	if attrs.Size == (Vec2{}) {
+		attrs.Size = DefaultChartAttrs().Size
+	}
+
+	// the data ranges
+	var count int
+	var xlo, xhi = f32(math.Inf(1)), f32(math.Inf(-1))
+	var ylo, yhi = xlo, xhi
+	var caches = make([]*seriesCache, len(series))
+	for i, s := range series {
+		count = max(count, len(s.Values))
+		caches[i] = cachedSeries(s.Values, s.Version)
+		ylo, yhi = min(ylo, caches[i].lo), max(yhi, caches[i].hi)
+		if len(s.Values) > 0 {
+			xlo = min(xlo, seriesX(s, 0))
+			xhi = max(xhi, seriesX(s, len(s.Values)-1))
+		}
+	}
+	if ylo > yhi {
+		ylo, yhi = 0, 1
+	}
+	if kind != chartLine {
+		ylo, yhi = min(ylo, 0), max(yhi, 0)
+	}
+	if ylo == yhi {
+		ylo, yhi = ylo-1, yhi+1
+	}
+	if kind == chartBar || xlo >= xhi {
+		xlo, xhi = 0, f32(max(1, count))
+	}
+
+	var plot = Rect{
+		Origin: Vec2{chartMarginLeft, chartMarginTop},
+		Size:   Vec2{attrs.Size[0] - chartMarginLeft - chartMarginRight, attrs.Size[1] - chartMarginTop - chartMarginBottom},
+	}
+	if plot.Size[0] <= 0 || plot.Size[1] <= 0 {
+		return
+	}
+
+	var yStep f32
+	if attrs.YMax > attrs.YMin {
+		ylo, yhi = attrs.YMin, attrs.YMax
+		yStep = niceStep(yhi-ylo, int(plot.Size[1]/40))
+	} else {
+		yStep = niceStep(yhi-ylo, int(plot.Size[1]/40))
+		ylo = f32(math.Floor(float64(ylo/yStep))) * yStep
+		yhi = f32(math.Ceil(float64(yhi/yStep))) * yStep
+	}
+	var xStep = niceStep(xhi-xlo, int(plot.Size[0]/80))
+
+	toPx := func(x, y f32) Vec2 {
+		return Vec2{
+			plot.Origin[0] + (x-xlo)/(xhi-xlo)*plot.Size[0],
+			plot.Origin[1] + (1-(y-ylo)/(yhi-ylo))*plot.Size[1],
+		}
+	}
+	var groupWidth = plot.Size[0] / f32(max(1, count))
+
+	Layout(TW(Gap(6)), func() {
+		var state = Use[chartState]("chart")
+		if state.xLabels == nil || len(state.xLabels) > 200 || state.xStep != xStep {
+			state.xLabels = make(map[f32]string)
+			state.xStep = xStep
+		}
+		if state.yLabels == nil || len(state.yLabels) > 200 || state.yStep != yStep {
+			state.yLabels = make(map[f32]string)
+			state.yStep = yStep
+		}
+		tickLabel := func(labels map[f32]string, v f32, step f32, format func(f32) string) string {
+			text, ok := labels[v]
+			if !ok {
+				if format != nil {
+					text = format(v)
+				} else {
+					text = strconv.FormatFloat(float64(v), 'f', stepDecimals(step), 32)
+				}
+				labels[v] = text
+			}
+			return text
+		}
+
+		Layout(TW(FixSizeV(attrs.Size)), func() {
+			var rect = GetResolvedRectOf(CurrentId())
+			var mouse = Vec2Sub(InputState.MousePoint, rect.Origin)
+
+			// what's under the mouse: a value index of each series
+			var hovered bool
+			var hoverX f32
+			var hoverIndex = make([]int, len(series))
+			if IsHovered() && count > 0 && mouse[0] >= plot.Origin[0] && mouse[0] <= plot.Origin[0]+plot.Size[0] {
+				hovered = true
+				if kind == chartBar {
+					var group = max(0, min(count-1, int((mouse[0]-plot.Origin[0])/groupWidth)))
+					hoverX = f32(group)
+					for i := range series {
+						hoverIndex[i] = group
+					}
+				} else {
+					hoverX = xlo + (mouse[0]-plot.Origin[0])/plot.Size[0]*(xhi-xlo)
+					// snap to the nearest point of the longest series
+					for _, s := range series {
+						if len(s.Values) == count {
+							hoverX = seriesX(s, seriesNearest(s, hoverX))
+							break
+						}
+					}
+					for i, s := range series {
+						hoverIndex[i] = seriesNearest(s, hoverX)
+					}
+				}
+			}
+
+			var yTicks = ticksBetween(ylo, yhi, yStep)
+			Draw(func(p *Path) {
+				for _, y := range yTicks {
+					var py = Roundf32(toPx(0, y)[1]) + 0.5
+					p.MoveTo(Vec2{plot.Origin[0], py})
+					p.LineTo(Vec2{plot.Origin[0] + plot.Size[0], py})
+				}
+				p.Stroke(Vec4{0, 0, 0, 0.08}, StrokeStyle{Width: 1})
+
+				if hovered && kind == chartBar {
+					p.Begin()
+					p.Rect(Rect{Origin: Vec2{plot.Origin[0] + hoverX*groupWidth, plot.Origin[1]}, Size: Vec2{groupWidth, plot.Size[1]}})
+					p.Fill(Vec4{0, 0, 0, 0.05})
+				}
+
+				var base = toPx(0, max(ylo, min(yhi, 0)))[1]
+				for si, s := range series {
+					var color = seriesColor(s, si)
+					var cache = caches[si]
+					var view = chartView{xlo: xlo, xhi: xhi, ylo: ylo, yhi: yhi, plot: plot, x: seriesXPtr(s)}
+					switch kind {
+					case chartBar:
+						view.count, view.slot, view.slots = count, si, len(series)
+						if !cache.drawn || cache.view != view {
+							// bars of a group share 80% of its width
+							var barWidth = groupWidth * 0.8 / f32(len(series))
+							var x0 = plot.Origin[0] + groupWidth*0.1 + f32(si)*barWidth
+							toY := func(v f32) f32 { return toPx(0, v)[1] }
+							cache.bars = decimateBars(s, x0, groupWidth, max(1, barWidth-1), base, toY, cache.bars[:0])
+							cache.view, cache.drawn = view, true
+						}
+						p.Begin()
+						for _, bar := range cache.bars {
+							p.Rect(bar)
+						}
+						p.Fill(color)
+					default:
+						if !cache.drawn || cache.view != view {
+							cache.points = decimateSeries(s, plot.Size[0]/(xhi-xlo), plot.Origin[0]-xlo*plot.Size[0]/(xhi-xlo), toPx, cache.points[:0])
+							cache.view, cache.drawn = view, true
+						}
+						var points = cache.points
+						if len(points) == 0 {
+							continue
+						}
+						if kind == chartArea {
+							p.Begin()
+							p.MoveTo(Vec2{points[0][0], base})
+							for _, pt := range points {
+								p.LineTo(pt)
+							}
+							p.LineTo(Vec2{points[len(points)-1][0], base})
+							p.Close()
+							var fill = color
+							fill[ALPHA] *= 0.25
+							p.Fill(fill)
+						}
+						p.Begin()
+						for i, pt := range points {
+							if i == 0 {
+								p.MoveTo(pt)
+							} else {
+								p.LineTo(pt)
+							}
+						}
+						p.Stroke(color, StrokeStyle{Width: 2, Join: JoinBevel})
+					}
+				}
+
+				// axes
+				p.Begin()
+				p.MoveTo(Vec2{plot.Origin[0] + 0.5, plot.Origin[1]})
+				p.LineTo(Vec2{plot.Origin[0] + 0.5, plot.Origin[1] + plot.Size[1] + 0.5})
+				p.LineTo(Vec2{plot.Origin[0] + plot.Size[0], plot.Origin[1] + plot.Size[1] + 0.5})
+				p.Stroke(Vec4{0, 0, 0, 0.35}, StrokeStyle{Width: 1})
+
+				if !hovered || kind == chartBar {
+					return
+				}
+				// crosshair, with a dot on each series
+				var px = toPx(hoverX, 0)[0]
+				p.Begin()
+				p.MoveTo(Vec2{px, plot.Origin[1]})
+				p.LineTo(Vec2{px, plot.Origin[1] + plot.Size[1]})
+				p.Stroke(Vec4{0, 0, 0, 0.4}, StrokeStyle{Width: 1, Dashes: []f32{3, 3}})
+				for si, s := range series {
+					var i = hoverIndex[si]
+					if i < 0 || isNaN(s.Values[i]) {
+						continue
+					}
+					p.Begin()
+					p.Circle(toPx(seriesX(s, i), s.Values[i]), 4)
+					p.Fill(seriesColor(s, si))
+					p.Stroke(Vec4{0, 0, 100, 1}, StrokeStyle{Width: 1.5})
+				}
+			})
+
+			// tick labels, placed by their size from the last frame
+			for _, y := range yTicks {
+				var text = tickLabel(state.yLabels, y, yStep, attrs.YFormat)
+				Layout(TW(ClickThrough, NoAnimate), func() {
+					var size = GetResolvedSize()
+					ModAttrs(Float(plot.Origin[0]-6-size[0], toPx(0, y)[1]-size[1]/2))
+					Label(text, Sz(10), Clr(0, 0, 35, 1))
+				})
+			}
+			var labelY = plot.Origin[1] + plot.Size[1] + 4
+			if kind == chartBar {
+				// every few groups when they're narrow
+				var every = max(1, int(math.Ceil(float64(40/groupWidth))))
+				for i := 0; i < count; i += every {
+					var text string
+					if i < len(attrs.Categories) {
+						text = attrs.Categories[i]
+					} else {
+						text = tickLabel(state.xLabels, f32(i+1), 1, attrs.XFormat)
+					}
+					var center = plot.Origin[0] + (f32(i)+0.5)*groupWidth
+					Layout(TW(ClickThrough, NoAnimate), func() {
+						var size = GetResolvedSize()
+						ModAttrs(Float(center-size[0]/2, labelY))
+						Label(text, Sz(10), Clr(0, 0, 35, 1))
+					})
+				}
+			} else {
+				for _, x := range ticksBetween(xlo, xhi, xStep) {
+					var text = tickLabel(state.xLabels, x, xStep, attrs.XFormat)
+					Layout(TW(ClickThrough, NoAnimate), func() {
+						var size = GetResolvedSize()
+						var left = toPx(x, 0)[0] - size[0]/2
+						ModAttrs(Float(max(0, min(left, attrs.Size[0]-size[0])), labelY))
+						Label(text, Sz(10), Clr(0, 0, 35, 1))
+					})
+				}
+			}
+
+			if hovered {
+				chartReadout(state, kind, series, attrs, hoverX, hoverIndex, mouse, plot)
+			}
+		})
+
+		if attrs.NoLegend || len(series) == 0 || len(series) == 1 && series[0].Name == "" {
+			return
+		}
+		Layout(TW(Row, Wrap, CrossMid, Gap(12), MaxWidth(attrs.Size[0]), Pad2(0, chartMarginLeft)), func() {
+			for i, s := range series {
+				Layout(TW(Row, CrossMid, Gap(4)), func() {
+					Element(TW(FixSize(10, 10), BR(2), BGV(seriesColor(s, i))))
+					Label(s.Name, Sz(11), Clr(0, 0, 25, 1))
+				})
+			}
+		})
+	})
+}
+
+// the panel by the mouse with the hovered values
+func chartReadout(state *chartState, kind chartKind, series []ChartSeries, attrs ChartAttrs, hoverX f32, hoverIndex []int, mouse Vec2, plot Rect) {
+	// only formatted again when the values change
+	var values = append(state.readoutValues[:0:0], hoverX)
+	for si, s := range series {
+		if i := hoverIndex[si]; i >= 0 && i < len(s.Values) {
+			values = append(values, s.Values[i])
+		} else {
+			values = append(values, f32(math.NaN()))
+		}
+	}
+	var same = len(values) == len(state.readoutValues) && len(state.readout) == len(values)
+	for i := range values {
+		if !same {
+			break
+		}
+		same = values[i] == state.readoutValues[i] || isNaN(values[i]) && isNaN(state.readoutValues[i])
+	}
+	if !same {
+		state.readoutValues = values
+		state.readout = state.readout[:0]
+		var title string
+		switch {
+		case kind == chartBar && int(hoverX) < len(attrs.Categories):
+			title = attrs.Categories[int(hoverX)]
+		case kind == chartBar:
+			title = strconv.Itoa(int(hoverX) + 1)
+		case attrs.XFormat != nil:
+			title = attrs.XFormat(hoverX)
+		default:
+			title = strconv.FormatFloat(float64(hoverX), 'g', 6, 32)
+		}
+		state.readout = append(state.readout, title)
+		for si, s := range series {
+			var v = values[si+1]
+			var text string
+			switch {
+			case isNaN(v):
+				text = "-"
+			case attrs.YFormat != nil:
+				text = attrs.YFormat(v)
+			default:
+				text = strconv.FormatFloat(float64(v), 'g', 6, 32)
+			}
+			if s.Name != "" {
+				text = s.Name + ": " + text
+			}
+			state.readout = append(state.readout, text)
+		}
+	}
+
+	Layout(TW(ClickThrough, NoAnimate, Pad(6), Gap(2), BR(4), BG(0, 0, 100, 0.95), BW(1), Bo(0, 0, 0, 0.2), Shd(2)), func() {
+		// on the right of the mouse, unless there's no room
+		var size = GetResolvedSize()
+		var x = mouse[0] + 12
+		if x+size[0] > plot.Origin[0]+plot.Size[0] {
+			x = mouse[0] - 12 - size[0]
+		}
+		ModAttrs(Float(x, plot.Origin[1]))
+		Label(state.readout[0], Sz(11), FontWeight(WeightBold), Clr(0, 0, 20, 1))
+		for si, s := range series {
+			Layout(TW(Row, CrossMid, Gap(4)), func() {
+				Element(TW(FixSize(8, 8), BR(4), BGV(seriesColor(s, si))))
+				Label(state.readout[si+1], Sz(11), Clr(0, 0, 20, 1))
+			})
+		}
+	})
+}
+
+type SparklineAttrs struct {
+	Size  Vec2
+	Color Vec4
+	Fill  bool // shade the area under the line
+
+	Version uint64 // like ChartSeries.Version
+}
+
+func DefaultSparklineAttrs() SparklineAttrs {
+	return SparklineAttrs{
+		Size:  Vec2{80, 20},
+		Color: Vec4{210, 70, 50, 1},
+	}
+}
+
+// Sparkline is a small inline line of the values, scaled to fit, with a dot on
+// the last one
+func Sparkline(values []f32) {
+	SparklineExt(values, DefaultSparklineAttrs())
+}
+
+func SparklineExt(values []f32, attrs SparklineAttrs) {
+	var cache = cachedSeries(values, attrs.Version)
+	var lo, hi = cache.lo, cache.hi
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	const inset = 2 // room for the dot
+	var size = attrs.Size
+	var n = f32(max(1, len(values)-1))
+	toPx := func(x, y f32) Vec2 {
+		return Vec2{inset + x/n*(size[0]-inset*2), inset + (1-(y-lo)/(hi-lo))*(size[1]-inset*2)}
+	}
+	Canvas(size, func(p *Path) {
+		if lo > hi {
+			return
+		}
+		var view = chartView{ylo: lo, yhi: hi, plot: Rect{Size: size}}
+		if !cache.drawn || cache.view != view {
+			cache.points = decimateSeries(ChartSeries{Values: values}, (size[0]-inset*2)/n, inset, toPx, cache.points[:0])
+			cache.view, cache.drawn = view, true
+		}
+		var points = cache.points
+		if len(points) == 0 {
+			return
+		}
+		if attrs.Fill {
+			p.MoveTo(Vec2{points[0][0], size[1]})
+			for _, pt := range points {
+				p.LineTo(pt)
+			}
+			p.LineTo(Vec2{points[len(points)-1][0], size[1]})
+			p.Close()
+			var fill = attrs.Color
+			fill[ALPHA] *= 0.2
+			p.Fill(fill)
+			p.Begin()
+		}
+		for i, pt := range points {
+			if i == 0 {
+				p.MoveTo(pt)
+			} else {
+				p.LineTo(pt)
+			}
+		}
+		p.Stroke(attrs.Color, StrokeStyle{Width: 1.5, Join: JoinBevel})
+		p.Begin()
+		p.Circle(points[len(points)-1], 2)
+		p.Fill(attrs.Color)
+	})
+}
@@ -0,0 +1,152 @@
+package widgets
+
+import (
+	"math"
+	"testing"
+
+	. "go.hasen.dev/shirei"
+)
+
+func TestNiceStep(t *testing.T) {
+	var cases = []struct {
+		span  f32
+		count int
+		want  f32
+	}{
+		{10, 10, 1},
+		{10, 5, 2},
+		{100, 4, 20},
+		{100, 2, 50},
+		{1, 4, 0.2},
+		{0.3, 3, 0.1},
+		{7, 1, 5},
+		{9, 1, 10},
+		{10, 0, 10}, // count is at least 1
+	}
+	for _, c := range cases {
+		var got = niceStep(c.span, c.count)
+		if math.Abs(float64(got-c.want)) > 1e-6*float64(c.want) {
+			t.Errorf("niceStep(%v, %d) = %v, want %v", c.span, c.count, got, c.want)
+		}
+	}
+}
+
+func identityPx(x, y f32) Vec2 {
+	return Vec2{x, y}
+}
+
+func TestDecimateSeries(t *testing.T) {
+	var nan = f32(math.NaN())
+	var cases = []struct {
+		name   string
+		values []f32
+		scale  f32
+		want   []Vec2
+	}{
+		{"one per column keeps all", []f32{3, 1, 2}, 1, []Vec2{{0, 3}, {1, 1}, {2, 2}}},
+		{"first, lowest, highest, last", []f32{5, 9, 1, 4, 6}, 0.1, []Vec2{{0, 5}, {1, 9}, {2, 1}, {4, 6}}},
+		{"lowest before highest", []f32{5, 1, 9, 6}, 0.1, []Vec2{{0, 5}, {1, 1}, {2, 9}, {3, 6}}},
+		{"no repeats", []f32{2, 2, 2}, 0.1, []Vec2{{0, 2}, {2, 2}}},
+		{"skips NaN", []f32{1, nan, 3}, 1, []Vec2{{0, 1}, {2, 3}}},
+		{"empty", nil, 1, nil},
+	}
+	for _, c := range cases {
+		var got = decimateSeries(ChartSeries{Values: c.values}, c.scale, 0, identityPx, nil)
+		if !equalPoints(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// a million points fit in at most four per column
+	var million = make([]f32, 1_000_000)
+	for i := range million {
+		million[i] = f32(math.Sin(float64(i) / 1000))
+	}
+	var got = decimateSeries(ChartSeries{Values: million}, 400.0/1_000_000, 0, identityPx, nil)
+	if len(got) > 400*4 {
+		t.Errorf("a million points over 400 columns gave %d points", len(got))
+	}
+}
+
+func TestDecimateSeriesX(t *testing.T) {
+	var s = ChartSeries{Values: []f32{1, 2, 3}, X: []f32{0, 0.5, 10}}
+	var got = decimateSeries(s, 1, 0, identityPx, nil)
+	var want = []Vec2{{0, 1}, {0.5, 2}, {10, 3}}
+	if !equalPoints(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecimateBars(t *testing.T) {
+	// y grows down from the base at 100
+	toY := func(v f32) f32 { return 100 - v }
+	var cases = []struct {
+		name   string
+		values []f32
+		step   f32
+		want   []Rect
+	}{
+		{"wide bars stay apart", []f32{10, 20}, 4, []Rect{
+			{Origin: Vec2{0, 90}, Size: Vec2{2, 10}},
+			{Origin: Vec2{4, 80}, Size: Vec2{2, 20}},
+		}},
+		{"thin bars merge per column", []f32{10, 30, 20, 5}, 0.5, []Rect{
+			{Origin: Vec2{0, 70}, Size: Vec2{2.5, 30}},
+			{Origin: Vec2{1, 80}, Size: Vec2{2.5, 20}},
+		}},
+		{"negative values hang from the base", []f32{-10, 10}, 0.5, []Rect{
+			{Origin: Vec2{0, 90}, Size: Vec2{2.5, 20}},
+		}},
+		{"skips NaN", []f32{f32(math.NaN()), 10}, 4, []Rect{
+			{Origin: Vec2{4, 90}, Size: Vec2{2, 10}},
+		}},
+	}
+	for _, c := range cases {
+		var got = decimateBars(ChartSeries{Values: c.values}, 0, c.step, 2, 100, toY, nil)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestCachedSeries(t *testing.T) {
+	var values = make([]f32, 3, 8)
+	copy(values, []f32{4, 1, 7})
+	var c = cachedSeries(values, 0)
+	if c.lo != 1 || c.hi != 7 {
+		t.Fatalf("range %v..%v, want 1..7", c.lo, c.hi)
+	}
+
+	// appending in place only scans what's new
+	values = append(values, -2)
+	c = cachedSeries(values, 0)
+	if c.lo != -2 || c.hi != 7 {
+		t.Fatalf("after append: range %v..%v, want -2..7", c.lo, c.hi)
+	}
+
+	// an edit in place with a new version is seen
+	values[2] = 3
+	c = cachedSeries(values, 1)
+	if c.lo != -2 || c.hi != 4 {
+		t.Fatalf("after edit: range %v..%v, want -2..4", c.lo, c.hi)
+	}
+}
+
+func equalPoints(a, b []Vec2) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
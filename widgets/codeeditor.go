@@ -0,0 +1,511 @@
+package widgets
+
+import (
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	g "go.hasen.dev/generic"
+	"go.hasen.dev/shirei"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+// a position in a code document; Col counts runes
+type CodePos struct {
+	Line int
+	Col  int
+}
+
+func (a CodePos) Before(b CodePos) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Col < b.Col)
+}
+
+// CodeDocument is the text edited by a CodeEditor.
+//
+// The text is kept as a list of lines, and editing only replaces the lines it
+// touches; the shaping cache is keyed by string headers, so unchanged lines
+// don't need to be shaped again.
+type CodeDocument struct {
+	Lines []string
+
+	Cursor CodePos
+	Anchor CodePos // the other end of the selection
+
+	tokenizer Tokenizer
+
+	// the tokenizer state at the start of each line; only the first
+	// len(states) lines are known, the rest are computed on demand
+	states []int
+
+	goalCol    int // column to aim for when moving up and down; -1 when unset
+	blinkStart time.Time
+	reveal     bool // scroll to the cursor on the next frame
+}
+
+func NewCodeDocument(text string, tokenizer Tokenizer) *CodeDocument {
+	var d = new(CodeDocument)
+	d.tokenizer = tokenizer
+	d.SetText(text)
+	return d
+}
+
+func (d *CodeDocument) SetText(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	d.Lines = strings.Split(text, "\n")
+	d.Cursor = CodePos{}
+	d.Anchor = CodePos{}
+	d.goalCol = -1
+	d.invalidate(0)
+}
+
+func (d *CodeDocument) Text() string {
+	return strings.Join(d.Lines, "\n")
+}
+
+func (d *CodeDocument) SetTokenizer(tokenizer Tokenizer) {
+	d.tokenizer = tokenizer
+	d.invalidate(0)
+}
+
+// Selection returns the selected range, ordered
+func (d *CodeDocument) Selection() (from CodePos, to CodePos) {
+	from, to = d.Anchor, d.Cursor
+	if to.Before(from) {
+		from, to = to, from
+	}
+	return d.clamp(from), d.clamp(to)
+}
+
+func (d *CodeDocument) HasSelection() bool {
+	from, to := d.Selection()
+	return from != to
+}
+
+func (d *CodeDocument) SelectedText() string {
+	from, to := d.Selection()
+	if from.Line == to.Line {
+		return string([]rune(d.Lines[from.Line])[from.Col:to.Col])
+	}
+	var sb strings.Builder
+	sb.WriteString(string([]rune(d.Lines[from.Line])[from.Col:]))
+	for line := from.Line + 1; line < to.Line; line++ {
+		sb.WriteByte('\n')
+		sb.WriteString(d.Lines[line])
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(string([]rune(d.Lines[to.Line])[:to.Col]))
+	return sb.String()
+}
+
+// SetCursor moves the cursor, extending the selection if `extend` is set, and
+// scrolls it into view
+func (d *CodeDocument) SetCursor(pos CodePos, extend bool) {
+	d.Cursor = d.clamp(pos)
+	if !extend {
+		d.Anchor = d.Cursor
+	}
+	d.blinkStart = time.Now()
+	d.reveal = true
+}
+
+// InsertText replaces the selection with text
+func (d *CodeDocument) InsertText(text string) {
+	d.DeleteSelection()
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	d.SetCursor(d.insertAt(d.Cursor, text), false)
+	d.goalCol = -1
+}
+
+func (d *CodeDocument) DeleteSelection() {
+	from, to := d.Selection()
+	if from != to {
+		d.deleteRange(from, to)
+		d.SetCursor(from, false)
+		d.goalCol = -1
+	}
+}
+
+func (d *CodeDocument) lineLen(line int) int {
+	return utf8.RuneCountInString(d.Lines[line])
+}
+
+func (d *CodeDocument) clamp(p CodePos) CodePos {
+	if len(d.Lines) == 0 {
+		d.Lines = []string{""}
+	}
+	p.Line = max(0, min(p.Line, len(d.Lines)-1))
+	p.Col = max(0, min(p.Col, d.lineLen(p.Line)))
+	return p
+}
+
+// the lines after `line` need to be tokenized again
+func (d *CodeDocument) invalidate(line int) {
+	if len(d.states) > line+1 {
+		d.states = d.states[:line+1]
+	}
+}
+
+// the tokenizer state at the start of the given line
+func (d *CodeDocument) stateAt(line int) int {
+	if len(d.states) == 0 {
+		g.Append(&d.states, 0)
+	}
+	for len(d.states) <= line {
+		var prev = len(d.states) - 1
+		_, state := d.tokenizer.Tokenize([]rune(d.Lines[prev]), d.states[prev], nil)
+		g.Append(&d.states, state)
+	}
+	return d.states[line]
+}
+
+func (d *CodeDocument) deleteRange(from CodePos, to CodePos) {
+	var head = []rune(d.Lines[from.Line])[:from.Col]
+	var tail = []rune(d.Lines[to.Line])[to.Col:]
+	d.Lines[from.Line] = string(head) + string(tail)
+	d.Lines = slices.Delete(d.Lines, from.Line+1, to.Line+1)
+	d.invalidate(from.Line)
+}
+
+// returns the position at the end of the inserted text
+func (d *CodeDocument) insertAt(p CodePos, text string) CodePos {
+	var runes = []rune(d.Lines[p.Line])
+	var head = string(runes[:p.Col])
+	var tail = string(runes[p.Col:])
+
+	var parts = strings.Split(text, "\n")
+	var last = len(parts) - 1
+	var end = CodePos{Line: p.Line + last, Col: utf8.RuneCountInString(parts[last])}
+	if last == 0 {
+		end.Col += p.Col
+	}
+
+	parts[0] = head + parts[0]
+	parts[last] = parts[last] + tail
+	d.Lines[p.Line] = parts[0]
+	d.Lines = slices.Insert(d.Lines, p.Line+1, parts[1:]...)
+	d.invalidate(p.Line)
+	return end
+}
+
+// the position one rune before or after p, crossing line boundaries
+func (d *CodeDocument) step(p CodePos, dir int) CodePos {
+	p.Col += dir
+	if p.Col < 0 && p.Line > 0 {
+		p.Line--
+		p.Col = d.lineLen(p.Line)
+	} else if p.Col > d.lineLen(p.Line) && p.Line < len(d.Lines)-1 {
+		p.Line++
+		p.Col = 0
+	}
+	return d.clamp(p)
+}
+
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeftFunc(line, unicode.IsSpace))]
+}
+
+type CodeEditorAttrs struct {
+	FontSize f32
+	ReadOnly bool
+	Theme    CodeTheme
+
+	// extra gutter column drawn before the line numbers (markers, breakpoints, ...)
+	GutterWidth f32
+	GutterFn    func(line int)
+}
+
+func DefaultCodeEditorAttrs() CodeEditorAttrs {
+	return CodeEditorAttrs{
+		FontSize: 13,
+		Theme:    DefaultCodeTheme(),
+	}
+}
+
+func CodeEditor(doc *CodeDocument) {
+	CodeEditorExt(doc, DefaultCodeEditorAttrs())
+}
+
+// line number labels are kept around so their string headers (which key the
+// shaping cache) stay the same from frame to frame
+var lineNumberLabels []string
+
+func lineNumberLabel(n int) string {
+	for len(lineNumberLabels) <= n {
+		g.Append(&lineNumberLabels, strconv.Itoa(len(lineNumberLabels)))
+	}
+	return lineNumberLabels[n]
+}
+
+func CodeEditorExt(doc *CodeDocument, attrs CodeEditorAttrs) {
+	if doc.tokenizer == nil {
+		doc.tokenizer = PlainTokenizer{}
+	}
+	doc.Cursor = doc.clamp(doc.Cursor)
+	doc.Anchor = doc.clamp(doc.Anchor)
+
+	var textAttrs = DefaultTextAttrs()
+	textAttrs.Families = Monospace
+	textAttrs.Size = attrs.FontSize
+	textAttrs.Color = attrs.Theme[TokenText]
+
+	var numberAttrs = textAttrs
+	numberAttrs.Color = Vec4{0, 0, 60, 1}
+
+	var lineHeight = f32(int(attrs.FontSize * 1.5))
+	var vpad = (lineHeight - attrs.FontSize) / 2
+
+	var charWidth = attrs.FontSize * 0.6
+	if zero := ShapeText("0", numberAttrs); len(zero.Lines) > 0 {
+		charWidth = zero.Lines[0].Width
+	}
+	var digits = max(2, len(strconv.Itoa(len(doc.Lines))))
+	var numbersWidth = f32(digits)*charWidth + 16
+
+	Layout(TW(Viewport, Focusable, BG(0, 0, 99, 1), BW(1), Bo(0, 0, 70, 1)), func() {
+		FocusOnClick()
+		PressAction()
+
+		var focused = HasFocus()
+		var dragging = IsActive()
+		var pageSize = max(1, int(GetResolvedSize()[1]/lineHeight)-1)
+
+		if focused {
+			if ReceivedFocusNow() {
+				doc.blinkStart = time.Now()
+			}
+			codeEditorInput(doc, attrs, pageSize)
+		}
+
+		var tokens []Token
+
+		type LineNo int
+
+		itemId := func(idx int) any {
+			return LineNo(idx)
+		}
+
+		itemHeight := func(idx int, width f32) f32 {
+			return lineHeight
+		}
+
+		itemView := func(idx int, width f32) {
+			var isCurrent = idx == doc.Cursor.Line
+			Layout(TW(Row, Expand, FixHeight(lineHeight)), func() {
+				if isCurrent {
+					ModAttrs(BG(210, 50, 96, 1))
+				}
+
+				// gutter
+				Layout(TW(Row, CrossMid, Expand, BG(0, 0, 96, 1)), func() {
+					if isCurrent {
+						ModAttrs(BG(210, 30, 92, 1))
+					}
+					if attrs.GutterWidth > 0 {
+						Layout(TW(FixWidth(attrs.GutterWidth), Expand, Center), func() {
+							if attrs.GutterFn != nil {
+								attrs.GutterFn(idx)
+							}
+						})
+					}
+					Layout(TW(Row, FixWidth(numbersWidth), MA(AlignEnd), Pad2(0, 8)), func() {
+						var a = numberAttrs
+						if isCurrent {
+							a.Color = Vec4{0, 0, 20, 1}
+						}
+						Text(lineNumberLabel(idx+1), a)
+					})
+				})
+
+				// the text itself
+				Layout(TW(Viewport, Pad2(vpad, 6)), func() {
+					var shaped = ShapeText(doc.Lines[idx], textAttrs)
+
+					if IsClicked() || (dragging && IsHovered()) {
+						var shift = slices.Contains(InputState.DownKeys, KeyShift)
+						var col = computeCursorIndex(GetContentRect(), InputState.MousePoint, shaped)
+						doc.SetCursor(CodePos{Line: idx, Col: col}, shift || FrameInput.Mouse != MouseClick)
+						doc.goalCol = -1
+					}
+
+					var state = doc.stateAt(idx)
+					tokens, _ = doc.tokenizer.Tokenize(shaped.Runes, state, tokens[:0])
+					colorFn := func(runeIndex int) Vec4 {
+						i := sort.Search(len(tokens), func(i int) bool { return tokens[i].End > runeIndex })
+						if i < len(tokens) && tokens[i].Start <= runeIndex {
+							return attrs.Theme[tokens[i].Kind]
+						}
+						return attrs.Theme[TokenText]
+					}
+
+					var selectionFrom, selectionTo int
+					var selectsNewline bool
+					from, to := doc.Selection()
+					if from != to && idx >= from.Line && idx <= to.Line {
+						selectionTo = len(shaped.Runes)
+						if idx == from.Line {
+							selectionFrom = from.Col
+						}
+						if idx == to.Line {
+							selectionTo = to.Col
+						} else {
+							selectsNewline = true
+						}
+					}
+
+					ShapedTextLayoutColored(shaped, textAttrs, selectionFrom, selectionTo, colorFn)
+
+					var rd = GetRenderData()
+					var origin = Vec2{rd.Padding[PAD_LEFT], rd.Padding[PAD_TOP]}
+
+					if selectsNewline {
+						pos := Vec2Add(origin, computeCursorPos(len(shaped.Runes), shaped))
+						Element(TW(NoAnimate, ClickThrough, FloatV(pos), FixSize(charWidth/2, attrs.FontSize), BG(220, 50, 70, 0.5)))
+					}
+
+					if focused && isCurrent {
+						if InputState.Composition != "" {
+							compositionUnderline(doc.Cursor.Col, shaped, textAttrs)
+						}
+
+						RequestNextFrame()
+						var alpha float32 = 1
+						var slot = int(time.Since(doc.blinkStart) / (time.Millisecond * 600))
+						if slot%2 == 1 {
+							alpha = 0
+						}
+						pos := Vec2Add(origin, computeCursorPos(doc.Cursor.Col, shaped))
+						Layout(TW(NoAnimate, ClickThrough, MinSize(1, attrs.FontSize), BG(0, 0, 20, alpha), FloatV(pos)), func() {
+							r := GetScreenRect()
+							shirei.CaretPos = Vec2Add(r.Origin, Vec2{0, attrs.FontSize * Baseline})
+							shirei.CaretAscent, shirei.CaretDescent = FontExtents(caretFont(doc.Cursor.Col, shaped, textAttrs), attrs.FontSize)
+						})
+					}
+				})
+			})
+		}
+
+		VirtualListViewExt(VirtualListAttrs{Reveal: doc.reveal, RevealIndex: doc.Cursor.Line}, len(doc.Lines), itemId, itemHeight, itemView)
+		doc.reveal = false
+	})
+}
+
+func codeEditorInput(doc *CodeDocument, attrs CodeEditorAttrs, pageSize int) {
+	var ctrl = ModCtrl
+	if runtime.GOOS == "darwin" {
+		ctrl = ModCmd
+	}
+
+	// Modifiers flag is not set unless another regular key is pressed, so we have to use this trick!
+	var shift = InputState.Modifiers&ModShift != 0
+
+	// vertical movement keeps aiming for the column where horizontal movement left off
+	moveVertically := func(lines int) {
+		if doc.goalCol < 0 {
+			doc.goalCol = doc.Cursor.Col
+		}
+		goal := doc.goalCol
+		doc.SetCursor(CodePos{Line: doc.Cursor.Line + lines, Col: goal}, shift)
+		doc.goalCol = goal
+	}
+
+	moveTo := func(p CodePos) {
+		doc.SetCursor(p, shift)
+		doc.goalCol = -1
+	}
+
+	switch ActiveCombo() {
+	case Combo(KeyA, ctrl):
+		doc.Anchor = CodePos{}
+		doc.Cursor = doc.clamp(CodePos{Line: len(doc.Lines) - 1, Col: doc.lineLen(len(doc.Lines) - 1)})
+	case Combo(KeyC, ctrl):
+		if doc.HasSelection() {
+			shirei.RequestTextCopy(doc.SelectedText())
+		}
+	case Combo(KeyX, ctrl):
+		if doc.HasSelection() && !attrs.ReadOnly {
+			shirei.RequestTextCopy(doc.SelectedText())
+			doc.DeleteSelection()
+		}
+	case Combo(KeyV, ctrl):
+		if !attrs.ReadOnly {
+			shirei.RequestPaste()
+		}
+	case Combo(KeyHome, ctrl), Combo(KeyHome, ctrl|ModShift):
+		moveTo(CodePos{})
+		return
+	case Combo(KeyEnd, ctrl), Combo(KeyEnd, ctrl|ModShift):
+		moveTo(CodePos{Line: len(doc.Lines) - 1, Col: doc.lineLen(len(doc.Lines) - 1)})
+		return
+	}
+
+	switch FrameInput.Key {
+	case KeyLeft:
+		if from, _ := doc.Selection(); doc.HasSelection() && !shift {
+			moveTo(from)
+		} else {
+			moveTo(doc.step(doc.Cursor, -1))
+		}
+	case KeyRight:
+		if _, to := doc.Selection(); doc.HasSelection() && !shift {
+			moveTo(to)
+		} else {
+			moveTo(doc.step(doc.Cursor, 1))
+		}
+	case KeyUp:
+		moveVertically(-1)
+	case KeyDown:
+		moveVertically(1)
+	case KeyPageUp:
+		moveVertically(-pageSize)
+	case KeyPageDown:
+		moveVertically(pageSize)
+	case KeyHome:
+		// toggle between the start of the text and the start of the line
+		var indent = utf8.RuneCountInString(leadingSpace(doc.Lines[doc.Cursor.Line]))
+		var col = indent
+		if doc.Cursor.Col == indent {
+			col = 0
+		}
+		moveTo(CodePos{Line: doc.Cursor.Line, Col: col})
+	case KeyEnd:
+		moveTo(CodePos{Line: doc.Cursor.Line, Col: doc.lineLen(doc.Cursor.Line)})
+	}
+
+	if attrs.ReadOnly {
+		return
+	}
+
+	switch FrameInput.Key {
+	case KeyEnter:
+		doc.InsertText("\n" + leadingSpace(doc.Lines[doc.Cursor.Line]))
+	case KeyTab:
+		if InputState.Modifiers&ModCtrl == 0 {
+			doc.InsertText("\t")
+		}
+	case KeyDeleteBackward:
+		if !doc.HasSelection() {
+			doc.Anchor = doc.step(doc.Cursor, -1)
+		}
+		doc.DeleteSelection()
+	case KeyDeleteForward:
+		if !doc.HasSelection() {
+			doc.Anchor = doc.step(doc.Cursor, 1)
+		}
+		doc.DeleteSelection()
+	}
+
+	if FrameInput.Text != "" || FrameInput.TextReplace > 0 {
+		// IME preedit updates replace text before the cursor, within the line
+		if !doc.HasSelection() && FrameInput.TextReplace > 0 {
+			doc.Anchor.Col = max(0, doc.Cursor.Col-FrameInput.TextReplace)
+		}
+		doc.InsertText(FrameInput.Text)
+	}
+}
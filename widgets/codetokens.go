@@ -0,0 +1,399 @@
+package widgets
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	. "go.hasen.dev/shirei"
+)
+
+type TokenKind uint8
+
+const (
+	TokenText TokenKind = iota
+	TokenKeyword
+	TokenType
+	TokenString
+	TokenNumber
+	TokenConstant // true, false, nil, ...
+	TokenComment
+	TokenKey // object keys in JSON and YAML
+	TokenPunct
+
+	TokenKindCount
+)
+
+// a run of runes in a line, [Start, End), that share a syntax color
+type Token struct {
+	Kind  TokenKind
+	Start int
+	End   int
+}
+
+// Tokenizer splits a line of code into tokens for syntax coloring.
+//
+// Constructs that span lines (block comments, raw strings, ...) are carried by
+// the state: Tokenize is given the state at the start of the line and returns
+// the state at its end. The state at the start of a document is 0.
+//
+// Tokens are appended to `tokens`, sorted and not overlapping; runes not
+// covered by any token are drawn as TokenText.
+type Tokenizer interface {
+	Tokenize(line []rune, state int, tokens []Token) ([]Token, int)
+}
+
+// colors by token kind
+type CodeTheme [TokenKindCount]Vec4
+
+func DefaultCodeTheme() CodeTheme {
+	return CodeTheme{
+		TokenText:     {0, 0, 15, 1},
+		TokenKeyword:  {290, 60, 40, 1},
+		TokenType:     {190, 80, 30, 1},
+		TokenString:   {110, 55, 30, 1},
+		TokenNumber:   {25, 85, 40, 1},
+		TokenConstant: {25, 85, 40, 1},
+		TokenComment:  {0, 0, 55, 1},
+		TokenKey:      {215, 65, 40, 1},
+		TokenPunct:    {0, 0, 40, 1},
+	}
+}
+
+type PlainTokenizer struct{}
+
+func (PlainTokenizer) Tokenize(line []rune, state int, tokens []Token) ([]Token, int) {
+	return tokens, state
+}
+
+// scanning helpers shared by the tokenizers
+
+func isIdentRune(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
+
+func runeAt(line []rune, i int) rune {
+	if i < 0 || i >= len(line) {
+		return 0
+	}
+	return line[i]
+}
+
+// returns the index after the delimiter, or the end of the line if not found
+func scanUntil(line []rune, from int, delim string) (end int, found bool) {
+	var d = []rune(delim)
+	for i := from; i+len(d) <= len(line); i++ {
+		if string(line[i:i+len(d)]) == delim {
+			return i + len(d), true
+		}
+	}
+	return len(line), false
+}
+
+// a quoted string starting at i, with backslash escapes
+func scanQuoted(line []rune, i int) int {
+	var quote = line[i]
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		}
+	}
+	return len(line)
+}
+
+func scanNumber(line []rune, i int) int {
+	var j = i
+	for j < len(line) {
+		ch := line[j]
+		isExp := (ch == '+' || ch == '-') && j > i && strings.ContainsRune("eEpP", line[j-1])
+		if !isIdentRune(ch) && ch != '.' && !isExp {
+			break
+		}
+		j++
+	}
+	return j
+}
+
+func scanIdent(line []rune, i int) int {
+	for i < len(line) && isIdentRune(line[i]) {
+		i++
+	}
+	return i
+}
+
+func skipSpaces(line []rune, i int) int {
+	for i < len(line) && unicode.IsSpace(line[i]) {
+		i++
+	}
+	return i
+}
+
+// ---- Go ----
+
+type GoTokenizer struct{}
+
+const (
+	goStateNormal = iota
+	goStateComment
+	goStateRawString
+)
+
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+}
+
+var goTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "error": true,
+	"complex64": true, "complex128": true, "float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"uintptr": true, "rune": true, "string": true,
+}
+
+var goConstants = map[string]bool{
+	"true": true, "false": true, "nil": true, "iota": true,
+}
+
+func (GoTokenizer) Tokenize(line []rune, state int, tokens []Token) ([]Token, int) {
+	var i int
+
+	// continue what the previous line left open
+	switch state {
+	case goStateComment, goStateRawString:
+		var kind, delim = TokenComment, "*/"
+		if state == goStateRawString {
+			kind, delim = TokenString, "`"
+		}
+		end, found := scanUntil(line, 0, delim)
+		tokens = append(tokens, Token{kind, 0, end})
+		if !found {
+			return tokens, state
+		}
+		i = end
+		state = goStateNormal
+	}
+
+	for i < len(line) {
+		var start = i
+		var kind = TokenText
+		var ch = line[i]
+		var next = runeAt(line, i+1)
+
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+			continue
+		case ch == '/' && next == '/':
+			kind, i = TokenComment, len(line)
+		case ch == '/' && next == '*':
+			end, found := scanUntil(line, i+2, "*/")
+			kind, i = TokenComment, end
+			if !found {
+				state = goStateComment
+			}
+		case ch == '`':
+			end, found := scanUntil(line, i+1, "`")
+			kind, i = TokenString, end
+			if !found {
+				state = goStateRawString
+			}
+		case ch == '"' || ch == '\'':
+			kind, i = TokenString, scanQuoted(line, i)
+		case unicode.IsDigit(ch) || (ch == '.' && unicode.IsDigit(next)):
+			kind, i = TokenNumber, scanNumber(line, i)
+		case isIdentRune(ch):
+			i = scanIdent(line, i)
+			switch word := string(line[start:i]); {
+			case goKeywords[word]:
+				kind = TokenKeyword
+			case goTypes[word]:
+				kind = TokenType
+			case goConstants[word]:
+				kind = TokenConstant
+			}
+		default:
+			kind, i = TokenPunct, i+1
+		}
+
+		if kind != TokenText {
+			tokens = append(tokens, Token{kind, start, i})
+		}
+	}
+	return tokens, state
+}
+
+// ---- JSON ----
+
+type JSONTokenizer struct{}
+
+func (JSONTokenizer) Tokenize(line []rune, state int, tokens []Token) ([]Token, int) {
+	var i int
+	for i < len(line) {
+		var start = i
+		var kind = TokenText
+		var ch = line[i]
+
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+			continue
+		case ch == '"':
+			i = scanQuoted(line, i)
+			kind = TokenString
+			if runeAt(line, skipSpaces(line, i)) == ':' {
+				kind = TokenKey
+			}
+		case ch == '-' || unicode.IsDigit(ch):
+			kind, i = TokenNumber, scanNumber(line, i+1)
+		case unicode.IsLetter(ch):
+			i = scanIdent(line, i)
+			switch string(line[start:i]) {
+			case "true", "false", "null":
+				kind = TokenConstant
+			}
+		default:
+			kind, i = TokenPunct, i+1
+		}
+
+		if kind != TokenText {
+			tokens = append(tokens, Token{kind, start, i})
+		}
+	}
+	return tokens, state
+}
+
+// ---- YAML ----
+
+// The state is 0, or inside a block scalar (| or >) it's one more than the
+// indentation of the line that started it: the scalar goes on as long as lines
+// are indented deeper than that.
+type YAMLTokenizer struct{}
+
+var yamlConstants = map[string]bool{
+	"true": true, "false": true, "True": true, "False": true, "TRUE": true, "FALSE": true,
+	"yes": true, "no": true, "on": true, "off": true,
+	"null": true, "Null": true, "NULL": true, "~": true,
+}
+
+func (YAMLTokenizer) Tokenize(line []rune, state int, tokens []Token) ([]Token, int) {
+	var indent = skipSpaces(line, 0)
+
+	if state > 0 {
+		if indent == len(line) {
+			return tokens, state // blank lines don't end a block scalar
+		}
+		if indent >= state {
+			return append(tokens, Token{TokenString, indent, len(line)}), state
+		}
+		state = 0
+	}
+
+	var i = indent
+	if i == 0 && len(line) >= 3 && (string(line[:3]) == "---" || string(line[:3]) == "...") {
+		tokens = append(tokens, Token{TokenKeyword, 0, 3})
+		i = 3
+	}
+
+	// nesting of [] and {} collections within the line
+	var flowDepth int
+	for i < len(line) {
+		var start = i
+		var kind = TokenText
+		var ch = line[i]
+		var next = runeAt(line, i+1)
+		var spaceBefore = i == 0 || unicode.IsSpace(line[i-1])
+
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+			continue
+		case ch == '#' && spaceBefore:
+			kind, i = TokenComment, len(line)
+		case ch == '-' && (next == 0 || unicode.IsSpace(next)):
+			kind, i = TokenPunct, i+1
+		case ch == '"' || ch == '\'':
+			i = scanQuoted(line, i)
+			kind = TokenString
+			if runeAt(line, skipSpaces(line, i)) == ':' {
+				kind = TokenKey
+			}
+		case ch == '[' || ch == '{':
+			flowDepth++
+			kind, i = TokenPunct, i+1
+		case ch == ']' || ch == '}':
+			flowDepth = max(0, flowDepth-1)
+			kind, i = TokenPunct, i+1
+		case ch == ',' || ch == ':':
+			kind, i = TokenPunct, i+1
+		case ch == '&' || ch == '*' || ch == '!':
+			for i < len(line) && !unicode.IsSpace(line[i]) && !strings.ContainsRune(",[]{}", line[i]) {
+				i++
+			}
+			kind = TokenType
+		case (ch == '|' || ch == '>') && flowDepth == 0:
+			// block scalar header, e.g. `|`, `>-`, `|2`
+			for i < len(line) && !unicode.IsSpace(line[i]) {
+				i++
+			}
+			kind = TokenPunct
+			state = indent + 1
+		default:
+			// plain scalar: ends at ": ", " #", the end of the line, or
+			// a flow indicator when inside a flow collection
+			var end = i
+			for i < len(line) {
+				c := line[i]
+				if c == ':' && (i+1 == len(line) || unicode.IsSpace(line[i+1])) {
+					break
+				}
+				if c == '#' && unicode.IsSpace(line[i-1]) {
+					break
+				}
+				if flowDepth > 0 && strings.ContainsRune(",[]{}", c) {
+					break
+				}
+				i++
+				if !unicode.IsSpace(c) {
+					end = i
+				}
+			}
+			var word = string(line[start:end])
+			switch {
+			case runeAt(line, i) == ':':
+				kind = TokenKey
+			case yamlConstants[word]:
+				kind = TokenConstant
+			case isYAMLNumber(word):
+				kind = TokenNumber
+			default:
+				kind = TokenString
+			}
+			tokens = append(tokens, Token{kind, start, end})
+			continue
+		}
+
+		if kind != TokenText {
+			tokens = append(tokens, Token{kind, start, i})
+		}
+	}
+	return tokens, state
+}
+
+func isYAMLNumber(word string) bool {
+	if word == "" || !strings.ContainsRune("0123456789+-.", rune(word[0])) {
+		return false
+	}
+	_, err := strconv.ParseFloat(word, 64)
+	if err == nil {
+		return true
+	}
+	_, err = strconv.ParseInt(word, 0, 64)
+	return err == nil
+}
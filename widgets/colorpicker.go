@@ -0,0 +1,328 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type ColorPickerAttrs struct {
+	Size    f32  // of the saturation/lightness square
+	NoAlpha bool // no alpha strip or field; alpha stays as it is
+}
+
+func DefaultColorPickerAttrs() ColorPickerAttrs {
+	return ColorPickerAttrs{
+		Size: 200,
+	}
+}
+
+// RecentColors are the colors last picked with a ColorPicker, most recent
+// first; they're shared by all pickers
+var RecentColors []Vec4
+
+const maxRecentColors = 12
+
+func AddRecentColor(c Vec4) {
+	if i := slices.Index(RecentColors, c); i >= 0 {
+		RecentColors = slices.Delete(RecentColors, i, i+1)
+	}
+	RecentColors = slices.Insert(RecentColors, 0, c)
+	if len(RecentColors) > maxRecentColors {
+		RecentColors = RecentColors[:maxRecentColors]
+	}
+}
+
+type colorArea uint8
+
+const (
+	colorAreaNone colorArea = iota
+	colorAreaSquare
+	colorAreaHue
+	colorAreaAlpha
+)
+
+type colorPickerState struct {
+	dragging colorArea
+	dirty    bool // changed since it was added to the recent colors
+
+	hex      string
+	hexColor Vec4 // the color hex was formatted from
+}
+
+const colorStripWidth = 16
+
+// ColorPicker edits an HSLA color with a saturation/lightness square, hue and
+// alpha strips, and HSL, RGB and hex fields. Returns true when the color
+// changes.
+func ColorPicker(color *Vec4) bool {
+	return ColorPickerExt(color, DefaultColorPickerAttrs())
+}
+
+func ColorPickerExt(color *Vec4, attrs ColorPickerAttrs) bool {
+	var changed bool
+	set := func(c Vec4) {
+		c[HUE] = max(0, min(360, c[HUE]))
+		c[SATURATION] = max(0, min(100, c[SATURATION]))
+		c[LIGHT] = max(0, min(100, c[LIGHT]))
+		c[ALPHA] = max(0, min(1, c[ALPHA]))
+		if c != *color {
+			*color = c
+			changed = true
+		}
+	}
+
+	Layout(TW(Gap(8), Pad(8), BR(4), BG(0, 0, 96, 1), BW(1), Bo(0, 0, 0, 0.3)), func() {
+		var state = Use[colorPickerState]("color-picker")
+		var c = *color
+
+		if InputState.MouseButton&MousePrimary == 0 {
+			state.dragging = colorAreaNone
+		}
+
+		// the position of the mouse in the area, from 0 to 1
+		dragArea := func(area colorArea) (Vec2, bool) {
+			if IsClicked() {
+				state.dragging = area
+			}
+			if state.dragging != area {
+				return Vec2{}, false
+			}
+			var rect = GetResolvedRectOf(CurrentId())
+			var p = Vec2Sub(InputState.MousePoint, rect.Origin)
+			p[0] = max(0, min(1, p[0]/rect.Size[0]))
+			p[1] = max(0, min(1, p[1]/rect.Size[1]))
+			return p, true
+		}
+
+		Layout(TW(Row, Gap(8)), func() {
+			// saturation across, lightness down; each column is two vertical
+			// gradients, which are exact since rgb is linear in lightness on
+			// either side of 50
+			const columnWidth = 4
+			var columns = max(1, int(attrs.Size/columnWidth))
+			var size = f32(columns * columnWidth)
+			Layout(TW(Row, FixSize(size, size), Clip, BR(2)), func() {
+				if p, ok := dragArea(colorAreaSquare); ok {
+					c[SATURATION] = p[0] * 100
+					c[LIGHT] = (1 - p[1]) * 100
+					set(c)
+				}
+				for i := range columns {
+					var s = 100 * (f32(i) + 0.5) / f32(columns)
+					Layout(TW(ClickThrough), func() {
+						Element(TW(FixSize(columnWidth, size/2), BG(c[HUE], s, 100, 1), Grad(0, 0, -50, 0)))
+						Element(TW(FixSize(columnWidth, size/2), BG(c[HUE], s, 50, 1), Grad(0, 0, -50, 0)))
+					})
+				}
+				var marker = Vec2{c[SATURATION] / 100 * size, (1 - c[LIGHT]/100) * size}
+				Element(TW(ClickThrough, NoAnimate, Float(marker[0]-6, marker[1]-6), FixSize(12, 12), BR(6), BW(2), Bo(0, 0, 100, 1), Shd(2)))
+			})
+
+			// hue, in six segments between primary and secondary colors,
+			// where rgb interpolation matches the hue
+			Layout(TW(FixSize(colorStripWidth, size), BR(2), Clip), func() {
+				if p, ok := dragArea(colorAreaHue); ok {
+					c[HUE] = p[1] * 360
+					set(c)
+				}
+				for i := range 6 {
+					Element(TW(ClickThrough, FixSize(colorStripWidth, size/6), BG(f32(i)*60, 100, 50, 1), Grad(60, 0, 0, 0)))
+				}
+				colorStripMarker(c[HUE] / 360 * size)
+			})
+
+			if attrs.NoAlpha {
+				return
+			}
+			Layout(TW(FixSize(colorStripWidth, size), BR(2), Clip), func() {
+				if p, ok := dragArea(colorAreaAlpha); ok {
+					c[ALPHA] = 1 - p[1]
+					set(c)
+				}
+				checkerboard(Vec2{colorStripWidth, size})
+				var opaque = c
+				opaque[ALPHA] = 1
+				Element(TW(ClickThrough, Float(0, 0), FixSize(colorStripWidth, size), BGV(opaque), Grad(0, 0, 0, -1)))
+				colorStripMarker((1 - c[ALPHA]) * size)
+			})
+		})
+		c = *color
+
+		if state.dragging != colorAreaNone && changed {
+			state.dirty = true
+		}
+
+		var fieldAttrs = DefaultTextInputAttrs()
+		fieldAttrs.FontSize = 12
+		fieldAttrs.Padding = N4(4)
+
+		// a number field; only what's typed into it changes the color, since
+		// it re-formats (and rounds) the value when it's not focused
+		field := func(label string, value f32, lo, hi, step float64, apply func(v f32)) {
+			Layout(TW(Row, CrossMid, Gap(3)), func() {
+				Label(label, Sz(11), Clr(0, 0, 30, 1))
+				var attrs = fieldAttrs
+				attrs.Min, attrs.Max, attrs.Step = lo, hi, step
+				attrs.HasMin, attrs.HasMax = true, true
+				var v = float64(value)
+				NumberInput(&v, attrs)
+				if HasFocusWithin() && f32(v) != value {
+					apply(f32(v))
+					state.dirty = true
+				}
+			})
+		}
+
+		Layout(TW(Row, CrossMid, Gap(8)), func() {
+			field("H", c[HUE], 0, 360, 1, func(v f32) { c[HUE] = v; set(c) })
+			field("S", c[SATURATION], 0, 100, 1, func(v f32) { c[SATURATION] = v; set(c) })
+			field("L", c[LIGHT], 0, 100, 1, func(v f32) { c[LIGHT] = v; set(c) })
+			if !attrs.NoAlpha {
+				field("A", c[ALPHA], 0, 1, 0.01, func(v f32) { c[ALPHA] = v; set(c) })
+			}
+		})
+
+		var rgb = roundedRGBA(c)
+		setRGB := func(r, g, b uint8) {
+			h, s, l := FloatRGBToHSL(f32(r)/0xff, f32(g)/0xff, f32(b)/0xff)
+			set(Vec4{h * 360, s * 100, l * 100, c[ALPHA]})
+		}
+		Layout(TW(Row, CrossMid, Gap(8)), func() {
+			field("R", f32(rgb.R), 0, 255, 1, func(v f32) { setRGB(uint8(v), rgb.G, rgb.B) })
+			field("G", f32(rgb.G), 0, 255, 1, func(v f32) { setRGB(rgb.R, uint8(v), rgb.B) })
+			field("B", f32(rgb.B), 0, 255, 1, func(v f32) { setRGB(rgb.R, rgb.G, uint8(v)) })
+		})
+
+		Layout(TW(Row, CrossMid, Gap(8)), func() {
+			// over a checkerboard, so transparency shows
+			Layout(TW(FixSize(40, 24), BR(2), BW(1), Bo(0, 0, 0, 0.3), Clip), func() {
+				checkerboard(Vec2{40, 24})
+				Element(TW(Float(0, 0), FixSize(40, 24), BGV(*color)))
+			})
+
+			Label("Hex", Sz(11), Clr(0, 0, 30, 1))
+			Layout(TW(), func() {
+				if !HasFocusWithin() && (state.hexColor != *color || state.hex == "") {
+					state.hexColor = *color
+					state.hex = colorHex(*color, !attrs.NoAlpha)
+				}
+				var inputAttrs = fieldAttrs
+				inputAttrs.MaxLength = 9
+				inputAttrs.Filter = func(r rune) bool {
+					return r == '#' || strings.ContainsRune("0123456789abcdefABCDEF", r)
+				}
+				TextInputExt(&state.hex, inputAttrs)
+				if HasFocusWithin() {
+					var current = roundedRGBA(*color)
+					if r, g, b, a, ok := parseColorHex(state.hex); ok && [4]uint8{r, g, b, a} != [4]uint8{current.R, current.G, current.B, current.A} {
+						var alpha = f32(a) / 0xff
+						if attrs.NoAlpha {
+							alpha = c[ALPHA]
+						}
+						h, s, l := FloatRGBToHSL(f32(r)/0xff, f32(g)/0xff, f32(b)/0xff)
+						set(Vec4{h * 360, s * 100, l * 100, alpha})
+						state.hexColor = *color
+						state.dirty = true
+					}
+				}
+			})
+		})
+
+		if len(RecentColors) > 0 {
+			Layout(TW(Row, Wrap, Gap(4), MaxWidth(attrs.Size+colorStripWidth*2+16)), func() {
+				for _, recent := range RecentColors {
+					Layout(TW(FixSize(18, 18), BR(2), BW(1), Bo(0, 0, 0, 0.3), Clip), func() {
+						if PressAction() {
+							set(recent)
+						}
+						checkerboard(Vec2{18, 18})
+						Element(TW(ClickThrough, Float(0, 0), FixSize(18, 18), BGV(recent)))
+						if IsHovered() {
+							ModAttrs(Bo(0, 0, 0, 0.8))
+						}
+					})
+				}
+			})
+		}
+
+		if state.dirty && state.dragging == colorAreaNone && !HasFocusWithin() {
+			state.dirty = false
+			AddRecentColor(*color)
+		}
+	})
+	return changed
+}
+
+func colorStripMarker(offset f32) {
+	Element(TW(ClickThrough, NoAnimate, Float(0, offset-2), FixSize(colorStripWidth, 4), BW(1), Bo(0, 0, 100, 1), Shd(2)))
+}
+
+// two cells each way of the checkerboard, for tiling
+var checkerTile = func() *image.RGBA {
+	const cell = 4
+	var img = image.NewRGBA(image.Rect(0, 0, cell*2, cell*2))
+	for y := range cell * 2 {
+		for x := range cell * 2 {
+			var light uint8 = 0xff
+			if (x/cell+y/cell)%2 == 1 {
+				light = 0xcc
+			}
+			img.SetRGBA(x, y, color.RGBA{light, light, light, 0xff})
+		}
+	}
+	return img
+}()
+
+// gray squares behind transparent colors
+func checkerboard(size Vec2) {
+	Layout(TW(ClickThrough, Float(0, 0), FixSizeV(size)), func() {
+		BackgroundImage(FrameImage(checkerTile), ImageAttrs{Tile: true, Focus: Vec2{-0.5, -0.5}})
+	})
+}
+
+// rounded to the nearest, unlike HSLAColor, so hex and rgb fields read back
+// what was typed in
+func roundedRGBA(c Vec4) color.NRGBA {
+	var r, g, b = FloatHSLToRGB(c[0]/360, c[1]/100, c[2]/100)
+	return color.NRGBA{
+		R: uint8(r*0xff + 0.5),
+		G: uint8(g*0xff + 0.5),
+		B: uint8(b*0xff + 0.5),
+		A: uint8(c[3]*0xff + 0.5),
+	}
+}
+
+// #rrggbb, or #rrggbbaa when alpha is wanted and not 1
+func colorHex(c Vec4, alpha bool) string {
+	var rgba = roundedRGBA(c)
+	if alpha && rgba.A != 0xff {
+		return fmt.Sprintf("#%02x%02x%02x%02x", rgba.R, rgba.G, rgba.B, rgba.A)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// accepts rgb, rrggbb and rrggbbaa, with or without the #
+func parseColorHex(text string) (r, g, b, a uint8, ok bool) {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "#")
+	if len(text) == 3 {
+		text = string([]byte{text[0], text[0], text[1], text[1], text[2], text[2]})
+	}
+	if len(text) == 6 {
+		text += "ff"
+	}
+	if len(text) != 8 {
+		return 0, 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(text, 16, 32)
+	if err != nil {
+		return 0, 0, 0, 0, false
+	}
+	return uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
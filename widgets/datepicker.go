@@ -0,0 +1,633 @@
+package widgets
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type DatePickerAttrs struct {
+	// days outside of these can't be picked; zero for no bound
+	Min time.Time
+	Max time.Time
+
+	FirstWeekday time.Weekday // the first column of the month grid
+	WeekdayNames [7]string    // column headers, from sunday; english when empty
+	Format       string       // for the field (see time.Layout)
+	Placeholder  string       // shown while the value is zero
+}
+
+func DefaultDatePickerAttrs() DatePickerAttrs {
+	return DatePickerAttrs{
+		FirstWeekday: LocaleFirstWeekday(),
+		WeekdayNames: weekdayNames,
+		Format:       "Jan 2, 2006",
+		Placeholder:  "Pick a date",
+	}
+}
+
+// regions where weeks don't start on monday, from the CLDR week data
+var weekStarts = map[string]time.Weekday{
+	"AE": time.Saturday, "AF": time.Saturday, "BH": time.Saturday, "DJ": time.Saturday,
+	"DZ": time.Saturday, "EG": time.Saturday, "IQ": time.Saturday, "IR": time.Saturday,
+	"JO": time.Saturday, "KW": time.Saturday, "LY": time.Saturday, "OM": time.Saturday,
+	"QA": time.Saturday, "SD": time.Saturday, "SY": time.Saturday, "MV": time.Friday,
+}
+
+func init() {
+	for _, region := range strings.Fields(`AG AS BD BR BS BT BW BZ CA CN CO DM DO ET GT GU HK HN ID IL IN
+		JM JP KE KH KR LA MH MM MO MT MX MZ NI NP PA PE PH PK PR PT PY SA SG SV TH TT TW UM US VE VI WS YE ZA ZW`) {
+		weekStarts[region] = time.Sunday
+	}
+}
+
+// LocaleFirstWeekday is the first day of the week for the user's locale, as
+// given by the LC_ALL, LC_TIME or LANG environment variables, or by the system
+// settings on macOS and Windows when they're not set; monday when unknown
+func LocaleFirstWeekday() time.Weekday {
+	var locale string
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	if locale == "" {
+		locale = platformLocale()
+	}
+	// e.g. en_US.UTF-8 or de_DE@euro
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return time.Monday
+	}
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return time.Monday
+	}
+	region, _ := tag.Region()
+	if day, ok := weekStarts[region.String()]; ok {
+		return day
+	}
+	return time.Monday
+}
+
+var weekdayNames = [7]string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"}
+
+// "00" to "59"; labels are drawn from these so the strings stay the same
+// across frames
+var twoDigits [60]string
+var dayNumbers [32]string
+
+func init() {
+	for i := range twoDigits {
+		twoDigits[i] = strconv.Itoa(i/10) + strconv.Itoa(i%10)
+	}
+	for i := range dayNumbers {
+		dayNumbers[i] = strconv.Itoa(i)
+	}
+}
+
+type pickerPopupKey struct{ state *pickerState }
+
+type pickerState struct {
+	open   bool
+	cursor time.Time // the day the keyboard is on; its month is shown
+	anchor time.Time // date range: the first day picked; zero until then
+	hover  time.Time // the day under the mouse; zero after keyboard moves
+
+	// picked in the popup, which is drawn after the widget returns; applied
+	// on the next frame so the widget can report the change
+	picked  time.Time
+	hasPick bool
+
+	// the field text and the month title, kept while they don't change
+	text     string
+	textFor  [2]time.Time
+	title    string
+	titleFor time.Time
+}
+
+func (state *pickerState) fieldText(values [2]time.Time, format func() string) string {
+	if state.text == "" || state.textFor != values {
+		state.textFor = values
+		state.text = format()
+	}
+	return state.text
+}
+
+func (state *pickerState) deferPick(t time.Time) {
+	state.picked = t
+	state.hasPick = true
+	RequestNextFrame()
+}
+
+func (state *pickerState) takePick() (time.Time, bool) {
+	var ok = state.hasPick
+	state.hasPick = false
+	return state.picked, ok
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// day with the time of day of t; the local time when t is zero
+func withDate(t time.Time, day time.Time) time.Time {
+	if t.IsZero() {
+		return day
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func (attrs *DatePickerAttrs) allows(day time.Time) bool {
+	if !attrs.Min.IsZero() && day.Before(dateOnly(attrs.Min)) {
+		return false
+	}
+	if !attrs.Max.IsZero() && day.After(dateOnly(attrs.Max)) {
+		return false
+	}
+	return true
+}
+
+func (attrs *DatePickerAttrs) clamp(day time.Time) time.Time {
+	if !attrs.Min.IsZero() && day.Before(dateOnly(attrs.Min)) {
+		return dateOnly(attrs.Min)
+	}
+	if !attrs.Max.IsZero() && day.After(dateOnly(attrs.Max)) {
+		return dateOnly(attrs.Max)
+	}
+	return day
+}
+
+// a focusable field that opens a popup under it; keys handles the keyboard
+// while the field is focused (Escape and Tab close the popup first)
+func pickerField(state *pickerState, text string, placeholder bool, icon rune, open func(), keys func(), popup func()) {
+	Layout(TW(Row, CrossMid, Focusable, Gap(6), Pad(DefaultTextSize/2), BR(2), BG(0, 0, 90, 1), Grad(0, 0, 4, 0), BW(1), Bo(0, 0, 50, 1)), func() {
+		var id = CurrentId()
+		var popupId = pickerPopupKey{state}
+
+		if FrameInput.Mouse == MouseClick {
+			if IsHovered() {
+				FocusImmediate()
+				if state.open {
+					state.open = false
+				} else {
+					open()
+					state.open = true
+				}
+			} else if !IdIsHovered(popupId) {
+				state.open = false
+				if HasFocus() {
+					Blur()
+				}
+			}
+		}
+		CycleFocusOnTab()
+
+		if !HasFocus() {
+			state.open = false
+		} else {
+			ModAttrs(BG(0, 0, 91, 1), Bo(0, 0, 30, 1))
+			var alt = InputState.Modifiers&ModAlt != 0
+			switch {
+			case state.open && (FrameInput.Key == KeyEscape || FrameInput.Key == KeyTab):
+				state.open = false
+			case !state.open && (FrameInput.Key == KeyEnter || FrameInput.Key == KeySpace || FrameInput.Key == KeyDown && alt):
+				open()
+				state.open = true
+			default:
+				keys()
+			}
+		}
+
+		var alpha f32 = 1
+		if placeholder {
+			alpha = 0.4
+		}
+		Label(text, Clr(0, 0, 0, alpha))
+		Icon(icon, Clr(0, 0, 20, 0.8))
+
+		if state.open {
+			Popup(func() {
+				LayoutId(popupId, TW(NoAnimate, Pad(8), Gap(6), BGV(_menuBG), BW(1), Bo(0, 0, 10, 0.8), BR(4), Shd(14)), func() {
+					ModAttrs(FloatV(_getPositionRelativeTo(id)))
+					popup()
+				})
+			})
+		}
+	})
+}
+
+// the same day n months later, or the last day of that month if it's shorter
+func addMonths(t time.Time, n int) time.Time {
+	var first = time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	var last = first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// move the cursor of a month grid with the keyboard
+func monthGridKeys(state *pickerState, attrs *DatePickerAttrs) {
+	var shift = InputState.Modifiers&ModShift != 0
+	var cursor = state.cursor
+	switch FrameInput.Key {
+	case KeyLeft:
+		cursor = cursor.AddDate(0, 0, -1)
+	case KeyRight:
+		cursor = cursor.AddDate(0, 0, 1)
+	case KeyUp:
+		cursor = cursor.AddDate(0, 0, -7)
+	case KeyDown:
+		cursor = cursor.AddDate(0, 0, 7)
+	case KeyPageUp:
+		if shift {
+			cursor = addMonths(cursor, -12)
+		} else {
+			cursor = addMonths(cursor, -1)
+		}
+	case KeyPageDown:
+		if shift {
+			cursor = addMonths(cursor, 12)
+		} else {
+			cursor = addMonths(cursor, 1)
+		}
+	case KeyHome:
+		cursor = cursor.AddDate(0, 0, -int((cursor.Weekday()-attrs.FirstWeekday+7)%7))
+	case KeyEnd:
+		cursor = cursor.AddDate(0, 0, 6-int((cursor.Weekday()-attrs.FirstWeekday+7)%7))
+	default:
+		return
+	}
+	state.cursor = attrs.clamp(cursor)
+	state.hover = time.Time{}
+}
+
+// the month of the cursor as a grid of days, with buttons for the previous
+// and next months; a clicked day is picked with deferPick. looks tells how a
+// day is drawn: selected, and within the selected range.
+func monthGrid(state *pickerState, attrs *DatePickerAttrs, looks func(day time.Time) (bool, bool)) {
+	const cell = 28
+	var first = time.Date(state.cursor.Year(), state.cursor.Month(), 1, 0, 0, 0, 0, state.cursor.Location())
+	var today = dateOnly(time.Now().In(state.cursor.Location()))
+
+	Layout(TW(Row, CrossMid, Expand), func() {
+		monthButton := func(icon rune, months int) {
+			Layout(TW(Pad(4), BR(3)), func() {
+				// only to months with days that can be picked
+				var target = first.AddDate(0, months, 0)
+				var enabled = (attrs.Min.IsZero() || !target.AddDate(0, 1, -1).Before(dateOnly(attrs.Min))) &&
+					(attrs.Max.IsZero() || !target.After(dateOnly(attrs.Max)))
+				if !enabled {
+					ModAttrs(Trans(0.7))
+				} else {
+					if PressAction() {
+						state.cursor = attrs.clamp(addMonths(state.cursor, months))
+					}
+					if IsHovered() {
+						ModAttrs(BG(0, 0, 0, 0.08))
+					}
+				}
+				Icon(icon, Clr(0, 0, 20, 1))
+			})
+		}
+		monthButton(SymLeft, -1)
+		Layout(TW(Grow(1), Row, MA(AlignMiddle)), func() {
+			if state.title == "" || !state.titleFor.Equal(first) {
+				state.titleFor = first
+				state.title = first.Format("January 2006")
+			}
+			Label(state.title, FontWeight(WeightBold), Clr(0, 0, 15, 1))
+		})
+		monthButton(SymRight, 1)
+	})
+
+	Layout(TW(Row), func() {
+		var names = attrs.WeekdayNames
+		if names == ([7]string{}) {
+			names = weekdayNames
+		}
+		for i := range 7 {
+			Layout(TW(FixSize(cell, cell*0.8), Center), func() {
+				Label(names[(int(attrs.FirstWeekday)+i)%7], Sz(11), Clr(0, 0, 40, 1))
+			})
+		}
+	})
+
+	// six weeks, starting with the one that has the first of the month
+	var start = first.AddDate(0, 0, -int((first.Weekday()-attrs.FirstWeekday+7)%7))
+	for week := range 6 {
+		Layout(TW(Row), func() {
+			for i := range 7 {
+				var day = start.AddDate(0, 0, week*7+i)
+				var allowed = attrs.allows(day)
+				var selected, inRange = looks(day)
+				Layout(TW(FixSize(cell, cell), Center, BR(cell/2)), func() {
+					var color = Vec4{0, 0, 10, 1}
+					if day.Month() != first.Month() {
+						color[ALPHA] = 0.4
+					}
+					if !allowed {
+						color[ALPHA] = 0.2
+					} else {
+						if IsHovered() {
+							state.hover = day
+							if !selected {
+								ModAttrs(BG(0, 0, 0, 0.08))
+							}
+						}
+						if IsClicked() {
+							state.cursor = day
+							state.deferPick(day)
+						}
+					}
+					switch {
+					case selected:
+						ModAttrs(BG(210, 70, 50, 1))
+						color = Vec4{0, 0, 100, 1}
+					case inRange:
+						ModAttrs(BR(2), BG(210, 70, 85, 1))
+					}
+					// the keyboard cursor, unless the mouse is being used
+					if day.Equal(state.cursor) && state.hover.IsZero() {
+						ModAttrs(BW(2), Bo(210, 80, 40, 1))
+					} else if day.Equal(today) {
+						ModAttrs(BW(1), Bo(0, 0, 30, 1))
+					}
+					Label(dayNumbers[day.Day()], Sz(12), ClrV(color))
+				})
+			}
+		})
+	}
+}
+
+// DatePicker is a field that opens a month grid to pick a day; returns true
+// when the value changes. The time of day of the value is kept.
+//
+// With the grid open, the arrows move by days and weeks, PageUp/PageDown by
+// months (years with Shift), and Enter picks.
+func DatePicker(value *time.Time) bool {
+	return DatePickerExt(value, DefaultDatePickerAttrs())
+}
+
+func DatePickerExt(value *time.Time, attrs DatePickerAttrs) bool {
+	var changed bool
+	Layout(TW(), func() {
+		var state = Use[pickerState]("date-picker")
+
+		pick := func(day time.Time) {
+			var next = withDate(*value, day)
+			if !next.Equal(*value) {
+				*value = next
+				changed = true
+			}
+			state.open = false
+		}
+		if day, ok := state.takePick(); ok {
+			pick(day)
+		}
+		open := func() {
+			state.cursor = dateOnly(time.Now())
+			if !value.IsZero() {
+				state.cursor = dateOnly(*value)
+			}
+			state.cursor = attrs.clamp(state.cursor)
+			state.hover = time.Time{}
+		}
+		keys := func() {
+			if !state.open {
+				return
+			}
+			if FrameInput.Key == KeyEnter || FrameInput.Key == KeySpace {
+				pick(state.cursor)
+				return
+			}
+			monthGridKeys(state, &attrs)
+		}
+
+		var text = state.fieldText([2]time.Time{*value}, func() string {
+			if value.IsZero() {
+				return attrs.Placeholder
+			}
+			return value.Format(attrs.Format)
+		})
+		pickerField(state, text, value.IsZero(), SymCalendar, open, keys, func() {
+			var selected = dateOnly(*value)
+			monthGrid(state, &attrs, func(day time.Time) (bool, bool) {
+				return !value.IsZero() && day.Equal(selected), false
+			})
+		})
+	})
+	return changed
+}
+
+// DateRangePicker picks a range of days with two clicks (or two Enters) on a
+// month grid; from stays before to. Returns true when the range changes.
+func DateRangePicker(from *time.Time, to *time.Time) bool {
+	return DateRangePickerExt(from, to, DefaultDatePickerAttrs())
+}
+
+func DateRangePickerExt(from *time.Time, to *time.Time, attrs DatePickerAttrs) bool {
+	var changed bool
+	Layout(TW(), func() {
+		var state = Use[pickerState]("date-range-picker")
+
+		pick := func(day time.Time) {
+			if state.anchor.IsZero() {
+				state.anchor = day
+				return
+			}
+			var first, last = state.anchor, day
+			if last.Before(first) {
+				first, last = last, first
+			}
+			state.anchor = time.Time{}
+			var nextFrom, nextTo = withDate(*from, first), withDate(*to, last)
+			if !nextFrom.Equal(*from) || !nextTo.Equal(*to) {
+				*from, *to = nextFrom, nextTo
+				changed = true
+			}
+			state.open = false
+		}
+		if day, ok := state.takePick(); ok {
+			pick(day)
+		}
+		open := func() {
+			state.cursor = dateOnly(time.Now())
+			if !from.IsZero() {
+				state.cursor = dateOnly(*from)
+			}
+			state.cursor = attrs.clamp(state.cursor)
+			state.anchor = time.Time{}
+			state.hover = time.Time{}
+		}
+		keys := func() {
+			if !state.open {
+				return
+			}
+			if FrameInput.Key == KeyEnter || FrameInput.Key == KeySpace {
+				pick(state.cursor)
+				return
+			}
+			monthGridKeys(state, &attrs)
+		}
+
+		var empty = from.IsZero() || to.IsZero()
+		var text = state.fieldText([2]time.Time{*from, *to}, func() string {
+			if empty {
+				return attrs.Placeholder
+			}
+			return from.Format(attrs.Format) + " – " + to.Format(attrs.Format)
+		})
+		pickerField(state, text, empty, SymCalendar, open, keys, func() {
+			// while picking, the range goes from the first day to the one
+			// under the mouse or the keyboard cursor
+			var first, last = dateOnly(*from), dateOnly(*to)
+			if !state.anchor.IsZero() {
+				var end = state.cursor
+				if !state.hover.IsZero() {
+					end = state.hover
+				}
+				first, last = state.anchor, end
+				if last.Before(first) {
+					first, last = last, first
+				}
+			} else if empty {
+				first, last = time.Time{}, time.Time{}
+			}
+			monthGrid(state, &attrs, func(day time.Time) (bool, bool) {
+				if first.IsZero() {
+					return false, false
+				}
+				var selected = day.Equal(first) || day.Equal(last)
+				return selected, day.After(first) && day.Before(last)
+			})
+			var hint = "Pick the first day"
+			if !state.anchor.IsZero() {
+				hint = "Pick the last day"
+			}
+			Label(hint, Sz(11), Clr(0, 0, 40, 1))
+		})
+	})
+	return changed
+}
+
+type TimePickerAttrs struct {
+	MinuteStep int    // of the minutes offered, and of Up/Down in the field
+	Format     string // for the field (see time.Layout)
+}
+
+func DefaultTimePickerAttrs() TimePickerAttrs {
+	return TimePickerAttrs{
+		MinuteStep: 5,
+		Format:     "15:04",
+	}
+}
+
+// TimePicker is a field that opens grids of hours and minutes; returns true
+// when the value changes. The date of the value is kept.
+//
+// In the field, Up/Down change the minutes by the step and PageUp/PageDown
+// change the hour.
+func TimePicker(value *time.Time) bool {
+	return TimePickerExt(value, DefaultTimePickerAttrs())
+}
+
+func TimePickerExt(value *time.Time, attrs TimePickerAttrs) bool {
+	if attrs.MinuteStep <= 0 || attrs.MinuteStep > 30 {
+		attrs.MinuteStep = 5
+	}
+	var changed bool
+	Layout(TW(), func() {
+		var state = Use[pickerState]("time-picker")
+
+		set := func(hour, minute int) {
+			var v = *value
+			var next = time.Date(v.Year(), v.Month(), v.Day(), hour, minute, 0, 0, v.Location())
+			if !next.Equal(*value) {
+				*value = next
+				changed = true
+			}
+		}
+		if picked, ok := state.takePick(); ok {
+			set(picked.Hour(), picked.Minute())
+		}
+		// steps of the minutes carry over into the hours, within the day
+		step := func(minutes int) {
+			var total = value.Hour()*60 + value.Minute() + minutes
+			total = (total%(24*60) + 24*60) % (24 * 60)
+			set(total/60, total%60)
+		}
+		keys := func() {
+			switch FrameInput.Key {
+			case KeyUp:
+				step(attrs.MinuteStep)
+			case KeyDown:
+				step(-attrs.MinuteStep)
+			case KeyPageUp:
+				step(60)
+			case KeyPageDown:
+				step(-60)
+			case KeyEnter:
+				state.open = false
+			}
+		}
+
+		var text = state.fieldText([2]time.Time{*value}, func() string {
+			return value.Format(attrs.Format)
+		})
+
+		const cell = 28
+		grid := func(label string, values []int, columns int, current int, pick func(v int)) {
+			Label(label, Sz(11), Clr(0, 0, 40, 1))
+			for row := 0; row*columns < len(values); row++ {
+				Layout(TW(Row), func() {
+					for _, v := range values[row*columns : min(len(values), (row+1)*columns)] {
+						Layout(TW(FixSize(cell, cell), Center, BR(3)), func() {
+							var color = Vec4{0, 0, 10, 1}
+							if IsClicked() {
+								pick(v)
+							}
+							switch {
+							case v == current:
+								ModAttrs(BG(210, 70, 50, 1))
+								color = Vec4{0, 0, 100, 1}
+							case IsHovered():
+								ModAttrs(BG(0, 0, 0, 0.08))
+							}
+							Label(twoDigits[v], Sz(12), ClrV(color))
+						})
+					}
+				})
+			}
+		}
+
+		pickerField(state, text, false, SymClock, func() {}, keys, func() {
+			var hours = make([]int, 24)
+			for h := range hours {
+				hours[h] = h
+			}
+			var minutes []int
+			for m := 0; m < 60; m += attrs.MinuteStep {
+				minutes = append(minutes, m)
+			}
+			// the hour and minute of a pick; only those are used from it
+			var picked = *value
+			if state.hasPick {
+				picked = state.picked
+			}
+			grid("Hour", hours, 6, picked.Hour(), func(h int) {
+				state.deferPick(time.Date(2000, 1, 1, h, picked.Minute(), 0, 0, time.UTC))
+			})
+			grid("Minute", minutes, 6, picked.Minute(), func(m int) {
+				state.deferPick(time.Date(2000, 1, 1, picked.Hour(), m, 0, 0, time.UTC))
+				state.open = false
+			})
+		})
+	})
+	return changed
+}
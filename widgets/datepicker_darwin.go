@@ -0,0 +1,16 @@
+package widgets
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// the region setting, e.g. en_US or en_US@rg=gbzzzz; GUI apps don't get LANG
+var platformLocale = sync.OnceValue(func() string {
+	out, err := exec.Command("defaults", "read", "-g", "AppleLocale").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+})
@@ -0,0 +1,6 @@
+//go:build !darwin && !windows
+
+package widgets
+
+// elsewhere the environment is all there is
+func platformLocale() string { return "" }
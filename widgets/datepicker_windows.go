@@ -0,0 +1,21 @@
+package widgets
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// the user's locale from the region settings, e.g. en-US
+var platformLocale = sync.OnceValue(func() string {
+	var buf [85]uint16 // LOCALE_NAME_MAX_LENGTH
+	var proc = syscall.NewLazyDLL("kernel32.dll").NewProc("GetUserDefaultLocaleName")
+	if proc.Find() != nil {
+		return ""
+	}
+	n, _, _ := proc.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buf[:])
+})
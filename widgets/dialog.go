@@ -0,0 +1,195 @@
+package widgets
+
+import (
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type DialogResult uint8
+
+const (
+	DialogPending DialogResult = iota
+	DialogAccepted
+	DialogCancelled
+)
+
+type DialogAttrs struct {
+	Title string
+	Width f32 // minimum width of the dialog
+
+	AcceptOnEnter   bool
+	CloseOnBackdrop bool // clicking outside the dialog cancels it
+}
+
+func DefaultDialogAttrs() DialogAttrs {
+	return DialogAttrs{
+		Width:         320,
+		AcceptOnEnter: true,
+	}
+}
+
+type dialogKey struct{ open *bool }
+
+type dialogState struct {
+	result       DialogResult
+	restoreFocus any // what had focus before the dialog opened
+}
+
+var dialogs = make(map[*bool]*dialogState)
+
+// the top dialog is the last one rendered; only it gets the keyboard
+var dialogTop, dialogTopNext *bool
+var dialogFrame int64
+
+// the dialog whose content is being drawn
+var dialogCurrent *bool
+
+// the top dialog as of the last frame, before or after this frame's popups ran
+func topDialog() *bool {
+	switch dialogFrame {
+	case FrameNumber:
+		return dialogTop
+	case FrameNumber - 1:
+		return dialogTopNext
+	}
+	return nil
+}
+
+// ModalActive reports whether an open dialog takes the keyboard away from
+// the caller, i.e. the caller isn't drawing the top dialog's content. Global
+// key handlers should ignore the keys while it's true; Accelerator does.
+func ModalActive() bool {
+	var top = topDialog()
+	return top != nil && *top && dialogCurrent != top
+}
+
+// Dialog shows a modal dialog while *open is true. The content under it is
+// dimmed and doesn't get any input, and Tab cycles focus within the dialog.
+// Shortcuts don't fire while it's open (see ModalActive).
+// Dialogs opened from inside a dialog are stacked on top of it.
+//
+// Escape cancels the dialog and Enter accepts it (see DialogAttrs); content
+// can close it with CloseDialog. Closing sets *open to false, and the result
+// is returned on the next frame, otherwise DialogPending is returned.
+//
+// Like other popups, dialogs only show up when PopupsHost is called.
+func Dialog(open *bool, attrs DialogAttrs, fn func()) DialogResult {
+	var state = dialogs[open]
+	if !*open {
+		delete(dialogs, open)
+		if state != nil {
+			return state.result
+		}
+		return DialogPending
+	}
+	if state == nil {
+		state = &dialogState{restoreFocus: FocusedId()}
+		dialogs[open] = state
+	}
+	state.result = DialogPending
+
+	Popup(func() {
+		if dialogFrame != FrameNumber {
+			dialogTop = nil
+			if dialogFrame == FrameNumber-1 {
+				dialogTop = dialogTopNext
+			}
+			dialogFrame = FrameNumber
+			dialogTopNext = nil
+		}
+		dialogTopNext = open
+
+		// the backdrop covers everything drawn before it, so it takes all the hovers
+		Layout(TW(NoAnimate, Float(0, 0), FixSizeV(WindowSize), BG(0, 0, 0, 0.3), Center), func() {
+			if attrs.CloseOnBackdrop && IsClicked() && IsHoveredDirectly() {
+				CloseDialog(open, DialogCancelled)
+			}
+
+			LayoutId(dialogKey{open}, TW(Focusable, MinWidth(attrs.Width), Pad(16), Gap(12), BR(6), BG(0, 0, 100, 1), Shd(20)), func() {
+				var first = FirstRender()
+				if first {
+					FocusImmediateOn(nil) // lets the content auto focus
+				}
+				TrapFocus()
+				CycleFocusOnTab()
+
+				if attrs.Title != "" {
+					Label(attrs.Title, Sz(16), FontWeight(WeightSemibold))
+				}
+				var outer = dialogCurrent
+				dialogCurrent = open
+				fn()
+				dialogCurrent = outer
+
+				if first {
+					AutoFocus()
+				} else if dialogTop == open && !HasFocusWithin() {
+					Focus()
+				}
+
+				if dialogTop == open && InputState.Modifiers == 0 {
+					switch FrameInput.Key {
+					case KeyEscape:
+						CloseDialog(open, DialogCancelled)
+					case KeyEnter:
+						if attrs.AcceptOnEnter {
+							CloseDialog(open, DialogAccepted)
+						}
+					}
+				}
+			})
+		})
+	})
+
+	return DialogPending
+}
+
+// CloseDialog closes a dialog; the result is returned by Dialog on the next frame
+func CloseDialog(open *bool, result DialogResult) {
+	if !*open {
+		return
+	}
+	*open = false
+	if state := dialogs[open]; state != nil {
+		state.result = result
+		FocusImmediateOn(state.restoreFocus)
+	}
+	RequestNextFrame()
+}
+
+// dialogButtons draws a right aligned row with cancel and accept buttons
+func dialogButtons(open *bool, accept string) {
+	Layout(TW(Row, Expand, MA(AlignEnd), Gap(8)), func() {
+		if Button(0, "Cancel") {
+			CloseDialog(open, DialogCancelled)
+		}
+		if ButtonExt(accept, ButtonAttrs{Primary: true}) {
+			CloseDialog(open, DialogAccepted)
+		}
+	})
+}
+
+// Confirm shows a message with OK and Cancel buttons while *open is true
+func Confirm(open *bool, title string, message string) DialogResult {
+	var attrs = DefaultDialogAttrs()
+	attrs.Title = title
+	return Dialog(open, attrs, func() {
+		Label(message, Sz(13))
+		dialogButtons(open, "OK")
+	})
+}
+
+// Prompt shows a message with a text input editing *value while *open is true
+func Prompt(open *bool, title string, message string, value *string) DialogResult {
+	var attrs = DefaultDialogAttrs()
+	attrs.Title = title
+	return Dialog(open, attrs, func() {
+		if message != "" {
+			Label(message, Sz(13))
+		}
+		Layout(TW(Expand), func() {
+			TextInput(value)
+		})
+		dialogButtons(open, "OK")
+	})
+}
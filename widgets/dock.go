@@ -0,0 +1,453 @@
+package widgets
+
+import (
+	"encoding/json"
+	"slices"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+// DockNode is a node in the layout of a dock space: either a leaf showing its
+// panels as tabs, or a split of two nodes
+type DockNode struct {
+	Panels []string `json:"panels,omitempty"`
+	Active int      `json:"active,omitempty"`
+
+	Row    bool       `json:"row,omitempty"`
+	Split  SplitState `json:"split,omitzero"`
+	First  *DockNode  `json:"first,omitempty"`
+	Second *DockNode  `json:"second,omitempty"`
+}
+
+func (n *DockNode) IsSplit() bool {
+	return n.First != nil && n.Second != nil
+}
+
+// a floating window, positioned relative to the dock space
+type DockFloat struct {
+	Node *DockNode `json:"node"`
+	Rect Rect      `json:"rect"`
+}
+
+// DockLayout is kept by the application and passed to DockSpace every frame;
+// see Save and LoadDockLayout to persist it
+type DockLayout struct {
+	Root     *DockNode    `json:"root"`
+	Floating []*DockFloat `json:"floating,omitempty"`
+}
+
+type DockPanel struct {
+	Id       string // identifies the panel in the layout, and the state of its view
+	Title    string
+	Icon     rune
+	Closable bool
+	View     func()
+}
+
+type DockAttrs struct {
+	Tabs      TabsAttrs
+	Split     SplitAttrs
+	FloatSize Vec2 // of new floating windows
+}
+
+func DefaultDockAttrs() DockAttrs {
+	var split = DefaultSplitAttrs()
+	split.MinFirst = 60
+	split.MinSecond = 60
+	return DockAttrs{
+		Tabs:      DefaultTabsAttrs(),
+		Split:     split,
+		FloatSize: Vec2{360, 260},
+	}
+}
+
+// NewDockLayout puts all the given panels as tabs in a single leaf
+func NewDockLayout(panels ...string) *DockLayout {
+	return &DockLayout{Root: &DockNode{Panels: panels}}
+}
+
+func (l *DockLayout) Save() ([]byte, error) {
+	return json.MarshalIndent(l, "", "  ")
+}
+
+// LoadDockLayout restores a layout saved with Save; panels it mentions that
+// are not passed to DockSpace are dropped from it
+func LoadDockLayout(data []byte) (*DockLayout, error) {
+	var l DockLayout
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	l.prune()
+	return &l, nil
+}
+
+// calls fn for every leaf, docked and floating
+func (l *DockLayout) leaves(fn func(leaf *DockNode)) {
+	var visit func(n *DockNode)
+	visit = func(n *DockNode) {
+		if n == nil {
+			return
+		}
+		if n.IsSplit() {
+			visit(n.First)
+			visit(n.Second)
+		} else {
+			fn(n)
+		}
+	}
+	visit(l.Root)
+	for _, fl := range l.Floating {
+		visit(fl.Node)
+	}
+}
+
+func (l *DockLayout) find(id string) (leaf *DockNode, index int) {
+	index = -1
+	l.leaves(func(n *DockNode) {
+		if i := slices.Index(n.Panels, id); i >= 0 && leaf == nil {
+			leaf, index = n, i
+		}
+	})
+	return leaf, index
+}
+
+func (l *DockLayout) Contains(id string) bool {
+	leaf, _ := l.find(id)
+	return leaf != nil
+}
+
+// Show activates a panel, adding it to the first docked leaf if it's not in
+// the layout
+func (l *DockLayout) Show(id string) {
+	if leaf, index := l.find(id); leaf != nil {
+		leaf.Active = index
+		return
+	}
+	if l.Root == nil {
+		l.Root = &DockNode{}
+	}
+	var leaf = l.Root
+	for leaf.IsSplit() {
+		leaf = leaf.First
+	}
+	leaf.Panels = append(leaf.Panels, id)
+	leaf.Active = len(leaf.Panels) - 1
+}
+
+// Close removes a panel from the layout and forgets the state of its view
+func (l *DockLayout) Close(id string) {
+	if leaf, index := l.find(id); leaf != nil {
+		leaf.remove(index)
+		l.prune()
+	}
+	ForgetHooks(tabContentKey{id})
+}
+
+func (n *DockNode) remove(index int) {
+	n.Panels = slices.Delete(n.Panels, index, index+1)
+	if n.Active > index || n.Active >= len(n.Panels) {
+		n.Active = max(0, n.Active-1)
+	}
+}
+
+// removes empty leaves, replacing splits that are left with one child by the child
+func (l *DockLayout) prune() {
+	var visit func(n *DockNode) *DockNode
+	visit = func(n *DockNode) *DockNode {
+		if n == nil {
+			return nil
+		}
+		if n.First != nil || n.Second != nil {
+			n.First, n.Second = visit(n.First), visit(n.Second)
+			switch {
+			case n.First == nil:
+				return n.Second
+			case n.Second == nil:
+				return n.First
+			}
+			return n
+		}
+		if len(n.Panels) == 0 {
+			return nil
+		}
+		n.Active = max(0, min(n.Active, len(n.Panels)-1))
+		return n
+	}
+
+	l.Root = visit(l.Root)
+	if l.Root == nil {
+		l.Root = &DockNode{}
+	}
+	l.Floating = slices.DeleteFunc(l.Floating, func(fl *DockFloat) bool {
+		fl.Node = visit(fl.Node)
+		return fl.Node == nil
+	})
+}
+
+type dockSide uint8
+
+const (
+	dockCenter dockSide = iota
+	dockLeft
+	dockRight
+	dockTop
+	dockBottom
+)
+
+type dockZoneKey struct {
+	leaf *DockNode
+	side dockSide
+}
+
+// moves a panel into a leaf: as a tab at index (or the end when -1) for
+// dockCenter, or splitting the leaf for the other sides
+func (l *DockLayout) dock(id string, target *DockNode, side dockSide, index int) {
+	src, srcIndex := l.find(id)
+	if src == nil || (src == target && side == dockCenter) {
+		return
+	}
+	src.remove(srcIndex)
+
+	if side == dockCenter {
+		if index < 0 || index > len(target.Panels) {
+			index = len(target.Panels)
+		}
+		target.Panels = slices.Insert(target.Panels, index, id)
+		target.Active = index
+	} else {
+		var moved = &DockNode{Panels: []string{id}}
+		var old = *target
+		*target = DockNode{Row: side == dockLeft || side == dockRight}
+		if side == dockLeft || side == dockTop {
+			target.First, target.Second = moved, &old
+		} else {
+			target.First, target.Second = &old, moved
+		}
+	}
+	l.prune()
+}
+
+func (l *DockLayout) float(id string, rect Rect) {
+	src, srcIndex := l.find(id)
+	if src == nil {
+		return
+	}
+	src.remove(srcIndex)
+	l.Floating = append(l.Floating, &DockFloat{
+		Node: &DockNode{Panels: []string{id}},
+		Rect: rect,
+	})
+	l.prune()
+}
+
+// the panel whose tab is being dragged, if any
+func (l *DockLayout) draggedPanel() string {
+	key, ok := GetDraggingItem[tabKey]()
+	if !ok {
+		return ""
+	}
+	id, _ := key.id.(string)
+	if id == "" || !l.Contains(id) {
+		return ""
+	}
+	return id
+}
+
+// DockSpace shows the panels in the layout. Panels are moved by dragging their
+// tabs: to the tab bar of another leaf, to the middle of a leaf to add them as
+// a tab, to its edges to split it, or anywhere else to float them in a window.
+func DockSpace(layout *DockLayout, panels []DockPanel, attrs DockAttrs) {
+	var byId = make(map[string]*DockPanel, len(panels))
+	for i := range panels {
+		byId[panels[i].Id] = &panels[i]
+	}
+
+	// drop panels that are not passed in (e.g. from an old saved layout)
+	layout.leaves(func(leaf *DockNode) {
+		leaf.Panels = slices.DeleteFunc(leaf.Panels, func(id string) bool {
+			return byId[id] == nil
+		})
+	})
+	layout.prune()
+
+	var origin Vec2
+
+	// the tab being dragged is dropped on the release frame, before anything
+	// gets laid out, using the drop target found in the previous frame
+	if id := layout.draggedPanel(); id != "" && FrameInput.Mouse == MouseRelease {
+		var ds = GetDraggingState()
+		switch target := ds.DropTarget.(type) {
+		case dockZoneKey:
+			layout.dock(id, target.leaf, target.side, -1)
+		case tabKey:
+			if targetId, ok := target.id.(string); ok {
+				if leaf, index := layout.find(targetId); leaf != nil {
+					if src, _ := layout.find(id); src != leaf {
+						layout.dock(id, leaf, dockCenter, index)
+					}
+				}
+			}
+		case nil:
+			// only float tabs that were actually moved, not clicked
+			var moved = Vec2Sub(GetDraggingItemRect().Origin, GetResolvedRectOf(tabKey{id}).Origin)
+			if max(moved[0], -moved[0], moved[1], -moved[1]) > 8 {
+				var spaceOrigin = GetResolvedRectOf(dockSpaceKey{layout}).Origin
+				var pos = Vec2Sub(InputState.MousePoint, spaceOrigin)
+				layout.float(id, Rect{Origin: Vec2Sub(pos, Vec2{20, 10}), Size: attrs.FloatSize})
+			}
+		}
+	}
+
+	var dragging = layout.draggedPanel()
+	var closing string
+	var raise = -1
+
+	LayoutId(dockSpaceKey{layout}, TW(Viewport), func() {
+		origin = GetRenderData().ResolvedOrigin
+		var spaceSize = GetResolvedSize()
+
+		layout.nodeView(layout.Root, byId, &attrs, dragging, &closing)
+
+		for index, fl := range layout.Floating {
+			var r = &fl.Rect
+			// keep the title bar reachable
+			r.Origin[0] = max(0, min(r.Origin[0], spaceSize[0]-40))
+			r.Origin[1] = max(0, min(r.Origin[1], spaceSize[1]-20))
+
+			Layout(TW(NoAnimate, InFront, FloatV(r.Origin), FixSizeV(r.Size), BG(0, 0, 100, 1), BW(1), Bo(0, 0, 60, 1), BR(4), Shd(8), Clip), func() {
+				if IsClicked() && index != len(layout.Floating)-1 {
+					raise = index
+				}
+
+				// title strip to move the window
+				Layout(TW(Expand, FixHeight(8), BG(0, 0, 75, 1)), func() {
+					PressAction()
+					if IsActive() {
+						r.Origin = Vec2Add(r.Origin, FrameInput.Motion)
+					}
+					if IsHovered() || IsActive() {
+						ModAttrs(BG(210, 50, 70, 1))
+					}
+				})
+
+				layout.nodeView(fl.Node, byId, &attrs, dragging, &closing)
+
+				// resize grip
+				Layout(TW(NoAnimate, InFront, Float(r.Size[0]-12, r.Size[1]-12), FixSize(12, 12)), func() {
+					PressAction()
+					if IsActive() {
+						r.Size = Vec2Add(r.Size, FrameInput.Motion)
+						r.Size[0] = max(r.Size[0], 120)
+						r.Size[1] = max(r.Size[1], 80)
+					}
+					var alpha f32 = 0.2
+					if IsHovered() || IsActive() {
+						alpha = 0.5
+					}
+					Element(TW(NoAnimate, Float(4, 10), FixSize(8, 2), BG(0, 0, 0, alpha)))
+					Element(TW(NoAnimate, Float(10, 4), FixSize(2, 8), BG(0, 0, 0, alpha)))
+				})
+			})
+		}
+
+		// the title of the dragged panel follows the mouse
+		if panel := byId[dragging]; panel != nil {
+			var pos = Vec2Add(Vec2Sub(InputState.MousePoint, origin), Vec2{12, 12})
+			Layout(TW(NoAnimate, InFront, ClickThrough, FloatV(pos), Pad2(4, 8), BR(4), BG(0, 0, 100, 0.9), Shd(4)), func() {
+				Label(panel.Title, Sz(attrs.Tabs.TextSize))
+			})
+		}
+	})
+
+	if raise >= 0 {
+		fl := layout.Floating[raise]
+		layout.Floating = append(slices.Delete(layout.Floating, raise, raise+1), fl)
+	}
+	if closing != "" {
+		layout.Close(closing)
+	}
+}
+
+type dockSpaceKey struct{ layout *DockLayout }
+
+func (l *DockLayout) nodeView(node *DockNode, panels map[string]*DockPanel, attrs *DockAttrs, dragging string, closing *string) {
+	if node.IsSplit() {
+		SplitPane(&node.Split, SplitAttrs{
+			Row:         node.Row,
+			DividerSize: attrs.Split.DividerSize,
+			MinFirst:    attrs.Split.MinFirst,
+			MaxFirst:    attrs.Split.MaxFirst,
+			MinSecond:   attrs.Split.MinSecond,
+			MaxSecond:   attrs.Split.MaxSecond,
+		}, func() {
+			l.nodeView(node.First, panels, attrs, dragging, closing)
+		}, func() {
+			l.nodeView(node.Second, panels, attrs, dragging, closing)
+		})
+		return
+	}
+
+	Layout(TW(Viewport), func() {
+		var leafOrigin = GetRenderData().ResolvedOrigin
+		var contentRect = Rect{Size: GetResolvedSize()}
+
+		if len(node.Panels) == 0 {
+			Layout(TW(Viewport, Center), func() {
+				Label("Drag panels here", Sz(attrs.Tabs.TextSize), Clr(0, 0, 50, 1))
+			})
+		} else {
+			var tabs = make([]TabItem, len(node.Panels))
+			for i, id := range node.Panels {
+				p := panels[id]
+				tabs[i] = TabItem{Id: id, Title: p.Title, Icon: p.Icon, Closable: p.Closable}
+			}
+			var closed = TabsView(&node.Active, &tabs, attrs.Tabs, func(tab TabItem) {
+				if view := panels[tab.Id.(string)].View; view != nil {
+					view()
+				}
+			})
+			// tabs may have been reordered
+			for i := range tabs {
+				node.Panels[i] = tabs[i].Id.(string)
+			}
+			if closed >= 0 {
+				*closing = node.Panels[closed]
+			}
+
+			// drop zones cover the content, below the tab bar
+			var r = GetResolvedRectOf(tabContentKey{node.Panels[node.Active]})
+			if r.Size[0] > 0 {
+				contentRect = Rect{Origin: Vec2Sub(r.Origin, leafOrigin), Size: r.Size}
+			}
+		}
+
+		if dragging == "" {
+			return
+		}
+
+		var o, w, h = contentRect.Origin, contentRect.Size[0], contentRect.Size[1]
+		type zone struct {
+			side    dockSide
+			hit     Rect // where to drop
+			preview Rect // where the panel would go
+		}
+		rect := func(x, y, w, h f32) Rect {
+			return Rect{Origin: Vec2{x, y}, Size: Vec2{w, h}}
+		}
+		var zones = [...]zone{
+			{dockLeft, rect(0, 0, w/4, h), rect(0, 0, w/2, h)},
+			{dockRight, rect(w*3/4, 0, w/4, h), rect(w/2, 0, w/2, h)},
+			{dockTop, rect(w/4, 0, w/2, h/4), rect(0, 0, w, h/2)},
+			{dockBottom, rect(w/4, h*3/4, w/2, h/4), rect(0, h/2, w, h/2)},
+			{dockCenter, rect(w/4, h/4, w/2, h/2), rect(0, 0, w, h)},
+		}
+		for _, z := range zones {
+			LayoutId(dockZoneKey{node, z.side}, TW(NoAnimate, InFront, FloatV(Vec2Add(o, z.hit.Origin)), FixSizeV(z.hit.Size)), func() {
+				if CanDropHere[tabKey]() {
+					Element(TW(NoAnimate, InFront, ClickThrough, FloatV(Vec2Sub(z.preview.Origin, z.hit.Origin)), FixSizeV(z.preview.Size), BG(210, 70, 50, 0.2), BW(2), Bo(210, 70, 50, 0.8)))
+				}
+			})
+		}
+	})
+}
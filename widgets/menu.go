@@ -1,14 +1,288 @@
 package widgets
 
 import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
 	. "go.hasen.dev/shirei"
 	. "go.hasen.dev/shirei/tw"
 )
 
-var _menuItemPressed bool
-
 var _menuBG = Vec4{220, 20, 94, 1}
 
+// the open menu; only one menu (with its submenus) is open at a time
+var menus = struct {
+	owner any  // the element that opened the menu; nil when closed
+	point Vec2 // where a context menu was opened
+
+	path []int // open submenus: path[l] is the item at level l whose submenu is open
+	hot  []int // highlighted item at each level; -1 for none, -2 to pick the first one
+
+	// collected while drawing, for the keyboard and for clicks outside
+	items  [][]menuItemInfo
+	panels []any
+	level  int // level being drawn, -1 outside of menus
+
+	activate [2]int // level and index of an item activated with the keyboard
+
+	mnemonics bool // underline mnemonics, for menus opened from the keyboard
+
+	// hovering an item while moving towards an open submenu doesn't switch
+	// to it right away; see the safe triangle in hoverItem
+	pending      [2]int
+	pendingSince time.Time
+}{level: -1, activate: [2]int{-1, -1}, pending: [2]int{-1, -1}}
+
+type menuItemInfo struct {
+	disabled bool
+	submenu  bool
+	mnemonic rune // upper case
+}
+
+type menuPanelKey struct{ level int }
+
+const menuSubmenuDelay = time.Millisecond * 300
+
+func openMenu(owner any, point Vec2) {
+	menus.owner = owner
+	menus.point = point
+	menus.path = menus.path[:0]
+	menus.hot = append(menus.hot[:0], -1)
+	menus.panels = menus.panels[:0]
+	menus.pending = [2]int{-1, -1}
+	menus.mnemonics = false
+}
+
+func closeMenu() {
+	menus.owner = nil
+	RequestNextFrame()
+}
+
+// IsMenuOpen reports whether the current element has its menu open (see
+// ContextMenu and MenuButton)
+func IsMenuOpen() bool {
+	return menus.owner != nil && menus.owner == CurrentId()
+}
+
+func menuIsHovered() bool {
+	return slices.ContainsFunc(menus.panels, IdIsHovered)
+}
+
+func (info menuItemInfo) selectable() bool {
+	return !info.disabled
+}
+
+func setMenuHot(level int, index int) {
+	for len(menus.hot) <= level {
+		menus.hot = append(menus.hot, -1)
+	}
+	menus.hot[level] = index
+}
+
+func menuHot(level int) int {
+	if level < len(menus.hot) {
+		return menus.hot[level]
+	}
+	return -1
+}
+
+// the next selectable item from index in the given direction, wrapping around
+func menuNextItem(items []menuItemInfo, index int, dir int) int {
+	var count = len(items)
+	for range count {
+		index = ((index+dir)%count + count) % count
+		if items[index].selectable() {
+			return index
+		}
+	}
+	return -1
+}
+
+func openSubmenu(level int, index int, keyboard bool) {
+	menus.path = append(menus.path[:level], index)
+	var hot = -1
+	if keyboard {
+		hot = -2
+	}
+	setMenuHot(level+1, hot)
+}
+
+func menuKeyboard() {
+	menus.activate = [2]int{-1, -1}
+	var level = min(len(menus.path), len(menus.items)-1)
+	if level < 0 {
+		return
+	}
+	var items = menus.items[level]
+	var hot = menuHot(level)
+	var valid = hot >= 0 && hot < len(items) && items[hot].selectable()
+
+	switch FrameInput.Key {
+	case KeyDown:
+		setMenuHot(level, menuNextItem(items, hot, 1))
+	case KeyUp:
+		if hot < 0 {
+			hot = 0
+		}
+		setMenuHot(level, menuNextItem(items, hot, -1))
+	case KeyRight:
+		if valid && items[hot].submenu {
+			openSubmenu(level, hot, true)
+		}
+	case KeyLeft:
+		if level > 0 {
+			menus.path = menus.path[:level-1]
+		}
+	case KeyEnter, KeySpace:
+		if valid && items[hot].submenu {
+			openSubmenu(level, hot, true)
+		} else if valid {
+			menus.activate = [2]int{level, hot}
+		}
+	case KeyEscape:
+		if level > 0 {
+			menus.path = menus.path[:level-1]
+		} else {
+			closeMenu()
+		}
+	default:
+		// mnemonics, with or without alt
+		var mod = InputState.Modifiers &^ ModShift
+		if FrameInput.Key == KeyCodeNone || (mod != 0 && mod != ModAlt) {
+			break
+		}
+		var index = slices.IndexFunc(items, func(info menuItemInfo) bool {
+			return info.selectable() && info.mnemonic != 0 && info.mnemonic == rune(FrameInput.Key)
+		})
+		if index >= 0 {
+			setMenuHot(level, index)
+			if items[index].submenu {
+				openSubmenu(level, index, true)
+			} else {
+				menus.activate = [2]int{level, index}
+			}
+		}
+	}
+}
+
+// the mouse moved over an item
+func hoverItem(level int, index int, submenu bool) {
+	// the mouse may cross other items on its way to an open submenu; don't
+	// close it while moving inside the triangle between the previous mouse
+	// position and the near edge of the submenu, unless it rests there
+	var current = [2]int{level, index}
+	if len(menus.path) > level && menus.path[level] != index && len(menus.panels) > level+1 {
+		var due = menus.pending == current && time.Since(menus.pendingSince) >= menuSubmenuDelay
+		var sub = GetResolvedRectOf(menus.panels[level+1])
+		var to = InputState.MousePoint
+		var from = Vec2Sub(to, FrameInput.Motion)
+		var edgeX = sub.Origin[0]
+		if edgeX < from[0] {
+			edgeX += sub.Size[0]
+		}
+		var a = Vec2{edgeX, sub.Origin[1]}
+		var b = Vec2{edgeX, sub.Origin[1] + sub.Size[1]}
+		if !due && FrameInput.Motion != (Vec2{}) && pointInTriangle(to, from, a, b) {
+			if menus.pending != current {
+				menus.pending = current
+				menus.pendingSince = time.Now()
+			}
+			RequestFrameAfter(menuSubmenuDelay)
+			return
+		}
+	}
+
+	menus.pending = [2]int{-1, -1}
+	setMenuHot(level, index)
+	if len(menus.path) > level && menus.path[level] == index {
+		return
+	}
+	menus.path = menus.path[:min(level, len(menus.path))]
+	if submenu {
+		openSubmenu(level, index, false)
+	}
+}
+
+func pointInTriangle(p, a, b, c Vec2) bool {
+	side := func(p1, p2, p3 Vec2) f32 {
+		return (p1[0]-p3[0])*(p2[1]-p3[1]) - (p2[0]-p3[0])*(p1[1]-p3[1])
+	}
+	d1, d2, d3 := side(p, a, b), side(p, b, c), side(p, c, a)
+	var neg = d1 < 0 || d2 < 0 || d3 < 0
+	var pos = d1 > 0 || d2 > 0 || d3 > 0
+	return !(neg && pos)
+}
+
+// draws the panel of a menu level; place is called inside the panel to
+// position it, so it can use GetResolvedSize
+func menuPanel(level int, place func() Vec2, fn func()) {
+	if level == 0 {
+		menuKeyboard()
+		menus.panels = menus.panels[:0]
+	}
+	for len(menus.items) <= level {
+		menus.items = append(menus.items, nil)
+	}
+	menus.items[level] = menus.items[level][:0]
+
+	LayoutId(menuPanelKey{level}, TW(NoAnimate, MinWidth(120), MaxWidth(600), Pad2(6, 0), Gap(2), BR(4), BGV(_menuBG), BW(1), Bo(0, 0, 10, 0.8), Shd(14), Clip), func() {
+		ModAttrs(FloatV(place()))
+		menus.panels = append(menus.panels[:level], CurrentId())
+
+		var outer = menus.level
+		menus.level = level
+		fn()
+		menus.level = outer
+
+		if menuHot(level) == -2 {
+			// opened from the keyboard: highlight the first item
+			setMenuHot(level, menuNextItem(menus.items[level], -1, 1))
+			RequestNextFrame()
+		}
+	})
+}
+
+// menus.owner is checked before drawing: an item may close the menu
+func menuRoot(owner any, place func() Vec2, fn func()) {
+	Popup(func() {
+		if menus.owner == owner {
+			menuPanel(0, place, fn)
+		}
+	})
+}
+
+// ContextMenu opens a menu at the mouse pointer when the current element is
+// right clicked. When nested elements have context menus, the last one to call
+// ContextMenu wins.
+func ContextMenu(fn func()) {
+	var id = CurrentId()
+	if menus.owner == id && FrameInput.Mouse == MouseClick && !menuIsHovered() {
+		closeMenu()
+	}
+	if IsClicked() && InputState.MouseButton&MouseSecondary != 0 {
+		openMenu(id, InputState.MousePoint)
+	}
+	if menus.owner != id {
+		return
+	}
+	menuRoot(id, func() Vec2 {
+		var size = GetResolvedSize()
+		var pos = menus.point
+		if pos[0]+size[0] > WindowSize[0] {
+			pos[0] -= size[0]
+		}
+		if pos[1]+size[1] > WindowSize[1] {
+			pos[1] -= size[1]
+		}
+		pos[0] = max(0, min(pos[0], WindowSize[0]-size[0]))
+		pos[1] = max(0, min(pos[1], WindowSize[1]-size[1]))
+		return pos
+	}, fn)
+}
+
 func MenuButton(label string, fn func()) {
 	MenuButtonExt(label, ButtonAttrs{
 		Icon: TypArrowSortedDown,
@@ -17,48 +291,22 @@ func MenuButton(label string, fn func()) {
 
 func MenuButtonExt(label string, attrs ButtonAttrs, fn func()) {
 	Layout(TW(), func() {
-		type MenuState struct {
-			open   bool
-			btnId  any
-			menuId any
+		var id = CurrentId()
+		if menus.owner == id && FrameInput.Mouse == MouseClick && !IsHovered() && !menuIsHovered() {
+			closeMenu()
 		}
-		var state = Use[MenuState]("menu-state")
 		if ButtonExt(label, attrs) {
-			state.open = !state.open
-		}
-
-		if state.open && _menuItemPressed {
-			_menuItemPressed = false
-			state.open = false
-		}
-
-		state.btnId = GetLastId()
-
-		if state.open {
-			Popup(func() {
-				LayoutId("action-menu", TW(BR(6), BG(0, 0, 10, 0.2)), func() {
-					ModAttrs(FloatV(_getPositionRelativeTo(state.btnId)))
-
-					state.menuId = CurrentId()
-					ModAttrs(func(a *Attrs) {
-						a.Shadow.Blur = 40
-						a.Shadow.Alpha = 0.3
-					})
-					Layout(TW(MinWidth(100), BR(4), Pad2(6, 0), Gap(2), MaxWidth(600), BGV(_menuBG), BW(1), Bo(0, 0, 10, 0.8), Clip), func() {
-						ModAttrs(func(a *Attrs) {
-							a.Shadow.Blur = 4
-							a.Shadow.Alpha = 0.7
-							a.Shadow.Offset[1] = 2
-						})
-						fn()
-					})
-				})
-			})
+			if menus.owner == id {
+				closeMenu()
+			} else {
+				openMenu(id, Vec2{})
+			}
 		}
-
-		// do this after handling the open menu so that clicks inside the menu can still register!
-		if !IdIsHovered(state.btnId) && !IdIsHovered(state.menuId) && FrameInput.Mouse == MouseClick { // click outside!
-			state.open = false
+		var btnId = GetLastId()
+		if menus.owner == id {
+			menuRoot(id, func() Vec2 {
+				return _getPositionRelativeTo(btnId)
+			}, fn)
 		}
 	})
 }
@@ -86,6 +334,9 @@ func _getPositionRelativeTo(anchorId any) Vec2 {
 }
 
 func MenuSeparator() {
+	if quietMenu.bar != nil {
+		return
+	}
 	Layout(TW(Expand, Pad2(4, 10)), func() {
 		Element(TW(BG(0, 0, 0, 0.5), MinSize(1, 1), Expand))
 		Element(TW(BG(0, 0, 100, 1), MinSize(1, 1), Expand))
@@ -93,48 +344,282 @@ func MenuSeparator() {
 }
 
 func MenuItemLabel(icon rune, label string) {
+	if quietMenu.bar != nil {
+		return
+	}
 	Layout(TW(Row, Expand, CA(AlignMiddle), BGV(_menuBG), Pad2(4, 8), Gap(12)), func() {
 		Icon(icon)
 		Label(label, Sz(12), Clr(0, 0, 10, 1))
 	})
 }
 
+// Labels of menu items can mark a mnemonic letter with '&' (e.g. "&Open");
+// typing it while the menu is open activates the item. Use "&&" for '&'.
+type MenuItemAttrs struct {
+	Icon     rune
+	Disabled bool
+	Primary  bool // the default action, drawn bold
+	Compact  bool // less padding, for dense menus
+	TextSize f32  // 12 when zero
+
+	// accelerator; it fires even when the menu is closed for menus in a
+	// MenuBar, and is shown on the right unless Shortcut is set
+	Combo    KeyCombo
+	Shortcut string // hint shown on the right, e.g. "Ctrl+S"
+}
+
 func MenuItem(icon rune, label string) bool {
-	return MenuItemExt(label, ButtonAttrs{Icon: icon})
+	return MenuAction(label, MenuItemAttrs{Icon: icon})
 }
 
+// MenuItemExt takes the attrs of a button: Ctrl ones are compact
 func MenuItemExt(label string, attrs ButtonAttrs) bool {
+	return MenuAction(label, MenuItemAttrs{
+		Icon:     attrs.Icon,
+		Disabled: attrs.Disabled,
+		Primary:  attrs.Primary,
+		Compact:  attrs.Ctrl,
+		TextSize: attrs.TextSize,
+	})
+}
+
+// MenuAction is MenuItem with the menu-specific attrs: accelerators and
+// shortcut hints
+func MenuAction(label string, attrs MenuItemAttrs) bool {
+	return menuItem(label, attrs, nil)
+}
+
+// MenuCheck is an item that toggles *checked; returns true when toggled
+func MenuCheck(label string, checked *bool) bool {
+	return MenuCheckExt(label, checked, MenuItemAttrs{})
+}
+
+func MenuCheckExt(label string, checked *bool, attrs MenuItemAttrs) bool {
+	attrs.Icon = 0
+	if *checked {
+		attrs.Icon = SymITick
+	}
+	if menuItem(label, attrs, nil) {
+		*checked = !*checked
+		return true
+	}
+	return false
+}
+
+// MenuRadio is an item that sets *selected to value; returns true when selected
+func MenuRadio[T comparable](label string, selected *T, value T) bool {
+	return MenuRadioExt(label, selected, value, MenuItemAttrs{})
+}
+
+func MenuRadioExt[T comparable](label string, selected *T, value T, attrs MenuItemAttrs) bool {
+	attrs.Icon = SymRadioOff
+	if *selected == value {
+		attrs.Icon = SymRadioFull
+	}
+	if menuItem(label, attrs, nil) {
+		*selected = value
+		return true
+	}
+	return false
+}
+
+// SubMenu is an item that opens another menu next to it when hovered
+func SubMenu(icon rune, label string, fn func()) {
+	SubMenuExt(label, MenuItemAttrs{Icon: icon}, fn)
+}
+
+func SubMenuExt(label string, attrs MenuItemAttrs, fn func()) {
+	menuItem(label, attrs, fn)
+}
+
+// the frame a combo last fired in; there's one key press per frame at most
+var comboFiredFrame int64 = -1
+
+// Accelerator reports whether combo was pressed this frame, for shortcuts
+// that aren't menu items. A press fires once: an item with the same combo
+// doesn't fire after Accelerator does, nor the other way. Nothing fires under
+// a modal dialog.
+func Accelerator(combo KeyCombo) bool {
+	if combo.Key == KeyCodeNone || ActiveCombo() != combo || comboFiredFrame == FrameNumber || ModalActive() {
+		return false
+	}
+	comboFiredFrame = FrameNumber
+	return true
+}
+
+func menuItem(label string, attrs MenuItemAttrs, submenu func()) bool {
+	if bar := quietMenu.bar; bar != nil {
+		if submenu != nil {
+			submenu()
+			return false
+		}
+		if attrs.Combo.Key != KeyCodeNone {
+			bar.combos[attrs.Combo] = quietMenu.title
+		}
+		return !attrs.Disabled && Accelerator(attrs.Combo)
+	}
+
+	var text, mnemonic = parseMnemonic(label)
+
+	// outside of menus (e.g. in a PopupPanel) items just respond to clicks
+	var level = menus.level
+	var inMenu = level >= 0
+	var index = -1
+	if inMenu {
+		index = len(menus.items[level])
+		menus.items[level] = append(menus.items[level], menuItemInfo{disabled: attrs.Disabled, submenu: submenu != nil, mnemonic: mnemonicRune(text, mnemonic)})
+	}
+
+	var textSize = attrs.TextSize
+	var iconFns []TextAttrsFn
+	if textSize == 0 {
+		textSize = 12
+	} else {
+		iconFns = append(iconFns, Sz(textSize))
+	}
+	var padding = Pad2(4, 8)
+	if attrs.Compact {
+		padding = Pad2(2, 6)
+	}
+	var weight = WeightNormal
+	if attrs.Primary {
+		weight = WeightBold
+	}
+
 	var action bool
-	Layout(TW(Row, Expand, CA(AlignMiddle), BGV(_menuBG), Pad2(4, 8), Gap(12)), func() {
+	Layout(TW(Row, Expand, CA(AlignMiddle), BGV(_menuBG), padding, Gap(12)), func() {
+		var itemId = CurrentId()
+		var hot bool
+
 		if attrs.Disabled {
 			ModAttrs(Trans(0.2))
+		} else if inMenu {
+			var current = [2]int{level, index}
+			var due = menus.pending == current && time.Since(menus.pendingSince) >= menuSubmenuDelay
+			if IsHovered() && (FrameInput.Motion != (Vec2{}) || due) {
+				hoverItem(level, index, submenu != nil)
+			}
+			action = PressAction() || menus.activate == current
+			hot = menuHot(level) == index
+		} else {
+			action = PressAction()
+			hot = IsHovered()
+		}
+		if !attrs.Disabled && submenu == nil && Accelerator(attrs.Combo) {
+			action = true
 		}
 
-		if !attrs.Disabled {
-			var hovered = IsHovered()
-			action = PressAction()
+		// highlight
+		var bg = Vec4{234, 92, 84, 0}
+		if hot {
+			bg[ALPHA] = 0.8
+		}
+		Element(TW(Float(0, 0), BR(2), MinSizeV(GetResolvedSize()), BGV(bg)))
 
-			// hovering highlight
-			const sp = 0
-			sz := GetResolvedSize()
-			sz[0] -= sp * 2
-			sz[1] -= sp * 2
-			var bg = Vec4{234, 92, 84, 0}
-			if hovered {
-				bg[ALPHA] = 0.8
-			}
-			Element(TW(Float(sp, sp), BR(2), MinSizeV(sz), BGV(bg)))
+		Icon(attrs.Icon, iconFns...)
+		mnemonicLabel(text, mnemonic, TTW(Sz(textSize), FontWeight(weight), Clr(0, 0, 10, 1)))
+
+		var shortcut = attrs.Shortcut
+		if shortcut == "" && attrs.Combo.Key != KeyCodeNone {
+			shortcut = comboText(attrs.Combo)
 		}
+		if shortcut != "" || submenu != nil {
+			Element(TW(Grow(1), MinWidth(12)))
+		}
+		if shortcut != "" {
+			Label(shortcut, Sz(textSize-1), Clr(0, 0, 10, 0.5))
+		}
+		if submenu != nil {
+			Icon(SymRight, Sz(10), Clr(0, 0, 10, 0.6))
 
-		Icon(attrs.Icon)
-		Label(label, Sz(12), Clr(0, 0, 10, 1))
+			if inMenu && len(menus.path) > level && menus.path[level] == index {
+				var itemRect = GetResolvedRectOf(itemId)
+				Popup(func() {
+					menuPanel(level+1, func() Vec2 {
+						return placeSubmenu(itemRect, GetResolvedSize())
+					}, submenu)
+				})
+			}
+		}
 	})
-	if action {
-		_menuItemPressed = true
+
+	if action && submenu != nil {
+		if inMenu {
+			openSubmenu(level, index, menus.activate == [2]int{level, index})
+		}
+		return false
+	}
+	if action && inMenu {
+		closeMenu()
 	}
 	return action
 }
 
+// the label without the '&' marker, and the byte index of the mnemonic in it (or -1)
+func parseMnemonic(label string) (string, int) {
+	var i = strings.IndexByte(label, '&')
+	if i < 0 {
+		return label, -1
+	}
+	if strings.Contains(label, "&&") {
+		// rare; unescaping allocates a new string every frame
+		var text = strings.ReplaceAll(label, "&&", "\x00")
+		var mnemonic = strings.IndexByte(text, '&')
+		text = strings.Replace(text, "&", "", 1)
+		return strings.ReplaceAll(text, "\x00", "&"), mnemonic
+	}
+	return label[:i] + label[i+1:], i
+}
+
+func mnemonicRune(text string, mnemonic int) rune {
+	if mnemonic < 0 || mnemonic >= len(text) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(text[mnemonic:])
+	return unicode.ToUpper(r)
+}
+
+// mnemonics are underlined while alt is held, or when the menu was opened
+// from the keyboard
+func mnemonicLabel(text string, mnemonic int, attrs TextAttrs) {
+	if mnemonic < 0 || mnemonic >= len(text) || (InputState.Modifiers&ModAlt == 0 && !menus.mnemonics) {
+		Text(text, attrs)
+		return
+	}
+	_, size := utf8.DecodeRuneInString(text[mnemonic:])
+	Layout(TW(Row), func() {
+		Text(text[:mnemonic], attrs)
+		Layout(TW(), func() {
+			Text(text[mnemonic:mnemonic+size], attrs)
+			Element(TW(Expand, FixHeight(1), BGV(attrs.Color)))
+		})
+		Text(text[mnemonic+size:], attrs)
+	})
+}
+
+var comboTexts = make(map[KeyCombo]string)
+
+// the strings are kept so shaped text is cached across frames
+func comboText(combo KeyCombo) string {
+	text, ok := comboTexts[combo]
+	if !ok {
+		text = combo.String()
+		comboTexts[combo] = text
+	}
+	return text
+}
+
+// to the right of the item, or to the left if there's no room
+func placeSubmenu(item Rect, size Vec2) Vec2 {
+	var pos = Vec2{item.Origin[0] + item.Size[0], item.Origin[1] - 6}
+	if pos[0]+size[0] > WindowSize[0] {
+		pos[0] = item.Origin[0] - size[0]
+	}
+	pos[0] = max(0, pos[0])
+	pos[1] = max(0, min(pos[1], WindowSize[1]-size[1]))
+	return pos
+}
+
 func PopupPanel(toggle *bool, anchorId any, a Attrs, fn func()) {
 	if *toggle {
 		var selfId any
@@ -0,0 +1,183 @@
+package widgets
+
+import (
+	"slices"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type menuBarState struct {
+	titles    []any  // ids of the menu titles, from the last frame
+	mnemonics []rune // of the titles
+	altDown   bool   // alt was pressed alone; releasing it opens the first menu
+
+	// accelerators of the items, and the title of the menu they're in
+	combos    map[KeyCombo]any
+	collected []any // titles of the menus whose combos are in combos
+	shown     any   // the menu of the bar open this frame
+	wasOpen   any   // and last frame
+}
+
+// set while a bar goes through one of its closed menus, to collect the combos
+// of its items and fire the one pressed; items don't draw anything then
+var quietMenu struct {
+	bar   *menuBarState
+	title any
+}
+
+// runs the menu without drawing it
+func (bar *menuBarState) collect(title any, fn func()) {
+	for combo, owner := range bar.combos {
+		if owner == title {
+			delete(bar.combos, combo)
+		}
+	}
+	if !slices.Contains(bar.collected, title) {
+		bar.collected = append(bar.collected, title)
+	}
+	var outer = quietMenu
+	quietMenu.bar, quietMenu.title = bar, title
+	fn()
+	quietMenu = outer
+}
+
+// the bar whose menus are being drawn
+var currentMenuBar *menuBarState
+
+func (bar *menuBarState) owns(id any) bool {
+	return id != nil && slices.Contains(bar.titles, id)
+}
+
+func (bar *menuBarState) open(index int, keyboard bool) {
+	if index < 0 || index >= len(bar.titles) {
+		return
+	}
+	openMenu(bar.titles[index], Vec2{})
+	if keyboard {
+		menus.hot[0] = -2
+		menus.mnemonics = true
+	}
+	RequestNextFrame()
+}
+
+// MenuBar draws a bar of menus declared with Menu; the menus should only
+// contain menu items. Accelerators of items (see MenuItemAttrs) fire even when
+// the menus are closed: the bar collects them when it first sees a menu and
+// after it's been open, and goes through the menu again when one is pressed.
+//
+// Alt with the mnemonic of a menu opens it (e.g. Alt+F for "&File"), and
+// tapping Alt alone opens the first menu.
+func MenuBar(fn func()) {
+	Layout(TW(Row, Expand, CrossMid, Pad2(2, 4), Gap(2), BG(0, 0, 92, 1)), func() {
+		var bar = Use[menuBarState]("menu-bar")
+		if bar.combos == nil {
+			bar.combos = make(map[KeyCombo]any)
+		}
+		bar.wasOpen, bar.shown = bar.shown, nil
+
+		var current = slices.Index(bar.titles, menus.owner)
+		var count = len(bar.titles)
+
+		// a modal dialog takes the keyboard
+		if ModalActive() {
+			bar.altDown = false
+		} else {
+			// alt alone: open the first menu when it's released
+			switch {
+			case FrameInput.Key == KeyAlt && InputState.Modifiers&^ModAlt == 0:
+				bar.altDown = true
+			case FrameInput.Key != KeyCodeNone || FrameInput.Mouse != 0:
+				bar.altDown = false
+			case bar.altDown && !slices.Contains(InputState.DownKeys, KeyAlt):
+				bar.altDown = false
+				if current >= 0 {
+					closeMenu()
+				} else {
+					bar.open(0, true)
+				}
+			}
+
+			if FrameInput.Key != KeyCodeNone && InputState.Modifiers == ModAlt {
+				if index := slices.Index(bar.mnemonics, rune(FrameInput.Key)); index >= 0 && index != current {
+					bar.open(index, true)
+				}
+			}
+
+			// left and right move between menus, unless a submenu takes them
+			if current >= 0 && count > 0 && len(menus.path) == 0 && InputState.Modifiers == 0 {
+				var hot = menuHot(0)
+				var onSubmenu = len(menus.items) > 0 && hot >= 0 && hot < len(menus.items[0]) && menus.items[0][hot].submenu
+				switch {
+				case FrameInput.Key == KeyLeft:
+					bar.open((current+count-1)%count, true)
+				case FrameInput.Key == KeyRight && !onSubmenu:
+					bar.open((current+1)%count, true)
+				}
+			}
+		}
+
+		bar.titles = bar.titles[:0]
+		bar.mnemonics = bar.mnemonics[:0]
+		var outer = currentMenuBar
+		currentMenuBar = bar
+		fn()
+		currentMenuBar = outer
+	})
+}
+
+// Menu is a menu in a MenuBar; the label can mark a mnemonic with '&'
+func Menu(label string, fn func()) {
+	var bar = currentMenuBar
+	if bar == nil {
+		return
+	}
+
+	var text, mnemonic = parseMnemonic(label)
+	Layout(TW(Pad2(3, 8), BR(3)), func() {
+		var id = CurrentId()
+		bar.titles = append(bar.titles, id)
+		bar.mnemonics = append(bar.mnemonics, mnemonicRune(text, mnemonic))
+
+		if menus.owner != id {
+			var combo = ActiveCombo()
+			var pressed = combo.Key != KeyCodeNone && bar.combos[combo] == id
+			if pressed || bar.wasOpen == id || !slices.Contains(bar.collected, id) {
+				bar.collect(id, fn)
+			}
+		}
+
+		if menus.owner == id && FrameInput.Mouse == MouseClick && !IsHovered() && !menuIsHovered() {
+			closeMenu()
+		}
+		if IsClicked() {
+			if menus.owner == id {
+				closeMenu()
+			} else {
+				openMenu(id, Vec2{})
+			}
+		} else if IsHovered() && menus.owner != id && bar.owns(menus.owner) {
+			// another menu of the bar is open: switch to this one
+			openMenu(id, Vec2{})
+		}
+
+		switch {
+		case menus.owner == id:
+			ModAttrs(BG(210, 60, 80, 1))
+		case IsHovered():
+			ModAttrs(BG(0, 0, 0, 0.08))
+		}
+		mnemonicLabel(text, mnemonic, TTW(Sz(13), Clr(0, 0, 10, 1)))
+
+		if menus.owner == id {
+			bar.shown = id
+			menuRoot(id, func() Vec2 {
+				var title = GetResolvedRectOf(id)
+				var size = GetResolvedSize()
+				var pos = Vec2{title.Origin[0], title.Origin[1] + title.Size[1]}
+				pos[0] = max(0, min(pos[0], WindowSize[0]-size[0]))
+				return pos
+			}, fn)
+		}
+	})
+}
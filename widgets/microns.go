@@ -27,6 +27,13 @@ func Icon(sym rune, fns ...TextAttrsFn) {
 	Label(string(sym), fns...)
 }
 
+// SVGIcon draws an svg where an Icon would go: a square of the text size, with
+// currentColor as the text color
+func SVGIcon(svg *SVG, fns ...TextAttrsFn) {
+	var attrs = TTW(fns...)
+	SVGImageColor(svg, ImageAttrs{Size: Vec2{attrs.Size, attrs.Size}, Fit: FitContain}, attrs.Color)
+}
+
 const SymArrowLeft = '\uE700'
 const SymArrowRight = '\uE701'
 const SymArrowUp = '\uE702'
@@ -21,8 +21,9 @@ func Popup(fn func()) {
 
 func PopupsHost() {
 	_popupsFrameNumber = FrameNumber
-	for _, p := range popups {
-		p()
+	// popups can open more popups (e.g. nested dialogs); they come after, on top
+	for i := 0; i < len(popups); i++ {
+		popups[i]()
 	}
 	g.ResetSlice(&popups)
 }
@@ -177,6 +177,17 @@ type ItemViewFn = func(index int, width f32)
 
 // VirtualListView is virtual list view where items have different heights!
 func VirtualListView(itemCount int, itemIdFn func(int) any, itemHeightFn ItemHeightFn, itemViewFn ItemViewFn) {
+	VirtualListViewExt(VirtualListAttrs{}, itemCount, itemIdFn, itemHeightFn, itemViewFn)
+}
+
+type VirtualListAttrs struct {
+	// when set, scroll just enough to bring the item at RevealIndex into view
+	// (e.g. to follow a keyboard cursor)
+	Reveal      bool
+	RevealIndex int
+}
+
+func VirtualListViewExt(attrs VirtualListAttrs, itemCount int, itemIdFn func(int) any, itemHeightFn ItemHeightFn, itemViewFn ItemViewFn) {
 	/*
 
 		Requirements and constraints:
@@ -306,6 +317,23 @@ func VirtualListView(itemCount int, itemIdFn func(int) any, itemHeightFn ItemHei
 		}
 	}
 
+	// the offset of an item, walking from the anchor if it's close enough
+	itemOffsetOfIndex := func(width f32, avgHeight f32, anchor ItemOffset, index int) ItemOffset {
+		if index < anchor.Index-N*2 || index > anchor.Index+N*2 {
+			return anchorFromOffset(width, avgHeight, avgHeight*f32(index))
+		}
+		var result = anchor
+		for result.Index > index {
+			result.Index--
+			result.Offset -= itemHeightFn(result.Index, width)
+		}
+		for result.Index < index {
+			result.Offset += itemHeightFn(result.Index, width)
+			result.Index++
+		}
+		return result
+	}
+
 	Layout(TW(Viewport, NoAnimate), func() {
 		ScrollOnInput()
 		ScrollBars()
@@ -372,6 +400,22 @@ func VirtualListView(itemCount int, itemIdFn func(int) any, itemHeightFn ItemHei
 			SetScrollOffset(Vec2{0, state.ScrollOffset})
 		}
 
+		if attrs.Reveal && attrs.RevealIndex >= 0 && attrs.RevealIndex < itemCount {
+			target := itemOffsetOfIndex(width, avgHeight, state.Anchor, attrs.RevealIndex)
+			height := itemHeightFn(target.Index, width)
+			desired := state.ScrollOffset
+			if target.Offset < desired {
+				desired = target.Offset
+			} else if target.Offset+height > desired+size[1] {
+				desired = target.Offset + height - size[1]
+			}
+			if desired != state.ScrollOffset {
+				SetScrollOffset(Vec2{0, desired})
+				state.ScrollOffset = GetScrollOffset()[1]
+				state.Anchor = target
+			}
+		}
+
 		first := itemOffsetFromAnchor(width, state.Anchor, state.ScrollOffset)
 
 		// edge case 1 (top)
@@ -0,0 +1,519 @@
+package widgets
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type SelectAttrs[T comparable] struct {
+	// the text shown for an option; fmt.Sprint is used when nil
+	Label func(option T) string
+
+	Placeholder string // shown while the value is not one of the options
+	Width       f32    // of the field; the list is as wide
+	FontSize    f32
+	RowHeight   f32
+	MaxRows     int // shown in the list before it scrolls
+
+	// ComboBox only: accept typed text that doesn't match an option. When
+	// Parse fails, the text goes back to the label of the value.
+	Parse func(text string) (T, bool)
+}
+
+func DefaultSelectAttrs[T comparable]() SelectAttrs[T] {
+	return SelectAttrs[T]{
+		Width:     180,
+		FontSize:  DefaultTextSize,
+		RowHeight: 24,
+		MaxRows:   8,
+	}
+}
+
+// keyed by the state pointer; ids inside the list have to be stable across
+// frames, and a pointer-shaped key keeps them so
+type selectListKey struct{ state *selectState }
+type selectRowKey int
+
+type selectState struct {
+	open    bool
+	hot     int   // index into matches
+	matches []int // indices of the options shown in the list
+	count   int   // of options when matches was computed
+	query   string
+	reveal  bool
+	moved   bool // the hot row was moved with the keyboard
+	pick    int  // option clicked in the list; applied on the next frame
+
+	// the text shaping cache wants the same strings every frame
+	labels map[any]string
+
+	// Select: type-ahead
+	typed   string
+	typedAt time.Time
+
+	// ComboBox: the text in the input, and the last text we saw there
+	text    string
+	seen    string
+	editing bool // text was typed since the value was last shown
+	focused bool
+}
+
+const selectTypeAheadTimeout = time.Second
+
+func selectLabel[T comparable](state *selectState, attrs *SelectAttrs[T], option T, count int) string {
+	if text, ok := state.labels[option]; ok {
+		return text
+	}
+	if state.labels == nil || len(state.labels) > count*2+64 {
+		state.labels = make(map[any]string)
+	}
+	var text string
+	if attrs.Label != nil {
+		text = attrs.Label(option)
+	} else {
+		text = fmt.Sprint(option)
+	}
+	state.labels[option] = text
+	return text
+}
+
+// recompute the matches if the query or the options changed
+func selectFilter[T comparable](state *selectState, attrs *SelectAttrs[T], options []T, query string) {
+	if state.matches != nil && state.count == len(options) && state.query == query {
+		return
+	}
+	state.count = len(options)
+	state.query = query
+	state.matches = state.matches[:0]
+	state.hot = 0
+	state.reveal = true
+	state.moved = false
+
+	if query == "" {
+		for i := range options {
+			state.matches = append(state.matches, i)
+		}
+		return
+	}
+
+	var scores = make(map[int]int)
+	for i, option := range options {
+		if score, ok := fuzzyMatch(query, selectLabel(state, attrs, option, len(options))); ok {
+			state.matches = append(state.matches, i)
+			scores[i] = score
+		}
+	}
+	slices.SortStableFunc(state.matches, func(a, b int) int {
+		return scores[b] - scores[a]
+	})
+}
+
+// fuzzyMatch reports whether the runes of query appear in text in order,
+// ignoring case. The score is higher for matches at the start of words and
+// for consecutive runs, and lower for gaps.
+func fuzzyMatch(query, text string) (int, bool) {
+	var q = []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, true
+	}
+	var score int
+	var qi int
+	var last = -1
+	var prev rune
+	for i, r := range []rune(text) {
+		if qi < len(q) && unicode.ToLower(r) == q[qi] {
+			score++
+			if i == 0 || !unicode.IsLetter(prev) && !unicode.IsDigit(prev) || unicode.IsUpper(r) && unicode.IsLower(prev) {
+				score += 5
+			}
+			if last >= 0 && last == i-1 {
+				score += 3
+			} else if last >= 0 {
+				score -= min(3, i-last-1)
+			}
+			last = i
+			qi++
+		}
+		prev = r
+	}
+	return score, qi == len(q)
+}
+
+// move the hot row of the open list with the navigation keys; returns true
+// if the key was one of them
+func selectNavigate(state *selectState, pageSize int) bool {
+	var last = len(state.matches) - 1
+	var hot = state.hot
+	switch FrameInput.Key {
+	case KeyUp:
+		hot--
+	case KeyDown:
+		hot++
+	case KeyPageUp:
+		hot -= pageSize
+	case KeyPageDown:
+		hot += pageSize
+	case KeyHome:
+		hot = 0
+	case KeyEnd:
+		hot = last
+	default:
+		return false
+	}
+	state.hot = max(0, min(hot, last))
+	state.reveal = true
+	state.moved = true
+	return true
+}
+
+func selectOpen(state *selectState, current int) {
+	state.open = true
+	state.hot = max(0, slices.Index(state.matches, current))
+	state.reveal = true
+	state.moved = false
+}
+
+// the list of options under the field, drawn in a popup
+func selectList[T comparable](fieldId any, focusId any, state *selectState, attrs *SelectAttrs[T], options []T, current int) {
+	Popup(func() {
+		LayoutId(selectListKey{state}, TW(NoAnimate, BGV(_menuBG), BW(1), Bo(0, 0, 10, 0.8), BR(2), Shd(14), Clip), func() {
+			var width = GetResolvedRectOf(fieldId).Size[0]
+			ModAttrs(FloatV(_getPositionRelativeTo(fieldId)), FixWidth(width))
+
+			if len(state.matches) == 0 {
+				Layout(TW(Pad2(4, 8)), func() {
+					Label("No matches", Sz(attrs.FontSize), Clr(0, 0, 40, 1))
+				})
+				return
+			}
+
+			var rows = min(len(state.matches), max(1, attrs.MaxRows))
+			Layout(TW(FixSize(width, f32(rows)*attrs.RowHeight)), func() {
+				rowId := func(index int) any {
+					return selectRowKey(state.matches[index])
+				}
+				rowHeight := func(index int, width f32) f32 {
+					return attrs.RowHeight
+				}
+				rowView := func(index int, width f32) {
+					var option = state.matches[index]
+					Layout(TW(Row, Expand, CrossMid, FixHeight(attrs.RowHeight), Pad2(0, 8), Gap(6), Clip), func() {
+						if IsHovered() && FrameInput.Motion != (Vec2{}) {
+							state.hot = index
+						}
+						if IsClicked() {
+							state.pick = option
+							state.open = false
+							FocusImmediateOn(focusId)
+							RequestNextFrame()
+						}
+						if index == state.hot {
+							ModAttrs(BG(234, 92, 84, 0.8))
+						}
+						var text = selectLabel(state, attrs, options[option], len(options))
+						var weight = WeightNormal
+						if option == current {
+							weight = WeightBold
+						}
+						Label(text, Sz(attrs.FontSize), FontWeight(weight), Clr(0, 0, 10, 1))
+					})
+				}
+				VirtualListViewExt(VirtualListAttrs{Reveal: state.reveal, RevealIndex: state.hot}, len(state.matches), rowId, rowHeight, rowView)
+				state.reveal = false
+			})
+		})
+	})
+}
+
+// apply an option picked in the list on the last frame
+func selectApplyPick[T comparable](state *selectState, value *T, options []T) bool {
+	var pick = state.pick
+	state.pick = -1
+	if pick < 0 || pick >= len(options) || options[pick] == *value {
+		return false
+	}
+	*value = options[pick]
+	return true
+}
+
+// Select is a dropdown list of options; returns true when the value changes.
+//
+// When focused, Up/Down change the value directly, Space or Enter opens the
+// list, and typing jumps to the first option that starts with the typed text.
+func Select[T comparable](value *T, options []T) bool {
+	return SelectExt(value, options, DefaultSelectAttrs[T]())
+}
+
+func SelectExt[T comparable](value *T, options []T, attrs SelectAttrs[T]) bool {
+	var changed bool
+	var padding = attrs.FontSize / 2
+	Layout(TW(Row, CrossMid, Focusable, Gap(padding), Pad(padding), BR(2), BG(0, 0, 90, 1), Grad(0, 0, 4, 0), BW(1), Bo(0, 0, 50, 1)), func() {
+		if attrs.Width > 0 {
+			ModAttrs(FixWidth(attrs.Width))
+		}
+		var id = CurrentId()
+		var state = UseWithInit("select", func() *selectState {
+			return &selectState{pick: -1}
+		})
+		var listId = selectListKey{state}
+
+		changed = selectApplyPick(state, value, options)
+		var current = slices.Index(options, *value)
+		selectFilter(state, &attrs, options, "")
+
+		if FrameInput.Mouse == MouseClick {
+			if IsHovered() {
+				FocusImmediate()
+				if state.open {
+					state.open = false
+				} else {
+					selectOpen(state, current)
+				}
+			} else if !IdIsHovered(listId) {
+				state.open = false
+				if HasFocus() {
+					Blur()
+				}
+			}
+		}
+		CycleFocusOnTab()
+
+		if !HasFocus() {
+			state.open = false
+		} else {
+			ModAttrs(BG(0, 0, 91, 1), Bo(0, 0, 30, 1))
+
+			setValue := func(index int) {
+				if index >= 0 && index < len(options) && options[index] != *value {
+					*value = options[index]
+					current = index
+					changed = true
+				}
+			}
+
+			// type-ahead; text being composed (IME) is replaced as it's
+			// updated, like in text inputs
+			var typing = time.Since(state.typedAt) < selectTypeAheadTimeout || InputState.Composition != ""
+			var hasText = FrameInput.Text != "" || FrameInput.TextReplace > 0
+			if hasText && (FrameInput.Text != " " || typing) {
+				if !typing {
+					state.typed = ""
+				}
+				var typed = []rune(state.typed)
+				typed = typed[:len(typed)-min(FrameInput.TextReplace, len(typed))]
+				state.typed = string(typed) + strings.ToLower(FrameInput.Text)
+				state.typedAt = time.Now()
+				var found = -1 // a cancelled composition leaves nothing to find
+				if state.typed != "" {
+					found = slices.IndexFunc(options, func(option T) bool {
+						return strings.HasPrefix(strings.ToLower(selectLabel(state, &attrs, option, len(options))), state.typed)
+					})
+				}
+				if found >= 0 {
+					if state.open {
+						state.hot = max(0, slices.Index(state.matches, found))
+						state.reveal = true
+					} else {
+						setValue(found)
+					}
+				}
+			}
+
+			var alt = InputState.Modifiers&ModAlt != 0
+			if state.open {
+				switch {
+				case selectNavigate(state, attrs.MaxRows-1):
+				case FrameInput.Key == KeyEnter, FrameInput.Key == KeySpace && !typing:
+					if state.hot < len(state.matches) {
+						setValue(state.matches[state.hot])
+					}
+					state.open = false
+				case FrameInput.Key == KeyEscape, FrameInput.Key == KeyTab:
+					state.open = false
+				}
+			} else {
+				switch {
+				case FrameInput.Key == KeyEnter, FrameInput.Key == KeySpace && !typing, FrameInput.Key == KeyDown && alt:
+					selectOpen(state, current)
+				case FrameInput.Key == KeyUp:
+					setValue(max(0, current-1))
+				case FrameInput.Key == KeyDown:
+					setValue(min(current+1, len(options)-1))
+				case FrameInput.Key == KeyHome:
+					setValue(0)
+				case FrameInput.Key == KeyEnd:
+					setValue(len(options) - 1)
+				}
+			}
+		}
+
+		Layout(TW(Grow(1), Clip), func() {
+			if current >= 0 {
+				Label(selectLabel(state, &attrs, options[current], len(options)), Sz(attrs.FontSize), Clr(0, 0, 0, 1))
+			} else {
+				Label(attrs.Placeholder, Sz(attrs.FontSize), Clr(0, 0, 0, 0.4))
+			}
+		})
+		Icon(SymDown, Sz(attrs.FontSize), Clr(0, 0, 20, 0.8))
+
+		if state.open {
+			selectList(id, id, state, &attrs, options, current)
+		}
+	})
+	return changed
+}
+
+// ComboBox is a text input with a dropdown list of options, filtered by the
+// typed text with fuzzy matching; returns true when the value changes.
+//
+// Typed text that doesn't match an option is only accepted when attrs.Parse
+// is set; otherwise Enter or leaving the input picks the best match.
+func ComboBox[T comparable](value *T, options []T) bool {
+	return ComboBoxExt(value, options, DefaultSelectAttrs[T]())
+}
+
+func ComboBoxExt[T comparable](value *T, options []T, attrs SelectAttrs[T]) bool {
+	var changed bool
+	Layout(TW(Row, CrossMid), func() {
+		var id = CurrentId()
+		var state = UseWithInit("combo-box", func() *selectState {
+			return &selectState{pick: -1}
+		})
+		var listId = selectListKey{state}
+
+		if selectApplyPick(state, value, options) {
+			changed = true
+			state.editing = false
+		}
+		var current = slices.Index(options, *value)
+
+		// show the value unless the user is typing
+		if !state.editing {
+			var text = ""
+			if current >= 0 || attrs.Parse != nil {
+				text = selectLabel(state, &attrs, *value, len(options))
+			}
+			if text != state.text {
+				state.text = text
+			}
+			state.seen = state.text
+		}
+
+		var inputAttrs = DefaultTextInputAttrs()
+		inputAttrs.FontSize = attrs.FontSize
+		inputAttrs.Padding = N4(attrs.FontSize / 2)
+		inputAttrs.Padding[PAD_RIGHT] += attrs.FontSize * 1.5 // room for the arrow
+		inputAttrs.MinWidth = attrs.Width
+		inputAttrs.Placeholder = attrs.Placeholder
+		TextInputExt(&state.text, inputAttrs)
+		var inputId = GetLastId()
+		var focused = IdHasFocus(inputId)
+
+		// the arrow, drawn over the right side of the input
+		var inputRect = GetResolvedRectOf(inputId)
+		var fieldRect = GetResolvedRectOf(id)
+		var arrowPos = Vec2{inputRect.Origin[0] - fieldRect.Origin[0] + inputRect.Size[0] - attrs.FontSize*1.75, inputRect.Origin[1] - fieldRect.Origin[1]}
+		Layout(TW(FloatV(arrowPos), FixSize(attrs.FontSize*1.5, inputRect.Size[1]), Center), func() {
+			if IsClicked() {
+				if state.open {
+					state.open = false
+				} else {
+					selectFilter(state, &attrs, options, "")
+					selectOpen(state, current)
+				}
+				FocusImmediateOn(inputId)
+			}
+			var alpha f32 = 0.6
+			if IsHovered() {
+				alpha = 0.9
+			}
+			Icon(SymDown, Sz(attrs.FontSize), Clr(0, 0, 20, alpha))
+		})
+
+		// leaving the input commits what was typed
+		commit := func() {
+			if state.editing {
+				var text = strings.TrimSpace(state.text)
+				var exact = slices.IndexFunc(options, func(option T) bool {
+					return strings.EqualFold(selectLabel(state, &attrs, option, len(options)), text)
+				})
+				var next, ok = *value, false
+				switch {
+				case exact >= 0:
+					next, ok = options[exact], true
+				case attrs.Parse != nil:
+					next, ok = attrs.Parse(text)
+				case state.open && state.hot < len(state.matches):
+					next, ok = options[state.matches[state.hot]], true
+				}
+				if ok && next != *value {
+					*value = next
+					changed = true
+				}
+			}
+			state.editing = false
+			state.open = false
+		}
+
+		if state.text != state.seen {
+			// typed something
+			state.seen = state.text
+			state.editing = true
+			state.open = true
+		}
+		if state.editing {
+			selectFilter(state, &attrs, options, strings.TrimSpace(state.text))
+		} else if !state.open {
+			selectFilter(state, &attrs, options, "")
+		}
+
+		if focused {
+			var alt = InputState.Modifiers&ModAlt != 0
+			if state.open {
+				switch {
+				case selectNavigate(state, attrs.MaxRows-1):
+				case FrameInput.Key == KeyEnter:
+					// with free text, typed text wins over the best match
+					if state.hot < len(state.matches) && (attrs.Parse == nil || state.moved || !state.editing) {
+						state.pick = -1
+						var option = options[state.matches[state.hot]]
+						if option != *value {
+							*value = option
+							changed = true
+						}
+						state.editing = false
+						state.open = false
+					} else {
+						commit()
+					}
+				case FrameInput.Key == KeyEscape:
+					state.open = false
+				}
+			} else {
+				switch {
+				case FrameInput.Key == KeyDown, FrameInput.Key == KeyUp && !alt:
+					selectOpen(state, slices.Index(options, *value))
+				case FrameInput.Key == KeyEnter:
+					commit()
+				case FrameInput.Key == KeyEscape:
+					// back to the value
+					state.editing = false
+				}
+			}
+		} else if state.focused && !IdIsHovered(listId) {
+			commit()
+		}
+		state.focused = focused
+
+		if state.open && focused {
+			selectList(id, inputId, state, &attrs, options, current)
+		}
+	})
+	return changed
+}
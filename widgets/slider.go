@@ -0,0 +1,266 @@
+package widgets
+
+import (
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type SliderAttrs struct {
+	Min   f32
+	Max   f32
+	Step  f32 // values snap to multiples of Step from Min; 0 for no snapping
+	Width f32 // the length of the track, even when it's vertical
+
+	Vertical bool // Min is at the bottom
+
+	// with the keyboard, the arrows move by Step (or 1% of the range when
+	// there's no Step) and PageUp/PageDown by PageStep (10 arrow moves when 0)
+	PageStep f32
+
+	// tick marks every Ticks (in value units), labelled when TickLabel is set
+	Ticks     f32
+	TickLabel func(v f32) string
+}
+
+const sliderThumbRadius = 10
+const sliderTickLength = 6
+
+type sliderState struct {
+	dragging int // the thumb being dragged; -1 for none
+	grab     f32 // where the thumb was grabbed, from its center
+	thumbs   []any
+
+	labels    map[f32]string
+	labelSize Vec2 // of the largest tick label, from the last frame
+}
+
+// Slider picks a value between attrs.Min and attrs.Max by dragging the thumb
+// or clicking on the track; the thumb takes focus for keyboard adjustment.
+// Returns true when the value changes.
+func Slider(value *float32, attrs SliderAttrs) bool {
+	return sliderExt([]*f32{value}, attrs)
+}
+
+// RangeSlider is a slider with two thumbs for a range; from stays <= to
+func RangeSlider(from *float32, to *float32, attrs SliderAttrs) bool {
+	if *to < *from {
+		*to, *from = *from, *to
+	}
+	return sliderExt([]*f32{from, to}, attrs)
+}
+
+// values are in order; their thumbs can't pass each other
+func sliderExt(values []*f32, attrs SliderAttrs) bool {
+	if attrs.Width == 0 {
+		attrs.Width = 200
+	}
+	if attrs.Max <= attrs.Min {
+		return false
+	}
+
+	var r f32 = sliderThumbRadius
+	var axis, across = 0, 1
+	if attrs.Vertical {
+		axis, across = 1, 0
+	}
+	var travel = attrs.Width - r*2
+	var span = attrs.Max - attrs.Min
+	var changed bool
+
+	vec := func(along, side f32) Vec2 {
+		var v Vec2
+		v[axis] = along
+		v[across] = side
+		return v
+	}
+
+	// where the thumb for v starts along the track
+	offsetOf := func(v f32) f32 {
+		var offset = travel * (v - attrs.Min) / span
+		if attrs.Vertical {
+			offset = travel - offset
+		}
+		return offset
+	}
+	// the value for a thumb centered at offset
+	valueAt := func(offset f32) f32 {
+		var t = (offset - r) / travel
+		if attrs.Vertical {
+			t = 1 - t
+		}
+		return attrs.Min + t*span
+	}
+	snap := func(v f32) f32 {
+		if attrs.Step > 0 {
+			v = attrs.Min + Roundf32((v-attrs.Min)/attrs.Step)*attrs.Step
+		}
+		return max(attrs.Min, min(attrs.Max, v))
+	}
+	set := func(i int, v f32) {
+		v = snap(v)
+		if i > 0 {
+			v = max(v, *values[i-1])
+		}
+		if i < len(values)-1 {
+			v = min(v, *values[i+1])
+		}
+		if v != *values[i] {
+			*values[i] = v
+			changed = true
+		}
+	}
+
+	var step = attrs.Step
+	if step == 0 {
+		step = span / 100
+	}
+	var page = attrs.PageStep
+	if page == 0 {
+		page = step * 10
+	}
+
+	var ticks []f32
+	if attrs.Ticks > 0 {
+		for i := 0; i <= 1000; i++ {
+			var v = attrs.Min + f32(i)*attrs.Ticks
+			if v > attrs.Max+attrs.Ticks/1000 {
+				break
+			}
+			ticks = append(ticks, v)
+		}
+	}
+
+	Layout(TW(Gap(2)), func() {
+		if attrs.Vertical {
+			ModAttrs(Row)
+		}
+		var state = UseWithInit("slider", func() *sliderState {
+			return &sliderState{dragging: -1}
+		})
+
+		var thickness = r * 2
+		if len(ticks) > 0 {
+			thickness += sliderTickLength
+		}
+		Layout(TW(FixSizeV(vec(attrs.Width, thickness))), func() {
+			var trackId = CurrentId()
+			var rect = GetResolvedRectOf(trackId)
+			var mouse = InputState.MousePoint[axis] - rect.Origin[axis]
+
+			// clicking the track moves the nearest thumb there
+			if IsClicked() && IsHoveredDirectly() {
+				var nearest = -1
+				var distance f32
+				for i, v := range values {
+					var d = Absf32(offsetOf(*v) + r - mouse)
+					// on a tie, go with the thumb on the side of the click
+					if nearest == -1 || d < distance || d == distance && mouse > offsetOf(*v)+r {
+						nearest, distance = i, d
+					}
+				}
+				set(nearest, valueAt(mouse))
+				state.dragging = nearest
+				state.grab = 0
+				if nearest < len(state.thumbs) {
+					FocusImmediateOn(state.thumbs[nearest])
+				}
+			}
+
+			if state.dragging >= len(values) || InputState.MouseButton&MousePrimary == 0 {
+				state.dragging = -1
+			}
+			if i := state.dragging; i >= 0 {
+				var v = snap(valueAt(mouse - state.grab))
+				// a thumb sitting on its neighbor hands the drag over to it
+				// when pulled past it
+				if i < len(values)-1 && v > *values[i+1] && *values[i] == *values[i+1] {
+					i++
+				} else if i > 0 && v < *values[i-1] && *values[i] == *values[i-1] {
+					i--
+				}
+				if i != state.dragging && i < len(state.thumbs) {
+					FocusImmediateOn(state.thumbs[i])
+				}
+				state.dragging = i
+				set(i, v)
+			}
+
+			// the track, with the selected part highlighted
+			var lo, hi = offsetOf(attrs.Min), offsetOf(*values[len(values)-1])
+			if len(values) > 1 {
+				lo = offsetOf(*values[0])
+			}
+			Element(TW(ClickThrough, FloatV(vec(r, r-2)), FixSizeV(vec(travel, 4)), BR(2), BG(0, 0, 75, 1)))
+			Element(TW(ClickThrough, FloatV(vec(min(lo, hi)+r, r-3)), FixSizeV(vec(Absf32(hi-lo), 6)), BR(3), BG(210, 70, 55, 1)))
+
+			for _, v := range ticks {
+				Element(TW(ClickThrough, FloatV(vec(offsetOf(v)+r, r*2)), FixSizeV(vec(1, sliderTickLength)), BG(0, 0, 40, 1)))
+			}
+
+			state.thumbs = state.thumbs[:0]
+			for i, v := range values {
+				Layout(TW(Focusable, FloatV(vec(offsetOf(*v), 0)), BR(r), FixSize(r*2, r*2), BG(0, 0, 98, 1), Grad(0, 0, -18, 0), Shd(2), BW(1), Bo(0, 0, 0, 0.5)), func() {
+					state.thumbs = append(state.thumbs, CurrentId())
+					// not FocusOnClick: clicks on the track focus the thumb too
+					if IsClicked() {
+						FocusImmediate()
+						state.dragging = i
+						state.grab = mouse - (offsetOf(*v) + r)
+					} else if FrameInput.Mouse == MouseClick && HasFocus() && !IdIsHovered(trackId) {
+						Blur()
+					}
+					CycleFocusOnTab()
+					if !HasFocus() {
+						return
+					}
+					ModAttrs(BW(2), Bo(210, 80, 50, 1))
+					switch FrameInput.Key {
+					case KeyRight, KeyUp:
+						set(i, *v+step)
+					case KeyLeft, KeyDown:
+						set(i, *v-step)
+					case KeyPageUp:
+						set(i, *v+page)
+					case KeyPageDown:
+						set(i, *v-page)
+					case KeyHome:
+						set(i, attrs.Min)
+					case KeyEnd:
+						set(i, attrs.Max)
+					}
+				})
+			}
+		})
+
+		if len(ticks) == 0 || attrs.TickLabel == nil {
+			return
+		}
+		if state.labels == nil || len(state.labels) > len(ticks)*2 {
+			state.labels = make(map[f32]string)
+		}
+		var labelSize Vec2
+		Layout(TW(FixSizeV(vec(attrs.Width, state.labelSize[across]))), func() {
+			for _, v := range ticks {
+				text, ok := state.labels[v]
+				if !ok {
+					text = attrs.TickLabel(v)
+					state.labels[v] = text
+				}
+				Layout(TW(NoAnimate), func() {
+					var size = GetResolvedSize()
+					labelSize[0] = max(labelSize[0], size[0])
+					labelSize[1] = max(labelSize[1], size[1])
+					var along = offsetOf(v) + r - size[axis]/2
+					along = max(0, min(along, attrs.Width-size[axis]))
+					ModAttrs(FloatV(vec(along, 0)))
+					Label(text, Sz(10), Clr(0, 0, 30, 1))
+				})
+			}
+		})
+		if labelSize != state.labelSize {
+			state.labelSize = labelSize
+			RequestNextFrame()
+		}
+	})
+	return changed
+}
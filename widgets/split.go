@@ -0,0 +1,125 @@
+package widgets
+
+import (
+	"time"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+// SplitState is the part of a split pane worth persisting; it's kept by the
+// application and serializes to JSON as is
+type SplitState struct {
+	// fraction of the space (minus the divider) given to the first pane;
+	// 0 means unset, and splits evenly
+	Ratio f32 `json:"ratio"`
+
+	// 1 or 2 when the first or second pane is collapsed
+	Collapsed int `json:"collapsed,omitempty"`
+}
+
+type SplitAttrs struct {
+	Row         bool // panes side by side, with a vertical divider
+	DividerSize f32
+
+	// limits in pixels; 0 means no limit
+	MinFirst  f32
+	MaxFirst  f32
+	MinSecond f32
+	MaxSecond f32
+}
+
+func DefaultSplitAttrs() SplitAttrs {
+	return SplitAttrs{
+		DividerSize: 6,
+	}
+}
+
+// the size of the first pane given the total space for both panes
+func (attrs *SplitAttrs) firstSize(state *SplitState, total f32) f32 {
+	switch state.Collapsed {
+	case 1:
+		return 0
+	case 2:
+		return total
+	}
+	var ratio = state.Ratio
+	if ratio <= 0 {
+		ratio = 0.5
+	}
+	var first = total * ratio
+	if attrs.MaxFirst > 0 {
+		first = min(first, attrs.MaxFirst)
+	}
+	first = max(first, attrs.MinFirst)
+	if attrs.MaxSecond > 0 {
+		first = max(first, total-attrs.MaxSecond)
+	}
+	first = min(first, total-attrs.MinSecond)
+	return max(0, min(first, total))
+}
+
+// SplitPane shows two panes separated by a divider that can be dragged to
+// resize them; double clicking the divider collapses the smaller pane (or
+// restores it)
+func SplitPane(state *SplitState, attrs SplitAttrs, first func(), second func()) {
+	main, _ := MainCrossAxes(attrs.Row)
+
+	Layout(TW(Viewport), func() {
+		ModAttrs(func(a *Attrs) { a.Row = attrs.Row })
+
+		var rect = GetContentRect()
+		var total = max(0, rect.Size[main]-attrs.DividerSize)
+		if total == 0 {
+			RequestNextFrame() // size not known yet
+		}
+		var firstSize = attrs.firstSize(state, total)
+
+		paneAttrs := func(size f32) Attrs {
+			var a = TW(Extrinsic, Clip, Expand, NoAnimate)
+			a.MinSize[main] = size
+			a.MaxSize[main] = size
+			return a
+		}
+
+		Layout(paneAttrs(firstSize), first)
+
+		Layout(paneAttrs(attrs.DividerSize), func() {
+			type DividerState struct {
+				lastClick time.Time
+			}
+			var divider = Use[DividerState]("divider")
+
+			if IsClicked() {
+				if time.Since(divider.lastClick) < time.Millisecond*400 {
+					if state.Collapsed != 0 {
+						state.Collapsed = 0
+					} else if firstSize <= total-firstSize {
+						state.Collapsed = 1
+					} else {
+						state.Collapsed = 2
+					}
+					divider.lastClick = time.Time{}
+				} else {
+					divider.lastClick = time.Now()
+				}
+			}
+
+			PressAction()
+			if IsActive() && FrameInput.Motion != (Vec2{}) && total > 0 {
+				pos := InputState.MousePoint[main] - rect.Origin[main] - attrs.DividerSize/2
+				state.Collapsed = 0
+				state.Ratio = pos / total
+				state.Ratio = attrs.firstSize(state, total) / total
+			}
+
+			var bg = Vec4{0, 0, 80, 1}
+			if IsHovered() || IsActive() {
+				bg = Vec4{210, 50, 70, 1}
+			}
+			ModAttrs(BGV(bg))
+		})
+
+		Layout(paneAttrs(total-firstSize), second)
+	})
+}
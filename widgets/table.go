@@ -0,0 +1,225 @@
+package widgets
+
+import (
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type TableColumn struct {
+	Header string
+
+	Width    f32 // initial width
+	MinWidth f32
+	MaxWidth f32 // 0 means no limit
+
+	Align Alignment // of the cell content
+
+	// clicking the header of a sortable column sorts by it (or flips the
+	// direction); the table only tracks the sort order, sorting the rows is up
+	// to the application
+	Sortable bool
+
+	Cell func(row int)
+}
+
+// TableState is kept by the application and passed to the table every frame
+type TableState struct {
+	SortColumn     int // -1 when not sorted
+	SortDescending bool
+
+	Selected int // -1 when no row is selected
+
+	widths  []f32
+	reveal  bool // scroll to the selected row on the next frame
+	scrollX f32  // of the header and the rows together
+}
+
+func NewTableState() *TableState {
+	return &TableState{SortColumn: -1, Selected: -1}
+}
+
+// Select selects a row and scrolls it into view
+func (s *TableState) Select(row int) {
+	s.Selected = row
+	s.reveal = true
+}
+
+type TableAttrs struct {
+	RowHeight f32
+	TextSize  f32
+	Striped   bool
+}
+
+func DefaultTableAttrs() TableAttrs {
+	return TableAttrs{
+		RowHeight: 24,
+		TextSize:  12,
+		Striped:   true,
+	}
+}
+
+func Table(state *TableState, rowCount int, columns []TableColumn) bool {
+	return TableExt(state, rowCount, columns, DefaultTableAttrs())
+}
+
+const tableMinColumnWidth = 24
+
+// TableExt returns true when the sort order changed, so the application can
+// sort its rows again
+func TableExt(state *TableState, rowCount int, columns []TableColumn, attrs TableAttrs) (sortChanged bool) {
+	if len(state.widths) != len(columns) {
+		state.widths = make([]f32, len(columns))
+		for c, col := range columns {
+			state.widths[c] = col.clampWidth(col.Width)
+		}
+	}
+	if state.Selected >= rowCount {
+		state.Selected = rowCount - 1
+	}
+
+	Layout(TW(Viewport, Focusable, BG(0, 0, 100, 1), BW(1), Bo(0, 0, 70, 1)), func() {
+		FocusOnClick()
+
+		var focused = HasFocus()
+		if focused {
+			var pageSize = max(1, int(GetResolvedSize()[1]/attrs.RowHeight)-2)
+			var selected = state.Selected
+			switch FrameInput.Key {
+			case KeyUp:
+				selected--
+			case KeyDown:
+				selected++
+			case KeyPageUp:
+				selected -= pageSize
+			case KeyPageDown:
+				selected += pageSize
+			case KeyHome:
+				selected = 0
+			case KeyEnd:
+				selected = rowCount - 1
+			}
+			if selected != state.Selected && rowCount > 0 {
+				state.Select(max(0, min(selected, rowCount-1)))
+			}
+		}
+
+		// the list only scrolls up and down; when the columns are wider than
+		// the table, the header and the rows are moved sideways together
+		var columnsWidth f32
+		for _, width := range state.widths {
+			columnsWidth += width + 1
+		}
+		if IsHovered() {
+			state.scrollX += FrameInput.Scroll[0]
+		}
+		state.scrollX = max(0, min(state.scrollX, columnsWidth+SCROLLBAR_WIDTH-GetResolvedSize()[0]))
+		var shifted = TW(Row, NoAnimate, Float(-state.scrollX, 0), FixHeight(attrs.RowHeight))
+
+		// the header stays outside the scrolled list so it's always visible
+		Layout(TW(Expand, FixHeight(attrs.RowHeight), BG(0, 0, 94, 1)), func() {
+			Layout(shifted, func() {
+				for c := range columns {
+					col := &columns[c]
+					width := state.widths[c]
+					Layout(TW(Row, CrossMid, Expand, Clip, FixWidth(width), Pad2(0, 6), Gap(4), MA(col.Align)), func() {
+						if col.Sortable {
+							if PressAction() {
+								if state.SortColumn == c {
+									state.SortDescending = !state.SortDescending
+								} else {
+									state.SortColumn = c
+									state.SortDescending = false
+								}
+								sortChanged = true
+							}
+							if IsHovered() {
+								ModAttrs(BG(0, 0, 90, 1))
+							}
+						}
+
+						Label(col.Header, Sz(attrs.TextSize), FontWeight(WeightSemibold), Clr(0, 0, 20, 1))
+
+						if col.Sortable {
+							var icon = TypArrowUnsorted
+							var alpha f32 = 0.3
+							if state.SortColumn == c {
+								icon = TypArrowSortedUp
+								if state.SortDescending {
+									icon = TypArrowSortedDown
+								}
+								alpha = 0.8
+							}
+							Icon(icon, Sz(attrs.TextSize), Clr(0, 0, 20, alpha))
+						}
+
+						// drag handle on the right edge to resize the column
+						Layout(TW(NoAnimate, InFront, Float(width-6, 0), FixSize(6, attrs.RowHeight)), func() {
+							PressAction()
+							if IsActive() {
+								state.widths[c] = col.clampWidth(state.widths[c] + FrameInput.Motion[0])
+							}
+							if IsHovered() || IsActive() {
+								Element(TW(NoAnimate, Float(4, 0), FixSize(2, attrs.RowHeight), BG(210, 60, 50, 1)))
+							}
+						})
+					})
+					Element(TW(FixWidth(1), Expand, BG(0, 0, 80, 1)))
+				}
+			})
+		})
+		Element(TW(Expand, FixHeight(1), BG(0, 0, 70, 1)))
+
+		type TableRow int
+
+		rowId := func(row int) any {
+			return TableRow(row)
+		}
+
+		rowHeight := func(row int, width f32) f32 {
+			return attrs.RowHeight
+		}
+
+		rowView := func(row int, width f32) {
+			Layout(TW(Expand, FixHeight(attrs.RowHeight)), func() {
+				if IsClicked() {
+					state.Selected = row
+				}
+
+				switch {
+				case row == state.Selected && focused:
+					ModAttrs(BG(210, 70, 85, 1))
+				case row == state.Selected:
+					ModAttrs(BG(0, 0, 86, 1))
+				case IsHovered():
+					ModAttrs(BG(210, 40, 96, 1))
+				case attrs.Striped && row%2 == 1:
+					ModAttrs(BG(0, 0, 97, 1))
+				}
+
+				Layout(shifted, func() {
+					for c := range columns {
+						col := &columns[c]
+						Layout(TW(Row, CrossMid, Expand, Clip, FixWidth(state.widths[c]+1), Pad2(0, 6), MA(col.Align)), func() {
+							if col.Cell != nil {
+								col.Cell(row)
+							}
+						})
+					}
+				})
+			})
+		}
+
+		VirtualListViewExt(VirtualListAttrs{Reveal: state.reveal, RevealIndex: state.Selected}, rowCount, rowId, rowHeight, rowView)
+		state.reveal = false
+	})
+
+	return sortChanged
+}
+
+func (col *TableColumn) clampWidth(w f32) f32 {
+	w = max(w, col.MinWidth, tableMinColumnWidth)
+	if col.MaxWidth > 0 {
+		w = min(w, col.MaxWidth)
+	}
+	return w
+}
@@ -0,0 +1,247 @@
+package widgets
+
+import (
+	"slices"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type TabItem struct {
+	Id       any // identifies the tab, and the state of its content
+	Title    string
+	Icon     rune
+	Closable bool
+}
+
+type TabsAttrs struct {
+	TextSize    f32
+	MaxTabWidth f32
+
+	Background Vec4 // of the bar
+	Active     Vec4
+	Inactive   Vec4
+}
+
+func DefaultTabsAttrs() TabsAttrs {
+	return TabsAttrs{
+		TextSize:    12,
+		MaxTabWidth: 200,
+		Background:  Vec4{0, 0, 82, 1},
+		Active:      Vec4{0, 0, 98, 1},
+		Inactive:    Vec4{0, 0, 90, 1},
+	}
+}
+
+type tabKey struct{ id any }
+type tabCloseKey struct{ id any }
+type tabContentKey struct{ id any }
+
+// Tabs draws a tab bar. Tabs can be reordered by dragging them, and the
+// keyboard switches between them (Ctrl+Tab, Ctrl+PageUp/PageDown) when nothing
+// is focused or the focus is in the bar.
+//
+// Returns the index of the tab whose close button was pressed, or -1. Closing
+// is left to the application (e.g. to confirm unsaved changes); see CloseTab.
+func Tabs(active *int, tabs *[]TabItem) int {
+	return TabsExt(active, tabs, DefaultTabsAttrs())
+}
+
+func TabsExt(active *int, tabs *[]TabItem, attrs TabsAttrs) int {
+	var closed = -1
+	Layout(TW(Expand), func() {
+		closed = tabsBar(active, tabs, attrs, HasFocusWithin() || IdHasFocus(nil))
+	})
+	return closed
+}
+
+// TabsView draws a tab bar and the content of the active tab below it; the
+// keyboard shortcuts also work while the focus is inside the content
+func TabsView(active *int, tabs *[]TabItem, attrs TabsAttrs, content func(tab TabItem)) int {
+	var closed = -1
+	Layout(TW(Viewport), func() {
+		closed = tabsBar(active, tabs, attrs, HasFocusWithin() || IdHasFocus(nil))
+		if *active >= 0 && *active < len(*tabs) {
+			tab := (*tabs)[*active]
+			TabContent(tab, func() {
+				content(tab)
+			})
+		}
+	})
+	return closed
+}
+
+// TabContent draws the content of a tab; the state of the views inside it (hooks
+// and scroll offsets) is kept while other tabs are shown, until the tab is closed
+// with CloseTab
+func TabContent(tab TabItem, fn func()) {
+	LayoutId(tabContentKey{tab.Id}, TW(Viewport), func() {
+		PersistHooks(tabContentKey{tab.Id})
+		Layout(TW(Viewport), fn)
+	})
+}
+
+// CloseTab removes a tab, keeping the active tab the same if possible, and
+// forgets the state of its content
+func CloseTab(active *int, tabs *[]TabItem, index int) {
+	if index < 0 || index >= len(*tabs) {
+		return
+	}
+	ForgetHooks(tabContentKey{(*tabs)[index].Id})
+	*tabs = slices.Delete(*tabs, index, index+1)
+	if *active > index || *active >= len(*tabs) {
+		*active = max(0, *active-1)
+	}
+}
+
+func tabsBar(active *int, tabs *[]TabItem, attrs TabsAttrs, keyboard bool) int {
+	var closed = -1
+	var count = len(*tabs)
+	if count == 0 {
+		Void()
+		return closed
+	}
+	*active = max(0, min(*active, count-1))
+
+	if keyboard && !ModalActive() {
+		switch ActiveCombo() {
+		case Combo(KeyTab, ModCtrl), Combo(KeyPageDown, ModCtrl):
+			*active = (*active + 1) % count
+		case Combo(KeyTab, ModCtrl|ModShift), Combo(KeyPageUp, ModCtrl):
+			*active = (*active + count - 1) % count
+		}
+	}
+
+	type BarState struct {
+		menuOpen bool
+		revealed any // the tab last scrolled into view
+	}
+	var state = Use[BarState]("tabs-bar")
+
+	Layout(TW(Row, Expand, CrossMid, Pad2(0, 4), BGV(attrs.Background)), func() {
+		ModAttrs(func(a *Attrs) {
+			a.Padding[PAD_TOP] = 4
+		})
+
+		var overflow bool
+
+		// the strip scrolls horizontally when tabs don't fit
+		Layout(TW(Row, Grow(1), Clip, Extrinsic, NoAnimate, Gap(2)), func() {
+			rd := GetRenderData()
+			overflow = rd.ContentSize[0] > rd.ResolvedSize[0]+1
+
+			if IsHovered() {
+				SetScrollOffset(Vec2Add(GetScrollOffset(), Vec2{FrameInput.Scroll[0] + FrameInput.Scroll[1], 0}))
+			}
+
+			var activeId = (*tabs)[*active].Id
+			if state.revealed != activeId {
+				// bring the active tab into view
+				tabRect := GetResolvedRectOf(tabKey{activeId})
+				if tabRect.Size[0] > 0 {
+					state.revealed = activeId
+					scroll := GetScrollOffset()
+					left := tabRect.Origin[0] - rd.ResolvedOrigin[0] + scroll[0]
+					right := left + tabRect.Size[0]
+					if left < scroll[0] {
+						scroll[0] = left
+					} else if right > scroll[0]+rd.ResolvedSize[0] {
+						scroll[0] = right - rd.ResolvedSize[0]
+					}
+					SetScrollOffset(scroll)
+				} else {
+					RequestNextFrame()
+				}
+			}
+
+			for index := range *tabs {
+				tab := (*tabs)[index]
+				LayoutId(tabKey{tab.Id}, TW(Row, CrossMid, Gap(6), Pad2(5, 10), BR4(4, 4, 0, 0), MaxWidth(attrs.MaxTabWidth), Clip, BGV(attrs.Inactive)), func() {
+					if IsClicked() && !IdIsHovered(tabCloseKey{tab.Id}) {
+						*active = index
+					}
+					if index == *active {
+						ModAttrs(BGV(attrs.Active), Shd(2))
+					} else if IsHovered() {
+						ModAttrs(BGV(Vec4Add(attrs.Inactive, Vec4{0, 0, 4, 0})))
+					}
+
+					// drag and drop to reorder
+					if CanDropHere[tabKey]() && !IsDragging() {
+						ModAttrs(Bo(210, 70, 50, 1), BW(1))
+					}
+					if IsDragging() {
+						ModAttrs(Trans(0.5))
+					}
+					// other targets (e.g. dock zones) are handled by whoever placed them
+					if DragAndDrop() {
+						if target, ok := draggingState.DropTarget.(tabKey); ok {
+							to := slices.IndexFunc(*tabs, func(t TabItem) bool { return t.Id == target.id })
+							if to >= 0 && to != index {
+								moveTab(active, tabs, index, to)
+							}
+						}
+					}
+
+					if tab.Icon != 0 {
+						Icon(tab.Icon, Sz(attrs.TextSize))
+					}
+					Label(tab.Title, Sz(attrs.TextSize))
+
+					if tab.Closable {
+						LayoutId(tabCloseKey{tab.Id}, TW(BR(8), Pad(2)), func() {
+							if PressAction() {
+								closed = index
+							}
+							var alpha f32 = 0.4
+							if IsHovered() {
+								ModAttrs(BG(0, 0, 0, 0.1))
+								alpha = 0.9
+							}
+							Icon(SymCancel, Sz(attrs.TextSize*0.8), Clr(0, 0, 10, alpha))
+						})
+					}
+				})
+			}
+		})
+
+		// a menu listing all the tabs for when they don't fit
+		if overflow || state.menuOpen {
+			Layout(TW(Pad2(4, 6), BR(4)), func() {
+				var chevronId = CurrentId()
+				if PressAction() {
+					state.menuOpen = !state.menuOpen
+				}
+				if IsHovered() {
+					ModAttrs(BG(0, 0, 0, 0.1))
+				}
+				Icon(SymDown, Sz(attrs.TextSize))
+
+				PopupPanel(&state.menuOpen, chevronId, TW(MinWidth(150), MaxHeight(400), Pad2(6, 0), Gap(2), BR(4)), func() {
+					ScrollOnInput()
+					for index, tab := range *tabs {
+						var icon = tab.Icon
+						if index == *active {
+							icon = SymITick
+						}
+						if MenuItem(icon, tab.Title) {
+							*active = index
+							state.menuOpen = false
+						}
+					}
+				})
+			})
+		}
+	})
+
+	return closed
+}
+
+// move a tab to another position, keeping the same tab active
+func moveTab(active *int, tabs *[]TabItem, from int, to int) {
+	var activeId = (*tabs)[*active].Id
+	var tab = (*tabs)[from]
+	*tabs = slices.Delete(*tabs, from, from+1)
+	*tabs = slices.Insert(*tabs, to, tab)
+	*active = slices.IndexFunc(*tabs, func(t TabItem) bool { return t.Id == activeId })
+}
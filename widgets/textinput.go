@@ -3,6 +3,7 @@ package widgets
 import (
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -77,6 +78,25 @@ func (s *TextInputState) insert(buf *string, text string) {
 	s.start = time.Now()
 }
 
+// for IME preedit updates: replace `count` runes before the cursor with text
+func (s *TextInputState) replace(buf *string, count int, text string) {
+	if s.cursor == s.cursor2 && count > 0 {
+		runes := []rune(*buf)
+		from := max(0, s.cursor-count)
+		to := min(s.cursor, len(runes))
+		if from < to {
+			g.RemoveAt(&runes, from, to-from)
+			*buf = string(runes)
+			s.cursor = from
+			s.cursor2 = from
+		}
+	}
+	if text != "" {
+		s.insert(buf, text)
+	}
+	s.start = time.Now()
+}
+
 func computeCursorPos(cursor int, text ShapedText) Vec2 {
 	// for now just a linear scan
 	// should be fine for small text
@@ -192,6 +212,23 @@ func PasswordInput(buf *string) {
 	TextInputExt(buf, attrs)
 }
 
+// NumberInput edits a number through a numeric text input. The value is
+// updated whenever the text parses, and the text is re-formatted from the value
+// when the input is not being edited
+func NumberInput(value *float64, attrs TextInputAttrs) {
+	attrs.Numeric = true
+	Layout(TW(), func() {
+		var text = Use[string]("number-text")
+		if !HasFocusWithin() {
+			*text = formatNumber(*value, attrs.Step)
+		}
+		TextInputExt(text, attrs)
+		if v, err := strconv.ParseFloat(strings.TrimSpace(*text), 64); err == nil {
+			*value = attrs.clampNumber(v)
+		}
+	})
+}
+
 type TextInputAttrs struct {
 	FontSize float32
 	Padding  Vec4
@@ -199,6 +236,26 @@ type TextInputAttrs struct {
 	MaxWidth float32
 
 	Masked bool
+
+	Placeholder string // shown while the input is empty
+	MaxLength   int    // in runes; 0 means no limit
+
+	// typed or pasted runes are dropped if this returns false for them
+	Filter func(r rune) bool
+
+	// a non-nil error puts the input in an error state, with the message
+	// shown under it
+	Validate func(text string) error
+
+	// numeric mode only accepts numbers, and adjusts them by Step with the
+	// Up/Down keys (10x with shift) or by dragging the handle on the right.
+	// values are kept at or above Min if HasMin, and at or below Max if HasMax
+	Numeric bool
+	Min     float64
+	Max     float64
+	HasMin  bool
+	HasMax  bool
+	Step    float64
 }
 
 func DefaultTextInputAttrs() (out TextInputAttrs) {
@@ -207,7 +264,84 @@ func DefaultTextInputAttrs() (out TextInputAttrs) {
 	return out
 }
 
+func (attrs *TextInputAttrs) filterText(text string) string {
+	if attrs.Filter == nil && !attrs.Numeric {
+		return text
+	}
+	var out = make([]rune, 0, len(text))
+	for _, r := range text {
+		if attrs.Numeric && !isNumberRune(r) {
+			continue
+		}
+		if attrs.Filter != nil && !attrs.Filter(r) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+func isNumberRune(r rune) bool {
+	return (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '+' || r == 'e' || r == 'E'
+}
+
+func (attrs *TextInputAttrs) clampNumber(v float64) float64 {
+	if attrs.HasMax {
+		v = min(v, attrs.Max)
+	}
+	if attrs.HasMin {
+		v = max(v, attrs.Min)
+	}
+	return v
+}
+
+// adjust the number in the buffer by the given number of steps
+func (attrs *TextInputAttrs) stepNumber(buf *string, steps int) {
+	var step = attrs.Step
+	if step == 0 {
+		step = 1
+	}
+	value, _ := strconv.ParseFloat(strings.TrimSpace(*buf), 64)
+	value = attrs.clampNumber(value + float64(steps)*step)
+	*buf = formatNumber(value, step)
+}
+
+// format with as many decimals as the step has
+func formatNumber(v float64, step float64) string {
+	var decimals = -1
+	if step != 0 {
+		stepStr := strconv.FormatFloat(step, 'f', -1, 64)
+		decimals = 0
+		if dot := strings.IndexByte(stepStr, '.'); dot != -1 {
+			decimals = len(stepStr) - dot - 1
+		}
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
 func TextInputExt(buf *string, attrs TextInputAttrs) {
+	if attrs.Validate == nil {
+		textInputBox(buf, attrs, false)
+		return
+	}
+
+	var err = attrs.Validate(*buf)
+	Layout(TW(Gap(3)), func() {
+		textInputBox(buf, attrs, err != nil)
+		if err != nil {
+			Label(err.Error(), Sz(attrs.FontSize*0.85), Clr(0, 70, 40, 1))
+		}
+	})
+}
+
+func textInputBox(buf *string, attrs TextInputAttrs, invalid bool) {
+	// room for the drag handle
+	var handleWidth f32
+	if attrs.Numeric {
+		handleWidth = attrs.FontSize
+		attrs.Padding[PAD_RIGHT] += handleWidth
+	}
+
 	var padSize = PadSize(attrs.Padding)
 	var inputContainerAttrs = Attrs{
 		Focusable:  true,
@@ -215,7 +349,7 @@ func TextInputExt(buf *string, attrs TextInputAttrs) {
 		Background: Vec4{0, 0, 90, 1},
 		Gradient:   Vec4{0, 0, 4, 0},
 		Padding:    attrs.Padding,
-		MinSize:    Vec2{padSize[0] + attrs.FontSize*10, attrs.FontSize + padSize[1]},
+		MinSize:    Vec2{max(attrs.MinWidth, padSize[0]+attrs.FontSize*10), attrs.FontSize + padSize[1]},
 		Border: Border{
 			BorderWidth: 1,
 			BorderColor: Vec4{0, 0, 50, 1},
@@ -332,10 +466,36 @@ func TextInputExt(buf *string, attrs TextInputAttrs) {
 
 			case KeyDeleteForward:
 				activeInput.delete(buf, 0)
+
+			case KeyUp, KeyDown:
+				if attrs.Numeric {
+					var steps = 1
+					if shift {
+						steps = 10
+					}
+					if FrameInput.Key == KeyDown {
+						steps = -steps
+					}
+					attrs.stepNumber(buf, steps)
+					activeInput.cursor = utf8.RuneCountInString(*buf)
+					activeInput.cursor2 = activeInput.cursor
+					activeInput.start = time.Now()
+				}
 			}
 
-			if FrameInput.Text != "" {
-				activeInput.insert(buf, FrameInput.Text)
+			if FrameInput.Text != "" || FrameInput.TextReplace > 0 {
+				var text = attrs.filterText(FrameInput.Text)
+				if attrs.MaxLength > 0 {
+					// the length of what remains after replacing the selection
+					from, to := activeInput.Range(shaped.Runes)
+					var kept = len(shaped.Runes) - (to - from)
+					if from == to {
+						kept -= min(FrameInput.TextReplace, from)
+					}
+					var runes = []rune(text)
+					text = string(runes[:min(len(runes), max(0, attrs.MaxLength-kept))])
+				}
+				activeInput.replace(buf, FrameInput.TextReplace, text)
 			}
 
 			selectionFrom = activeInput.cursor2
@@ -345,10 +505,28 @@ func TextInputExt(buf *string, attrs TextInputAttrs) {
 			}
 		}
 
+		if invalid {
+			ModAttrs(BG(0, 60, 95, 1), Bo(0, 70, 45, 1))
+		}
+
 		// top shadow!
 		Element(TW(NoAnimate, Float(0, 0), FixSize(size[0], 4), BG(0, 0, 20, 0.5), Grad(0, 0, 0, -0.5)))
 
-		ShapedTextLayout(shaped, inputTextAttrs, selectionFrom, selectionTo)
+		if *buf == "" && attrs.Placeholder != "" {
+			var placeholderAttrs = inputTextAttrs
+			placeholderAttrs.Color = Vec4{0, 0, 0, 0.4}
+			Text(attrs.Placeholder, placeholderAttrs)
+		} else {
+			ShapedTextLayout(shaped, inputTextAttrs, selectionFrom, selectionTo)
+		}
+
+		if attrs.Numeric {
+			numberDragHandle(buf, attrs, handleWidth, size)
+		}
+
+		if HasFocus() && InputState.Composition != "" {
+			compositionUnderline(activeInput.cursor, shaped, inputTextAttrs)
+		}
 
 		if HasFocus() {
 			RequestNextFrame()
@@ -365,8 +543,85 @@ func TextInputExt(buf *string, attrs TextInputAttrs) {
 			pos[1] += rd.Padding[PAD_RIGHT]
 			Layout(TW(MinSize(1, inputTextAttrs.Size), BG(0, 0, 30, alpha), FloatV(pos)), func() {
 				r := GetScreenRect()
-				shirei.CaretPos = Vec2Add(r.Origin, Vec2{0, r.Size[1]})
+				shirei.CaretPos = Vec2Add(r.Origin, Vec2{0, inputTextAttrs.Size * Baseline})
+				shirei.CaretAscent, shirei.CaretDescent = FontExtents(caretFont(activeInput.cursor, shaped, inputTextAttrs), inputTextAttrs.Size)
 			})
 		}
 	})
 }
+
+// the font of the glyph before the cursor, so the IME gets accurate metrics
+func caretFont(cursor int, shaped ShapedText, attrs TextAttrs) FontId {
+	var fontId FontId
+	for _, line := range shaped.Lines {
+		for _, segment := range line.Segments {
+			for _, g := range segment.Glyphs {
+				if fontId == 0 || int(g.Cluster) < cursor {
+					fontId = g.FontId
+				}
+			}
+		}
+	}
+	if fontId == 0 {
+		for _, family := range attrs.Families {
+			if fid := LookupFace(FaceLookupKey{Family: family, Aspect: attrs.FontAspect}); fid != 0 {
+				return fid
+			}
+		}
+		fontId, _ = FallbackFontFor('M', attrs.FontAspect)
+	}
+	return fontId
+}
+
+// the composition (IME preedit) sits right before the cursor; underline it, and
+// use a thicker line for the part the IME has selected (e.g. the clause being
+// converted)
+func compositionUnderline(cursor int, shaped ShapedText, attrs TextAttrs) {
+	var count = utf8.RuneCountInString(InputState.Composition)
+	var from = max(0, cursor-count)
+	var sel = InputState.CompositionRange
+
+	var rd = GetRenderData()
+	var offset = Vec2{rd.Padding[PAD_LEFT], rd.Padding[PAD_TOP] + attrs.Size}
+
+	for i := from; i < cursor; i++ {
+		// one segment per rune; cheap enough for the length of a preedit
+		p0 := computeCursorPos(i, shaped)
+		p1 := computeCursorPos(i+1, shaped)
+		if p1[1] != p0[1] {
+			// the next rune wrapped to another line
+			p1[0] = p0[0] + attrs.Size/2
+		}
+		x0, x1 := min(p0[0], p1[0]), max(p0[0], p1[0])
+
+		var thickness f32 = 1
+		if idx := i - from; sel[0] != sel[1] && idx >= sel[0] && idx < sel[1] {
+			thickness = 2
+		}
+		Element(TW(NoAnimate, ClickThrough, FloatV(Vec2Add(offset, Vec2{x0 + 1, p0[1] - thickness})), FixSize(max(1, x1-x0-2), thickness), BG(0, 0, 20, 0.9)))
+	}
+}
+
+// dragging the handle right or up increases the number by one step every few pixels
+func numberDragHandle(buf *string, attrs TextInputAttrs, width f32, size Vec2) {
+	const pixelsPerStep = 4
+	Layout(TW(NoAnimate, Float(size[0]-width-1, 1), FixSize(width, size[1]-2), Center), func() {
+		var acc = Use[f32]("drag-accumulator")
+		PressAction()
+		if IsActive() {
+			*acc += FrameInput.Motion[0] - FrameInput.Motion[1]
+			var steps = int(*acc / pixelsPerStep)
+			if steps != 0 {
+				*acc -= f32(steps) * pixelsPerStep
+				attrs.stepNumber(buf, steps)
+			}
+		} else {
+			*acc = 0
+		}
+		var alpha f32 = 0.4
+		if IsHovered() || IsActive() {
+			alpha = 0.8
+		}
+		Icon(TypArrowUnsorted, Sz(attrs.FontSize), Clr(0, 0, 10, alpha))
+	})
+}
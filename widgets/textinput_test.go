@@ -0,0 +1,56 @@
+package widgets
+
+import "testing"
+
+func TestClampNumber(t *testing.T) {
+	var cases = []struct {
+		name           string
+		min, max       float64
+		hasMin, hasMax bool
+		in, want       float64
+	}{
+		{"unbounded", 0, 0, false, false, -7, -7},
+		{"both, inside", 0, 10, true, true, 4, 4},
+		{"both, below", 0, 10, true, true, -1, 0},
+		{"both, above", 0, 10, true, true, 11, 10},
+		{"min only, inside", 5, 0, true, false, 50, 50},
+		{"min only, below", 5, 0, true, false, 2, 5},
+		{"zero min only", 0, 0, true, false, 1e9, 1e9},
+		{"zero min only, below", 0, 0, true, false, -3, 0},
+		{"max only, inside", 0, -5, false, true, -50, -50},
+		{"max only, above", 0, -5, false, true, 3, -5},
+	}
+	for _, c := range cases {
+		var attrs = TextInputAttrs{Min: c.min, Max: c.max, HasMin: c.hasMin, HasMax: c.hasMax}
+		if got := attrs.clampNumber(c.in); got != c.want {
+			t.Errorf("%s: clampNumber(%v) = %v, want %v", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestStepNumber(t *testing.T) {
+	var cases = []struct {
+		name  string
+		attrs TextInputAttrs
+		buf   string
+		steps int
+		want  string
+	}{
+		{"default step", TextInputAttrs{}, "3", 1, "4"},
+		{"down past zero", TextInputAttrs{}, "0", -2, "-2"},
+		{"empty buffer", TextInputAttrs{Step: 1}, "", 1, "1"},
+		{"decimal step", TextInputAttrs{Step: 0.5}, "1", 3, "2.5"},
+		{"keeps step decimals", TextInputAttrs{Step: 0.25}, "1", -1, "0.75"},
+		{"shift step", TextInputAttrs{Step: 1}, "5", 10, "15"},
+		{"stops at max", TextInputAttrs{Step: 1, Max: 10, HasMax: true}, "9", 5, "10"},
+		{"stops at min", TextInputAttrs{Step: 1, Min: 5, HasMin: true}, "6", -5, "5"},
+		{"min only goes up", TextInputAttrs{Step: 1, Min: 5, HasMin: true}, "6", 5, "11"},
+	}
+	for _, c := range cases {
+		var buf = c.buf
+		c.attrs.stepNumber(&buf, c.steps)
+		if buf != c.want {
+			t.Errorf("%s: stepping %q by %d gave %q, want %q", c.name, c.buf, c.steps, buf, c.want)
+		}
+	}
+}
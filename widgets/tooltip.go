@@ -0,0 +1,152 @@
+package widgets
+
+import (
+	"time"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type Placement uint8
+
+const (
+	PlaceTop Placement = iota
+	PlaceBottom
+	PlaceLeft
+	PlaceRight
+)
+
+type TooltipAttrs struct {
+	Delay     time.Duration // hover time before the tooltip shows
+	Placement Placement     // preferred; flipped when there's no room
+	Gap       f32           // between the element and the tooltip
+	MaxWidth  f32
+}
+
+func DefaultTooltipAttrs() TooltipAttrs {
+	return TooltipAttrs{
+		Delay:     time.Millisecond * 500,
+		Placement: PlaceTop,
+		Gap:       6,
+		MaxWidth:  300,
+	}
+}
+
+type tooltipKey struct{ anchor any }
+
+// only one tooltip shows at a time
+var tooltip struct {
+	// the element that gets the tooltip this frame; when elements with
+	// tooltips are nested, the last one to call Tooltip wins
+	candidate any
+
+	anchor any // the element whose tooltip is showing or waiting to show
+	since  time.Time
+	frame  int64 // the last frame the anchor was hovered
+	shown  int   // frames the tooltip has been drawn for
+	hidden bool  // dismissed until the mouse leaves the element
+}
+
+// Tooltip shows content next to the current element after it's been hovered
+// for a while; it hides when the mouse leaves or on any key press or click
+func Tooltip(content func()) {
+	TooltipExt(DefaultTooltipAttrs(), content)
+}
+
+func TooltipText(text string) {
+	TooltipExt(DefaultTooltipAttrs(), func() {
+		Label(text, Sz(12), Clr(0, 0, 100, 1))
+	})
+}
+
+func TooltipExt(attrs TooltipAttrs, content func()) {
+	if !IsHovered() {
+		return
+	}
+	var id = CurrentId()
+	tooltip.candidate = id
+
+	// decided in a popup, after every element had a chance to claim the tooltip
+	Popup(func() {
+		if tooltip.candidate != id {
+			return
+		}
+		if tooltip.anchor != id || tooltip.frame < FrameNumber-1 {
+			tooltip.anchor = id
+			tooltip.since = time.Now()
+			tooltip.shown = 0
+			tooltip.hidden = false
+		}
+		tooltip.frame = FrameNumber
+
+		if FrameInput.Key != KeyCodeNone || FrameInput.Text != "" || FrameInput.Mouse == MouseClick || FrameInput.Scroll != (Vec2{}) {
+			tooltip.hidden = true
+		}
+		if tooltip.hidden {
+			return
+		}
+		if wait := attrs.Delay - time.Since(tooltip.since); wait > 0 {
+			RequestFrameAfter(wait)
+			return
+		}
+
+		LayoutId(tooltipKey{id}, TW(NoAnimate, ClickThrough, MaxWidth(attrs.MaxWidth), Pad2(4, 8), BR(4), BG(0, 0, 15, 0.95), Shd(6)), func() {
+			var size = GetResolvedSize()
+			ModAttrs(FloatV(placeNear(GetResolvedRectOf(id), size, attrs.Placement, attrs.Gap)))
+			if tooltip.shown == 0 {
+				// the size is not known until it's laid out once
+				ModAttrs(Trans(1))
+				RequestNextFrame()
+			}
+			tooltip.shown++
+			content()
+		})
+	})
+}
+
+// the position for a box of the given size next to anchor, on the preferred
+// side if it fits in the window, or else the opposite side, or else any side
+// that fits; it's kept inside the window on the other axis
+func placeNear(anchor Rect, size Vec2, preferred Placement, gap f32) Vec2 {
+	positionOn := func(p Placement) Vec2 {
+		var pos Vec2
+		switch p {
+		case PlaceTop, PlaceBottom:
+			pos[0] = anchor.Origin[0] + (anchor.Size[0]-size[0])/2
+			pos[1] = anchor.Origin[1] - size[1] - gap
+			if p == PlaceBottom {
+				pos[1] = anchor.Origin[1] + anchor.Size[1] + gap
+			}
+		case PlaceLeft, PlaceRight:
+			pos[1] = anchor.Origin[1] + (anchor.Size[1]-size[1])/2
+			pos[0] = anchor.Origin[0] - size[0] - gap
+			if p == PlaceRight {
+				pos[0] = anchor.Origin[0] + anchor.Size[0] + gap
+			}
+		}
+		return pos
+	}
+	fits := func(p Placement) bool {
+		var pos = positionOn(p)
+		switch p {
+		case PlaceTop, PlaceBottom:
+			return pos[1] >= 0 && pos[1]+size[1] <= WindowSize[1]
+		default:
+			return pos[0] >= 0 && pos[0]+size[0] <= WindowSize[0]
+		}
+	}
+
+	var opposite = [...]Placement{PlaceTop: PlaceBottom, PlaceBottom: PlaceTop, PlaceLeft: PlaceRight, PlaceRight: PlaceLeft}
+	var placement = preferred
+	for _, p := range []Placement{preferred, opposite[preferred], PlaceBottom, PlaceTop, PlaceRight, PlaceLeft} {
+		if fits(p) {
+			placement = p
+			break
+		}
+	}
+
+	var pos = positionOn(placement)
+	pos[0] = max(0, min(pos[0], WindowSize[0]-size[0]))
+	pos[1] = max(0, min(pos[1], WindowSize[1]-size[1]))
+	return pos
+}
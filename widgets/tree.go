@@ -0,0 +1,350 @@
+package widgets
+
+import (
+	"slices"
+
+	. "go.hasen.dev/shirei"
+	. "go.hasen.dev/shirei/tw"
+)
+
+type TreeAttrs[T comparable] struct {
+	// Children lists the children of a node; it's only called when the node
+	// is expanded, and the result is kept until Reload is called for the node.
+	// With Async set it's called from a background goroutine.
+	Children func(node T) []T
+	Async    bool
+
+	// whether a node has children, so leaves don't show an expand arrow
+	// before being expanded; when nil, every node is assumed to have children
+	// until they are loaded
+	HasChildren func(node T) bool
+
+	// draws the content of a row, after the indentation and the arrow
+	NodeView func(node T, depth int)
+
+	RowHeight f32
+	Indent    f32
+}
+
+func DefaultTreeAttrs[T comparable]() TreeAttrs[T] {
+	return TreeAttrs[T]{
+		RowHeight: 22,
+		Indent:    16,
+	}
+}
+
+type treeRow[T comparable] struct {
+	node    T
+	depth   int
+	parent  int  // index of the parent row; -1 for roots
+	loading bool // placeholder shown while the children of parent are loading
+}
+
+type treeRowKey[T comparable] struct {
+	node    T
+	loading bool
+}
+
+// TreeState is kept by the application and passed to the tree every frame
+type TreeState[T comparable] struct {
+	Selected    T
+	HasSelected bool
+
+	expanded map[T]bool
+	children map[T][]T
+	loading  map[T]bool
+
+	// the expanded part of the tree, flattened
+	rows     []treeRow[T]
+	roots    []T
+	dirty    bool
+	selIndex int
+
+	reveal bool
+}
+
+func (s *TreeState[T]) init() {
+	if s.expanded == nil {
+		s.expanded = make(map[T]bool)
+		s.children = make(map[T][]T)
+		s.loading = make(map[T]bool)
+		s.dirty = true
+	}
+}
+
+func (s *TreeState[T]) IsExpanded(node T) bool {
+	return s.expanded[node]
+}
+
+func (s *TreeState[T]) Expand(node T) {
+	s.init()
+	s.expanded[node] = true
+	s.dirty = true
+	RequestNextFrame()
+}
+
+func (s *TreeState[T]) Collapse(node T) {
+	s.init()
+	delete(s.expanded, node)
+	s.dirty = true
+	RequestNextFrame()
+}
+
+// Reload forgets the children of node; they will be listed again if it's expanded
+func (s *TreeState[T]) Reload(node T) {
+	s.init()
+	delete(s.children, node)
+	s.dirty = true
+	RequestNextFrame()
+}
+
+// Select selects a node and scrolls it into view (if it's visible)
+func (s *TreeState[T]) Select(node T) {
+	s.Selected = node
+	s.HasSelected = true
+	s.reveal = true
+}
+
+func (s *TreeState[T]) toggle(node T) {
+	if s.expanded[node] {
+		s.Collapse(node)
+	} else {
+		s.Expand(node)
+	}
+}
+
+// the children of an expanded node, if available; starts loading them if not
+func (s *TreeState[T]) childrenOf(node T, attrs *TreeAttrs[T]) (children []T, ok bool) {
+	if children, ok = s.children[node]; ok {
+		return children, true
+	}
+	if attrs.Children == nil {
+		return nil, true
+	}
+	if !attrs.Async {
+		children = attrs.Children(node)
+		s.children[node] = children
+		return children, true
+	}
+	if !s.loading[node] {
+		s.loading[node] = true
+		go func() {
+			children := attrs.Children(node)
+			WithFrameLock(func() {
+				delete(s.loading, node)
+				s.children[node] = children
+				s.dirty = true
+			})
+			RequestNextFrame()
+		}()
+	}
+	return nil, false
+}
+
+func (s *TreeState[T]) expandable(node T, attrs *TreeAttrs[T]) bool {
+	if children, ok := s.children[node]; ok {
+		return len(children) > 0
+	}
+	if attrs.HasChildren != nil {
+		return attrs.HasChildren(node)
+	}
+	return true
+}
+
+func (s *TreeState[T]) flatten(attrs *TreeAttrs[T]) {
+	s.rows = s.rows[:0]
+	var visit func(node T, depth int, parent int)
+	visit = func(node T, depth int, parent int) {
+		var index = len(s.rows)
+		s.rows = append(s.rows, treeRow[T]{node: node, depth: depth, parent: parent})
+		if !s.expanded[node] {
+			return
+		}
+		children, ok := s.childrenOf(node, attrs)
+		if !ok {
+			s.rows = append(s.rows, treeRow[T]{node: node, depth: depth + 1, parent: index, loading: true})
+			return
+		}
+		for _, child := range children {
+			visit(child, depth+1, index)
+		}
+	}
+	for _, root := range s.roots {
+		visit(root, 0, -1)
+	}
+}
+
+// the row of the selected node, or -1 if it's not visible
+func (s *TreeState[T]) selectedRow() int {
+	if !s.HasSelected {
+		return -1
+	}
+	if s.selIndex >= 0 && s.selIndex < len(s.rows) && !s.rows[s.selIndex].loading && s.rows[s.selIndex].node == s.Selected {
+		return s.selIndex
+	}
+	s.selIndex = slices.IndexFunc(s.rows, func(r treeRow[T]) bool {
+		return !r.loading && r.node == s.Selected
+	})
+	return s.selIndex
+}
+
+// TreeView shows the tree under the given roots; only expanded nodes are
+// listed, so trees of any size can be shown as long as most of it is collapsed
+func TreeView[T comparable](state *TreeState[T], roots []T, attrs TreeAttrs[T]) {
+	state.init()
+	if !slices.Equal(roots, state.roots) {
+		state.roots = append(state.roots[:0], roots...)
+		state.dirty = true
+	}
+	if state.dirty {
+		state.dirty = false
+		state.flatten(&attrs)
+	}
+
+	Layout(TW(Viewport, Focusable, BG(0, 0, 100, 1), BW(1), Bo(0, 0, 70, 1)), func() {
+		FocusOnClick()
+
+		var focused = HasFocus()
+		var current = state.selectedRow()
+
+		if focused && len(state.rows) > 0 {
+			var pageSize = max(1, int(GetResolvedSize()[1]/attrs.RowHeight)-1)
+			var target = current
+
+			moveTo := func(index int) {
+				index = max(0, min(index, len(state.rows)-1))
+				// skip over loading placeholders
+				if state.rows[index].loading {
+					if index > current {
+						index = min(index+1, len(state.rows)-1)
+					} else {
+						index--
+					}
+				}
+				if index >= 0 && !state.rows[index].loading {
+					target = index
+				}
+			}
+
+			switch FrameInput.Key {
+			case KeyUp:
+				moveTo(current - 1)
+			case KeyDown:
+				moveTo(current + 1)
+			case KeyPageUp:
+				moveTo(current - pageSize)
+			case KeyPageDown:
+				moveTo(current + pageSize)
+			case KeyHome:
+				moveTo(0)
+			case KeyEnd:
+				moveTo(len(state.rows) - 1)
+			case KeyRight:
+				// expand, or go to the first child if already expanded
+				if current >= 0 {
+					node := state.rows[current].node
+					if !state.expanded[node] && state.expandable(node, &attrs) {
+						state.Expand(node)
+					} else if state.expanded[node] {
+						moveTo(current + 1)
+					}
+				}
+			case KeyLeft:
+				// collapse, or go to the parent if already collapsed
+				if current >= 0 {
+					row := state.rows[current]
+					if state.expanded[row.node] {
+						state.Collapse(row.node)
+					} else if row.parent >= 0 {
+						target = row.parent
+					}
+				}
+			case KeyEnter, KeySpace:
+				if current >= 0 && state.expandable(state.rows[current].node, &attrs) {
+					state.toggle(state.rows[current].node)
+				}
+			}
+
+			if target != current && target >= 0 {
+				state.Select(state.rows[target].node)
+				state.selIndex = target
+			}
+		}
+
+		// keyboard input may have expanded or collapsed something
+		if state.dirty {
+			state.dirty = false
+			state.flatten(&attrs)
+		}
+		current = state.selectedRow()
+
+		// keyed by node so row state follows nodes as the tree expands and collapses
+		rowId := func(index int) any {
+			return treeRowKey[T]{state.rows[index].node, state.rows[index].loading}
+		}
+
+		rowHeight := func(index int, width f32) f32 {
+			return attrs.RowHeight
+		}
+
+		rowView := func(index int, width f32) {
+			var row = state.rows[index]
+			Layout(TW(Row, Expand, CrossMid, FixHeight(attrs.RowHeight)), func() {
+				if !row.loading {
+					if IsClicked() {
+						state.Select(row.node)
+						state.selIndex = index
+					}
+					switch {
+					case index == current && focused:
+						ModAttrs(BG(210, 70, 85, 1))
+					case index == current:
+						ModAttrs(BG(0, 0, 88, 1))
+					case IsHovered():
+						ModAttrs(BG(210, 40, 96, 1))
+					}
+				}
+
+				// indentation guides
+				for range row.depth {
+					Layout(TW(Row, Expand, FixWidth(attrs.Indent), MA(AlignMiddle)), func() {
+						Element(TW(FixWidth(1), Expand, BG(0, 0, 0, 0.15)))
+					})
+				}
+
+				if row.loading {
+					Label("Loading…", Sz(12), Clr(0, 0, 50, 1))
+					return
+				}
+
+				// expand/collapse arrow
+				Layout(TW(FixWidth(attrs.Indent), Expand, Center), func() {
+					if !state.expandable(row.node, &attrs) {
+						return
+					}
+					if PressAction() {
+						state.toggle(row.node)
+					}
+					var icon = SymRight
+					if state.expanded[row.node] {
+						icon = SymDown
+					}
+					var alpha f32 = 0.5
+					if IsHovered() {
+						alpha = 0.9
+					}
+					Icon(icon, Sz(attrs.Indent*0.75), Clr(0, 0, 20, alpha))
+				})
+
+				Layout(TW(Row, CrossMid, Grow(1), Clip, Gap(4)), func() {
+					if attrs.NodeView != nil {
+						attrs.NodeView(row.node, row.depth)
+					}
+				})
+			})
+		}
+
+		VirtualListViewExt(VirtualListAttrs{Reveal: state.reveal && current >= 0, RevealIndex: current}, len(state.rows), rowId, rowHeight, rowView)
+		state.reveal = false
+	})
+}